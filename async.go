@@ -0,0 +1,206 @@
+package slogjournal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// QueuePolicy controls what Handle does when the queue of a handler with
+// Options.QueueSize is full.
+type QueuePolicy int
+
+const (
+	// QueueBlock makes Handle wait until there is room in the queue, so that
+	// no entries are lost when journald falls behind.
+	QueueBlock QueuePolicy = iota
+	// QueueDrop makes Handle drop the entry and return ErrQueueFull, so that
+	// it never waits for journald. Dropped entries are counted by Close.
+	QueueDrop
+)
+
+// ErrQueueFull is returned by Handle for entries dropped because the queue
+// of the handler is full and Options.QueueFull is QueueDrop.
+var ErrQueueFull = errors.New("journal queue is full")
+
+var errQueueClosed = errors.New("journal queue is closed")
+
+type queueDeadlineKey struct{}
+
+// WithQueueDeadline returns a copy of ctx that makes the queue of a handler
+// with Options.QueueSize drop the records handled with it, rather than
+// write them, if they are still queued at t, e.g. health check results that
+// are worthless once stale. Dropped records are counted by Close as
+// EXPIRED. The deadline of ctx itself is not used, since how long a request
+// may take says nothing about how long its entries stay relevant.
+func WithQueueDeadline(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, queueDeadlineKey{}, t)
+}
+
+// queueDeadline returns the time at which the queue drops an entry handled
+// with ctx, or the zero time if it never does.
+func (h *Handler) queueDeadline(ctx context.Context) time.Time {
+	var deadline time.Time
+	if ctx != nil {
+		deadline, _ = ctx.Value(queueDeadlineKey{}).(time.Time)
+	}
+	if age := h.opts.QueueMaxAge; age > 0 {
+		if t := time.Now().Add(age); deadline.IsZero() || t.Before(deadline) {
+			deadline = t
+		}
+	}
+	return deadline
+}
+
+// queued is an entry, or a request to be notified when the entries queued
+// before it are written, in the queue of an asyncWriter.
+type queued struct {
+	b      *[]byte
+	groups []string
+	// deadline, if not zero, is the time after which the entry is dropped.
+	deadline time.Time
+	done     chan struct{}
+}
+
+// asyncWriter queues entries and writes them to w on a goroutine of its own.
+type asyncWriter struct {
+	w      io.Writer
+	policy QueuePolicy
+	stats  *stats
+	// diagnose reports entries the goroutine fails to write.
+	diagnose func(DiagnosticKind, string, error)
+
+	// mu guards closed; Write and Close hold it while they use q.
+	mu     sync.RWMutex
+	closed bool
+	q      chan queued
+	// stopped is closed when the goroutine returns.
+	stopped chan struct{}
+}
+
+func newAsyncWriter(w io.Writer, size int, policy QueuePolicy, s *stats, diagnose func(DiagnosticKind, string, error)) *asyncWriter {
+	a := &asyncWriter{
+		w:        w,
+		policy:   policy,
+		stats:    s,
+		diagnose: diagnose,
+		q:        make(chan queued, size),
+		stopped:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// run writes the queued entries until the queue is closed.
+func (a *asyncWriter) run() {
+	defer close(a.stopped)
+	gw, _ := a.w.(groupWriter)
+	for e := range a.q {
+		if e.done != nil {
+			close(e.done)
+			continue
+		}
+		if !e.deadline.IsZero() && time.Now().After(e.deadline) {
+			putBuffer(e.b)
+			a.stats.expired.Add(1)
+			continue
+		}
+		var err error
+		if gw != nil {
+			_, err = gw.WriteGroups(*e.b, e.groups)
+		} else {
+			_, err = a.w.Write(*e.b)
+		}
+		putBuffer(e.b)
+		if err != nil {
+			a.stats.dropped.Add(1)
+			a.diagnose(DiagnosticWriterFailed, "writing a queued entry failed", err)
+		}
+	}
+}
+
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	return a.enqueue(p, nil, time.Time{})
+}
+
+func (a *asyncWriter) WriteGroups(p []byte, groups []string) (int, error) {
+	return a.enqueue(p, groups, time.Time{})
+}
+
+// enqueue queues a copy of p, since the buffers of entries are reused once
+// Handle returns, to be written unless it is still queued at deadline.
+func (a *asyncWriter) enqueue(p []byte, groups []string, deadline time.Time) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return 0, errQueueClosed
+	}
+	b := getBuffer()
+	*b = append(*b, p...)
+	e := queued{b: b, groups: groups, deadline: deadline}
+	if a.policy == QueueDrop {
+		select {
+		case a.q <- e:
+		default:
+			putBuffer(b)
+			return 0, ErrQueueFull
+		}
+	} else {
+		a.q <- e
+	}
+	return len(p), nil
+}
+
+// Flush waits until the entries queued so far are written.
+func (a *asyncWriter) Flush() error {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return nil
+	}
+	done := make(chan struct{})
+	a.q <- queued{done: done}
+	a.mu.RUnlock()
+	<-done
+	return nil
+}
+
+// Close writes the queued entries and closes the underlying writer.
+func (a *asyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.q)
+	a.mu.Unlock()
+	<-a.stopped
+	if c, ok := a.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (a *asyncWriter) sink() string {
+	return "queue to " + sinkOf(a.w)
+}
+
+// flusher is implemented by writers that buffer entries.
+type flusher interface {
+	Flush() error
+}
+
+// Flush waits until the entries handled so far by h, and the handlers
+// derived from it, are written, if Options.QueueSize is set. Otherwise
+// entries are written by Handle and Flush does nothing.
+func (h *Handler) Flush() error {
+	if f, ok := h.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+var _ groupWriter = &asyncWriter{}
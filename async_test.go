@@ -0,0 +1,208 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// queueWriter keeps the entries written to it, after failing the first n
+// writes. If gate is non-nil, writes block until it is closed.
+type queueWriter struct {
+	gate    chan struct{}
+	n       int
+	entries []Entry
+	closed  bool
+}
+
+func (w *queueWriter) Write(p []byte) (int, error) {
+	if w.gate != nil {
+		<-w.gate
+	}
+	if w.n > 0 {
+		w.n--
+		return 0, errors.New("write failed")
+	}
+	// p is reused once Write returns.
+	e, err := ParseEntry(bytes.Clone(p))
+	if err != nil {
+		return 0, err
+	}
+	w.entries = append(w.entries, e)
+	return len(p), nil
+}
+
+func (w *queueWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+// message returns the MESSAGE of the i-th entry written to w.
+func (w *queueWriter) message(i int) string {
+	if i >= len(w.entries) {
+		return ""
+	}
+	v, _ := w.entries[i].Get("MESSAGE")
+	return string(v)
+}
+
+func TestQueue(t *testing.T) {
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &queueWriter{gate: make(chan struct{}), n: 1}
+	handler.w = newAsyncWriter(w, 2, QueueDrop, handler.stats, noDiagnose)
+
+	// The first entry is taken off the queue and blocks the writer, the
+	// next two fill the queue.
+	for _, msg := range []string{"first", "second", "third"} {
+		if err := handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)); err != nil {
+			t.Fatal(err)
+		}
+		if msg == "first" {
+			for len(handler.w.(*asyncWriter).q) > 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+	if err := handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "fourth", 0)); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	close(w.gate)
+	if err := handler.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// The first write fails.
+	for i, want := range []string{"second", "third"} {
+		if got := w.message(i); got != want {
+			t.Errorf("expected MESSAGE=%s, got %q", want, got)
+		}
+	}
+
+	if err := handler.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !w.closed {
+		t.Error("expected the writer to be closed")
+	}
+	if len(w.entries) != 3 {
+		t.Fatalf("expected a summary entry, got %d entries", len(w.entries))
+	}
+	if v, _ := w.entries[2].Get(DroppedKey); string(v) != "2" {
+		t.Errorf("expected %s=2, got %q", DroppedKey, v)
+	}
+	if err := handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "closed", 0)); err == nil {
+		t.Error("expected Handle to fail after Close")
+	}
+}
+
+func TestQueueBlock(t *testing.T) {
+	w := &queueWriter{}
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = newAsyncWriter(w, 1, QueueBlock, handler.stats, noDiagnose)
+	logger := slog.New(handler).WithGroup("G")
+	for range 10 {
+		logger.Info("Hello, World!", "KEY", "value")
+	}
+	if err := handler.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.entries) != 10 {
+		t.Fatalf("expected 10 entries, got %d", len(w.entries))
+	}
+	for _, e := range w.entries {
+		if v, _ := e.Get("G_KEY"); string(v) != "value" {
+			t.Errorf("expected G_KEY=value, got %q", v)
+		}
+	}
+}
+
+func TestQueueDeadline(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		maxAge time.Duration
+		ctx    context.Context
+	}{
+		{"Context", time.Hour, WithQueueDeadline(context.Background(), time.Now())},
+		{"MaxAge", time.Millisecond, context.Background()},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, err := NewHandler(&Options{QueueMaxAge: tt.maxAge})
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := &queueWriter{gate: make(chan struct{})}
+			handler.w = newAsyncWriter(w, 2, QueueBlock, handler.stats, noDiagnose)
+
+			// The first entry is taken off the queue and blocks the writer
+			// until the second one is stale.
+			_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "first", 0))
+			for len(handler.w.(*asyncWriter).q) > 0 {
+				time.Sleep(time.Millisecond)
+			}
+			_ = handler.Handle(tt.ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "stale", 0))
+			time.Sleep(10 * time.Millisecond)
+			close(w.gate)
+			if err := handler.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(w.entries) != 2 || w.message(0) != "first" {
+				t.Fatalf("expected the first entry and the Close summary, got %v", w.entries)
+			}
+			if v, _ := w.entries[1].Get(ExpiredKey); string(v) != "1" {
+				t.Errorf("expected %s=1, got %q", ExpiredKey, v)
+			}
+		})
+	}
+}
+
+// diagnosingWriter reports a diagnostic for every entry written to it, like
+// the journal writer does for large entries.
+type diagnosingWriter struct {
+	queueWriter
+	diagnose func(DiagnosticKind, string, error)
+}
+
+func (w *diagnosingWriter) Write(p []byte) (int, error) {
+	if e, err := ParseEntry(p); err == nil {
+		if id, _ := e.Get(MessageIDKey); string(id) != MessageIDDiagnostic {
+			w.diagnose(DiagnosticLargeEntry, "large entry", nil)
+		}
+	}
+	return w.queueWriter.Write(p)
+}
+
+func TestQueueDiagnostics(t *testing.T) {
+	handler, err := NewHandler(&Options{DiagnosticEntries: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &diagnosingWriter{diagnose: handler.diagnose}
+	handler.w = newAsyncWriter(w, 1, QueueBlock, handler.stats, handler.diagnose)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 10 {
+			_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+		}
+		_ = handler.Close()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock writing diagnostics of a full queue")
+	}
+	if len(w.entries) != 20 {
+		t.Errorf("expected 10 entries and 10 diagnostics, got %d entries", len(w.entries))
+	}
+}
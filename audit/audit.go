@@ -0,0 +1,113 @@
+// Package audit logs security-relevant events with stable MESSAGE_IDs and a
+// fixed set of fields, in the spirit of the audit-style events systemd logs
+// itself. Events are logged at NOTICE priority or above, so they survive the
+// usual level filtering.
+//
+// Every event has a MESSAGE_ID identifying its kind, an AUDIT_EVENT field
+// naming it and an AUDIT_USER field. Further fields depend on the kind of
+// event. Query them with e.g.
+//
+//	journalctl MESSAGE_ID=10bef3b4dd9d4dc3a178b5e22d81dc01
+//
+// The schemas of the events are registered with
+// [slogjournal.RegisterSchema], so their fields are not prefixed by the
+// groups of a logger, e.g. AUDIT_USER stays AUDIT_USER under
+// WithGroup("HTTP").
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// Message IDs of the events.
+const (
+	MessageIDAuthSuccess     = "c229133666b643259abbdbfdb3a167cd"
+	MessageIDAuthFailure     = "10bef3b4dd9d4dc3a178b5e22d81dc01"
+	MessageIDPrivilegeChange = "8fa8b92e89fb4749b50e1773d93659ad"
+	MessageIDAccessDenied    = "36b04c95e4914f619ce557aec73ab589"
+)
+
+// Fields of the events.
+const (
+	EventKey     = "AUDIT_EVENT"
+	UserKey      = "AUDIT_USER"
+	MethodKey    = "AUDIT_METHOD"
+	ReasonKey    = "AUDIT_REASON"
+	PrivilegeKey = "AUDIT_PRIVILEGE"
+	ResourceKey  = "AUDIT_RESOURCE"
+)
+
+func init() {
+	required := func(names ...string) []slogjournal.FieldSpec {
+		specs := []slogjournal.FieldSpec{
+			{Name: EventKey, Type: slogjournal.TypeString, Required: true},
+			{Name: UserKey, Type: slogjournal.TypeString, Required: true},
+		}
+		for _, name := range names {
+			specs = append(specs, slogjournal.FieldSpec{Name: name, Type: slogjournal.TypeString, Required: true})
+		}
+		return specs
+	}
+	for _, s := range []slogjournal.Schema{
+		{MessageID: MessageIDAuthSuccess, Name: "AuthSuccess", Level: slogjournal.LevelNotice, Fields: required(MethodKey)},
+		{MessageID: MessageIDAuthFailure, Name: "AuthFailure", Level: slog.LevelWarn, Fields: required(MethodKey, ReasonKey)},
+		{MessageID: MessageIDPrivilegeChange, Name: "PrivilegeChange", Level: slogjournal.LevelNotice, Fields: required(PrivilegeKey)},
+		{MessageID: MessageIDAccessDenied, Name: "AccessDenied", Level: slog.LevelWarn, Fields: required(ResourceKey, ReasonKey)},
+	} {
+		slogjournal.RegisterSchema(s)
+	}
+}
+
+// AuthSuccess logs that user authenticated successfully using method, e.g.
+// "password" or "publickey".
+func AuthSuccess(ctx context.Context, l *slog.Logger, user, method string, attrs ...slog.Attr) {
+	log(ctx, l, slogjournal.LevelNotice, MessageIDAuthSuccess, "auth-success", user,
+		"authentication succeeded for "+user, attrs, slog.String(MethodKey, method))
+}
+
+// AuthFailure logs that user failed to authenticate using method for reason.
+func AuthFailure(ctx context.Context, l *slog.Logger, user, method, reason string, attrs ...slog.Attr) {
+	log(ctx, l, slog.LevelWarn, MessageIDAuthFailure, "auth-failure", user,
+		"authentication failed for "+user+": "+reason,
+		attrs, slog.String(MethodKey, method), slog.String(ReasonKey, reason))
+}
+
+// PrivilegeChange logs that user acquired privilege, e.g. "root" or "admin".
+func PrivilegeChange(ctx context.Context, l *slog.Logger, user, privilege string, attrs ...slog.Attr) {
+	log(ctx, l, slogjournal.LevelNotice, MessageIDPrivilegeChange, "privilege-change", user,
+		user+" acquired privilege "+privilege, attrs, slog.String(PrivilegeKey, privilege))
+}
+
+// AccessDenied logs that user was denied access to resource for reason.
+func AccessDenied(ctx context.Context, l *slog.Logger, user, resource, reason string, attrs ...slog.Attr) {
+	log(ctx, l, slog.LevelWarn, MessageIDAccessDenied, "access-denied", user,
+		"access to "+resource+" denied for "+user+": "+reason,
+		attrs, slog.String(ResourceKey, resource), slog.String(ReasonKey, reason))
+}
+
+// log logs the event with the attrs of the caller followed by the attrs of
+// the event, without appending to the slice of the caller.
+func log(ctx context.Context, l *slog.Logger, level slog.Level, id, event, user, msg string, attrs []slog.Attr, eventAttrs ...slog.Attr) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // skip [Callers, log, AuthSuccess]
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.AddAttrs(
+		slog.String(slogjournal.MessageIDKey, id),
+		slog.String(EventKey, event),
+		slog.String(UserKey, user),
+	)
+	r.AddAttrs(attrs...)
+	r.AddAttrs(eventAttrs...)
+	_ = l.Handler().Handle(ctx, r)
+}
@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	slogjournal "github.com/systemd/slog-journal"
+	"github.com/systemd/slog-journal/journaltest"
+)
+
+type recordHandler struct {
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestEvents(t *testing.T) {
+	h := &recordHandler{}
+	l := slog.New(h)
+	AuthSuccess(context.TODO(), l, "alice", "publickey")
+	AuthFailure(context.TODO(), l, "bob", "password", "wrong password")
+	PrivilegeChange(context.TODO(), l, "alice", "root")
+	AccessDenied(context.TODO(), l, "bob", "/etc/shadow", "not permitted")
+
+	tests := []struct {
+		id     string
+		level  slog.Level
+		fields map[string]string
+	}{
+		{MessageIDAuthSuccess, slog.LevelInfo + 1, map[string]string{UserKey: "alice", MethodKey: "publickey"}},
+		{MessageIDAuthFailure, slog.LevelWarn, map[string]string{UserKey: "bob", ReasonKey: "wrong password"}},
+		{MessageIDPrivilegeChange, slog.LevelInfo + 1, map[string]string{UserKey: "alice", PrivilegeKey: "root"}},
+		{MessageIDAccessDenied, slog.LevelWarn, map[string]string{ResourceKey: "/etc/shadow"}},
+	}
+	if len(h.records) != len(tests) {
+		t.Fatalf("expected %d records, got %d", len(tests), len(h.records))
+	}
+	for i, tt := range tests {
+		r := h.records[i]
+		attrs := map[string]string{}
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value.String()
+			return true
+		})
+		if r.Level != tt.level || attrs["MESSAGE_ID"] != tt.id {
+			t.Errorf("unexpected record %v", r)
+		}
+		for k, v := range tt.fields {
+			if attrs[k] != v {
+				t.Errorf("expected %s=%s, got %q", k, v, attrs[k])
+			}
+		}
+		if r.PC == 0 {
+			t.Error("expected PC")
+		}
+	}
+}
+
+func TestEventsWithGroup(t *testing.T) {
+	// Entries that break the schemas of the events are not written.
+	handler, rec := journaltest.NewHandler(t, &slogjournal.Options{SchemaMode: slogjournal.SchemaReject})
+	AuthFailure(context.TODO(), slog.New(handler.WithGroup("HTTP")), "bob", "password", "wrong password", slog.String("PEER", "192.0.2.1"))
+	e := rec.Last(t)
+	journaltest.FieldEquals(t, e, slogjournal.MessageIDKey, MessageIDAuthFailure)
+	journaltest.NoField(t, e, "HTTP_MESSAGE_ID")
+	journaltest.FieldEquals(t, e, UserKey, "bob")
+	journaltest.FieldEquals(t, e, ReasonKey, "wrong password")
+	journaltest.NoField(t, e, "HTTP_"+UserKey)
+	journaltest.FieldEquals(t, e, "HTTP_PEER", "192.0.2.1")
+}
+
+func TestEventsKeepCallerAttrs(t *testing.T) {
+	h := &recordHandler{}
+	attrs := make([]slog.Attr, 1, 4)
+	attrs[0] = slog.String("REQUEST", "1")
+	AuthFailure(context.TODO(), slog.New(h), "bob", "password", "wrong password", attrs...)
+	if spare := attrs[1:cap(attrs)]; !spare[0].Equal(slog.Attr{}) {
+		t.Errorf("expected the attrs of the caller to be left alone, got %v", spare)
+	}
+	if n := h.records[0].NumAttrs(); n != 6 {
+		t.Errorf("expected 6 attrs, got %d", n)
+	}
+}
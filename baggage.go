@@ -0,0 +1,40 @@
+package slogjournal
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// baggageFields maps the allowed baggage members to their field names.
+func baggageFields(members []string) map[string]string {
+	m := make(map[string]string, len(members))
+	for _, member := range members {
+		m[member] = sanitizeFieldName(member)
+	}
+	return m
+}
+
+// appendBaggage appends the allowed members of the W3C baggage of ctx.
+func (h *Handler) appendBaggage(b []byte, ctx context.Context) []byte {
+	header := h.opts.Baggage(ctx)
+	for header != "" {
+		var member string
+		member, header, _ = strings.Cut(header, ",")
+		member, _, _ = strings.Cut(member, ";")
+		key, value, ok := strings.Cut(member, "=")
+		if !ok {
+			continue
+		}
+		name, ok := h.baggage[strings.TrimSpace(key)]
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if v, err := url.PathUnescape(value); err == nil {
+			value = v
+		}
+		b = h.appendKV(b, name, []byte(value))
+	}
+	return b
+}
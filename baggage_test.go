@@ -0,0 +1,41 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type baggageKey struct{}
+
+func TestBaggage(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{
+		Baggage: func(ctx context.Context) string {
+			s, _ := ctx.Value(baggageKey{}).(string)
+			return s
+		},
+		BaggageMembers: []string{"user.tier", "tenant"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	ctx := context.WithValue(context.Background(), baggageKey{}, "user.tier=gold;ttl=60, session=abc,tenant = acme%20corp ,broken")
+	_ = handler.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{"USER_TIER": "gold", "TENANT": "acme corp"} {
+		if kv[k] != v {
+			t.Errorf("expected %s=%s, got %q", k, v, kv[k])
+		}
+	}
+	if _, ok := kv["SESSION"]; ok {
+		t.Error("expected members not listed to be ignored")
+	}
+}
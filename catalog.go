@@ -0,0 +1,138 @@
+package slogjournal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// CatalogEntry is the entry of the [message catalog] explaining the entries
+// with a MESSAGE_ID, which journalctl -x shows next to them. Register
+// entries with [RegisterCatalogEntry] and write them to a .catalog file with
+// [WriteCatalog], to be installed into /usr/lib/systemd/catalog/ followed by
+// journalctl --update-catalog.
+//
+// [message catalog]: https://systemd.io/CATALOG/
+type CatalogEntry struct {
+	// MessageID is the MESSAGE_ID the entry explains.
+	MessageID string
+	// Subject is a one-line summary of the message.
+	Subject string
+	// DefinedBy names the program or project defining the message.
+	DefinedBy string
+	// Support is a URL where support can be found.
+	Support string
+	// Documentation lists URLs, such as man: or https: URLs, documenting
+	// the message.
+	Documentation []string
+	// Text explains the message and what to do about it. It may refer to
+	// fields of the explained entry as @FIELD@, which journalctl replaces by
+	// their values.
+	Text string
+}
+
+// Event returns an event logged at level with the MESSAGE_ID of c and its
+// subject as the message, so that entries logged with it are explained by
+// c:
+//
+//	var checkFailedEntry = slogjournal.CatalogEntry{
+//		MessageID: "f20bb34dbced4b6c8453932f5742278d",
+//		Subject:   "Health check failed",
+//		Text:      "The health check @CHECK@ failed. ...",
+//	}
+//
+//	var checkFailed = checkFailedEntry.Event(slog.LevelWarn)
+//
+//	func init() { slogjournal.RegisterCatalogEntry(checkFailedEntry) }
+func (c CatalogEntry) Event(level slog.Level) Event {
+	return Event{ID: c.MessageID, Level: level, Message: c.Subject}
+}
+
+var catalog = struct {
+	sync.RWMutex
+	m map[string]CatalogEntry
+}{m: map[string]CatalogEntry{}}
+
+// RegisterCatalogEntry registers c as the catalog entry of the MESSAGE_ID
+// c.MessageID, replacing any previous registration, for [WriteCatalog].
+//
+// RegisterCatalogEntry is meant to be called during initialization.
+func RegisterCatalogEntry(c CatalogEntry) {
+	catalog.Lock()
+	defer catalog.Unlock()
+	catalog.m[c.MessageID] = c
+}
+
+// LookupCatalogEntry returns the catalog entry registered for the
+// MESSAGE_ID id.
+func LookupCatalogEntry(id string) (CatalogEntry, bool) {
+	catalog.RLock()
+	defer catalog.RUnlock()
+	c, ok := catalog.m[id]
+	return c, ok
+}
+
+// WriteCatalog writes the registered catalog entries to w in the format of
+// .catalog files, ordered by MESSAGE_ID. Services can ship it by writing it
+// at build time, e.g. behind a command line flag. It fails on entries with
+// an invalid MESSAGE_ID, a header field spanning several lines or a line of
+// text starting with "-- ", which would start another entry.
+func WriteCatalog(w io.Writer) error {
+	catalog.RLock()
+	entries := make([]CatalogEntry, 0, len(catalog.m))
+	for _, c := range catalog.m {
+		entries = append(entries, c)
+	}
+	catalog.RUnlock()
+	slices.SortFunc(entries, func(a, b CatalogEntry) int {
+		return strings.Compare(strings.ToLower(a.MessageID), strings.ToLower(b.MessageID))
+	})
+
+	bw := bufio.NewWriter(w)
+	for i, c := range entries {
+		if err := c.check(); err != nil {
+			return err
+		}
+		if i > 0 {
+			bw.WriteByte('\n')
+		}
+		fmt.Fprintf(bw, "-- %s\n", strings.ToLower(c.MessageID))
+		for _, h := range [][2]string{
+			{"Subject", c.Subject},
+			{"Defined-By", c.DefinedBy},
+			{"Support", c.Support},
+			{"Documentation", strings.Join(c.Documentation, " ")},
+		} {
+			if h[1] != "" {
+				fmt.Fprintf(bw, "%s: %s\n", h[0], h[1])
+			}
+		}
+		if text := strings.TrimRight(c.Text, "\n"); text != "" {
+			fmt.Fprintf(bw, "\n%s\n", text)
+		}
+	}
+	return bw.Flush()
+}
+
+// check reports the problems of c that make it unfit for a .catalog file.
+func (c CatalogEntry) check() error {
+	if !ValidMessageID(c.MessageID) {
+		return fmt.Errorf("catalog entry: invalid MESSAGE_ID %q", c.MessageID)
+	}
+	for _, v := range append([]string{c.Subject, c.DefinedBy, c.Support}, c.Documentation...) {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("catalog entry %s: header field %q spans several lines", c.MessageID, v)
+		}
+	}
+	for _, line := range strings.Split(c.Text, "\n") {
+		// Such a line would start another entry.
+		if strings.HasPrefix(line, "-- ") {
+			return fmt.Errorf("catalog entry %s: text line %q starts with \"-- \"", c.MessageID, line)
+		}
+	}
+	return nil
+}
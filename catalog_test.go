@@ -0,0 +1,74 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	entry := CatalogEntry{
+		MessageID:     "F20BB34DBCED4B6C8453932F5742278D",
+		Subject:       "Health check failed",
+		DefinedBy:     "example",
+		Documentation: []string{"man:example(8)", "https://example.org/checks"},
+		Text:          "The health check @CHECK@ failed.\n\nCheck the service.\n",
+	}
+	RegisterCatalogEntry(entry)
+	RegisterCatalogEntry(CatalogEntry{MessageID: "0a1b2c3d4e5f60718293a4b5c6d7e8f9", Subject: "Started"})
+	t.Cleanup(func() {
+		catalog.Lock()
+		defer catalog.Unlock()
+		delete(catalog.m, entry.MessageID)
+		delete(catalog.m, "0a1b2c3d4e5f60718293a4b5c6d7e8f9")
+	})
+	if c, ok := LookupCatalogEntry(entry.MessageID); !ok || c.Subject != entry.Subject {
+		t.Errorf("expected the registered entry, got %v", c)
+	}
+
+	var b strings.Builder
+	if err := WriteCatalog(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `-- 0a1b2c3d4e5f60718293a4b5c6d7e8f9
+Subject: Started
+
+-- f20bb34dbced4b6c8453932f5742278d
+Subject: Health check failed
+Defined-By: example
+Documentation: man:example(8) https://example.org/checks
+
+The health check @CHECK@ failed.
+
+Check the service.
+`
+	if b.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, b.String())
+	}
+
+	w := &queueWriter{}
+	handler, err := NewHandlerWithWriter(w, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Event(slog.LevelWarn).Log(context.Background(), slog.New(handler), slog.String("CHECK", "disk"))
+	if v, _ := w.entries[0].Get(MessageIDKey); string(v) != entry.MessageID {
+		t.Errorf("expected MESSAGE_ID=%s, got %q", entry.MessageID, v)
+	}
+	if got := w.message(0); got != entry.Subject {
+		t.Errorf("expected MESSAGE=%s, got %q", entry.Subject, got)
+	}
+}
+
+func TestCatalogInvalid(t *testing.T) {
+	for _, c := range []CatalogEntry{
+		{MessageID: "not-an-id"},
+		{MessageID: "f20bb34dbced4b6c8453932f5742278d", Subject: "two\nlines"},
+		{MessageID: "f20bb34dbced4b6c8453932f5742278d", Text: "text\n-- 0a1b2c3d4e5f60718293a4b5c6d7e8f9"},
+	} {
+		if err := c.check(); err == nil {
+			t.Errorf("expected %+v to be invalid", c)
+		}
+	}
+}
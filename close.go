@@ -0,0 +1,114 @@
+package slogjournal
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MessageIDHandlerClosed is the MESSAGE_ID of the entry Close writes to
+// summarize the entries that were lost.
+const MessageIDHandlerClosed = "15a0876e7594405288b2e7450d120334"
+
+// Fields of the entry written by Close.
+const (
+	DroppedKey        = "DROPPED"
+	RejectedKey       = "REJECTED"
+	TruncatedCountKey = "TRUNCATED_COUNT"
+	ExpiredKey        = "EXPIRED"
+)
+
+// stats count the entries a handler and the handlers derived from it lost.
+type stats struct {
+	// dropped counts entries that could not be written.
+	dropped atomic.Uint64
+	// rejected counts entries rejected by their schema.
+	rejected atomic.Uint64
+	// truncated counts entries truncated to fit the static buffer.
+	truncated atomic.Uint64
+	// suppressed counts entries suppressed by the rate limit.
+	suppressed atomic.Uint64
+	// expired counts entries dropped by the queue at their deadline.
+	expired atomic.Uint64
+
+	// sanitized records the field names renamed by FieldNamesSanitize.
+	sanitized sanitizedNames
+
+	// diagnosing is set while a diagnostic entry is written.
+	diagnosing atomic.Bool
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// root returns a handler for the entries h writes about itself. It writes
+// to the writer of h, but without the attributes and groups of h, without
+// checking schemas, and without expiring in the queue.
+func (h *Handler) root() *Handler {
+	root := &Handler{opts: h.opts, w: h.w, cache: h.cache, identifier: h.identifier, redact: h.redact, static: h.static, stats: &stats{}}
+	root.opts.SchemaMode = SchemaIgnore
+	root.opts.QueueMaxAge = 0
+	return root
+}
+
+// Close closes the handler and the handlers derived from it with WithAttrs
+// and WithGroup. If any entries were dropped because they could not be
+// written, rejected by their schema, truncated, suppressed by the rate
+// limit or expired in the queue during the lifetime of the handler, Close
+// first writes a final entry at LevelWarn with the MESSAGE_ID
+// [MessageIDHandlerClosed] that counts them in the fields DROPPED,
+// REJECTED, TRUNCATED_COUNT and, if any, SUPPRESSED and EXPIRED, so that
+// data loss leaves a trace.
+//
+// With Options.DedupWindow, Close first writes the entries counting the
+// repeats of records that were not written again.
+//
+// With Options.QueueSize, Close first waits for the queued entries to be
+// written, and stops the goroutine writing them.
+//
+// The handler must not be used after Close. Calling Close more than once
+// has no effect.
+func (h *Handler) Close() error {
+	h.stats.closeOnce.Do(func() {
+		if h.dedup != nil {
+			h.writeRepeated(h.dedup.flush())
+		}
+		// Queued entries may still fail to be written.
+		_ = h.Flush()
+		dropped := h.stats.dropped.Load()
+		rejected := h.stats.rejected.Load()
+		truncated := h.stats.truncated.Load()
+		suppressed := h.stats.suppressed.Load()
+		expired := h.stats.expired.Load()
+		if dropped+rejected+truncated+suppressed+expired > 0 {
+			msg := "slog-journal handler closed: " + strconv.FormatUint(dropped, 10) + " entries dropped, " +
+				strconv.FormatUint(rejected, 10) + " rejected, " + strconv.FormatUint(truncated, 10) + " truncated"
+			r := slog.NewRecord(time.Now(), slog.LevelWarn, msg, 0)
+			r.AddAttrs(
+				slog.String(MessageIDKey, MessageIDHandlerClosed),
+				slog.Uint64(DroppedKey, dropped),
+				slog.Uint64(RejectedKey, rejected),
+				slog.Uint64(TruncatedCountKey, truncated),
+			)
+			if suppressed > 0 {
+				r.Message += ", " + strconv.FormatUint(suppressed, 10) + " suppressed"
+				r.AddAttrs(slog.Uint64(SuppressedKey, suppressed))
+			}
+			if expired > 0 {
+				r.Message += ", " + strconv.FormatUint(expired, 10) + " expired"
+				r.AddAttrs(slog.Uint64(ExpiredKey, expired))
+			}
+			h.stats.closeErr = h.root().Handle(context.Background(), r)
+		}
+		if c, ok := h.w.(io.Closer); ok {
+			if err := c.Close(); err != nil && h.stats.closeErr == nil {
+				h.stats.closeErr = err
+			}
+		}
+	})
+	return h.stats.closeErr
+}
@@ -0,0 +1,93 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingWriter fails the first n writes and buffers the others.
+type failingWriter struct {
+	n      int
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n > 0 {
+		w.n--
+		return 0, errors.New("write failed")
+	}
+	return w.buf.Write(p)
+}
+
+func (w *failingWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestClose(t *testing.T) {
+	handler, err := NewHandler(&Options{StaticBufferSize: 256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &failingWriter{n: 2}
+	handler.w = w
+	logger := slog.New(handler).With("SERVICE", "test")
+
+	logger.Info("first")
+	logger.Info("second")
+	logger.Info("large", "HUGE", strings.Repeat("x", 512))
+	w.buf.Reset()
+
+	if err := handler.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !w.closed {
+		t.Error("expected the writer to be closed")
+	}
+	kv, err := deserializeKeyValue(&w.buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{
+		MessageIDKey:      MessageIDHandlerClosed,
+		"PRIORITY":        "4",
+		DroppedKey:        "2",
+		RejectedKey:       "0",
+		TruncatedCountKey: "1",
+	} {
+		if kv[k] != v {
+			t.Errorf("expected %s=%s, got %q", k, v, kv[k])
+		}
+	}
+	if _, ok := kv["SERVICE"]; ok {
+		t.Error("expected the summary without the attributes of the logger")
+	}
+
+	w.buf.Reset()
+	if err := handler.Close(); err != nil || w.buf.Len() != 0 {
+		t.Errorf("expected second Close to do nothing, got %v, %q", err, w.buf.String())
+	}
+}
+
+func TestCloseNothingLost(t *testing.T) {
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &failingWriter{}
+	handler.w = w
+	_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+	w.buf.Reset()
+	if err := handler.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if w.buf.Len() != 0 {
+		t.Errorf("expected no summary, got %q", w.buf.String())
+	}
+}
@@ -0,0 +1,180 @@
+// Command slog-journal-bench measures the throughput of the journal handler
+// against the local journald, or against a stand-in for it.
+//
+// Usage:
+//
+//	slog-journal-bench -c 64 -d 10s -size 256 -attrs 8
+//
+// It logs from -c goroutines for -d, each entry carrying a message of -size
+// bytes and -attrs attributes, and reports the number of entries per second,
+// the heap allocations per entry and the number of entries the handler
+// failed to write.
+//
+// -target selects where the entries go: "journald" sends them to the socket
+// of journald, or -socket, and fails if it does not exist, since the handler
+// silently drops entries while journald is not running. Entries that
+// journald itself drops, e.g. because of its rate limiting, are not counted;
+// check journalctl for "Suppressed" messages. "relay" sends them to a
+// slogjournal.Server on a temporary socket and "recorder" to a
+// journaltest.Recorder in memory, which both also report the number of
+// entries that arrived.
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+	"github.com/systemd/slog-journal/journaltest"
+)
+
+type config struct {
+	concurrency int
+	duration    time.Duration
+	size        int
+	attrs       int
+}
+
+type result struct {
+	entries  uint64
+	failures uint64
+	elapsed  time.Duration
+	allocs   uint64
+	bytes    uint64
+}
+
+func main() {
+	var cfg config
+	flag.IntVar(&cfg.concurrency, "c", runtime.GOMAXPROCS(0), "number of logging goroutines")
+	flag.DurationVar(&cfg.duration, "d", 5*time.Second, "duration of the benchmark")
+	flag.IntVar(&cfg.size, "size", 64, "size of the message in bytes")
+	flag.IntVar(&cfg.attrs, "attrs", 4, "number of attributes per entry")
+	name := flag.String("target", "journald", "where to send entries: journald, relay or recorder")
+	socket := flag.String("socket", "", "path of the socket of journald (default "+journalSocket+")")
+	flag.Parse()
+
+	t, err := newTarget(*name, &slogjournal.Options{SyslogIdentifier: "slog-journal-bench", SocketPath: *socket})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "slog-journal-bench:", err)
+		os.Exit(1)
+	}
+	defer t.close()
+	r := run(t.handler, cfg)
+	perEntry := func(n uint64) uint64 {
+		if r.entries == 0 {
+			return 0
+		}
+		return n / r.entries
+	}
+	fmt.Printf("entries:       %d in %v\n", r.entries, r.elapsed.Round(time.Millisecond))
+	fmt.Printf("entries/sec:   %.0f\n", float64(r.entries)/r.elapsed.Seconds())
+	fmt.Printf("allocs/entry:  %d (%d B)\n", perEntry(r.allocs), perEntry(r.bytes))
+	fmt.Printf("failed:        %d (%.2f%%)\n", r.failures, 100*float64(r.failures)/float64(max(r.entries+r.failures, 1)))
+	if t.received != nil {
+		received := t.received()
+		fmt.Printf("received:      %d (%d lost)\n", received, r.entries-min(received, r.entries))
+	}
+}
+
+// journalSocket is the socket journald listens on for the native protocol.
+const journalSocket = "/run/systemd/journal/socket"
+
+// target is where the benchmark sends entries.
+type target struct {
+	handler *slogjournal.Handler
+	// received, if not nil, returns the number of entries that arrived.
+	received func() uint64
+	close    func()
+}
+
+// newTarget returns the target named name, with a handler created with
+// opts.
+func newTarget(name string, opts *slogjournal.Options) (*target, error) {
+	switch name {
+	case "journald":
+		path := cmp.Or(opts.SocketPath, journalSocket)
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("journald is not running: %w", err)
+		}
+		h, err := slogjournal.NewHandler(opts)
+		if err != nil {
+			return nil, err
+		}
+		return &target{handler: h, close: func() { _ = h.Close() }}, nil
+	case "recorder":
+		rec := &journaltest.Recorder{}
+		h, err := slogjournal.NewHandlerWithWriter(rec, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &target{
+			handler:  h,
+			received: func() uint64 { return uint64(len(rec.Entries())) },
+			close:    func() { _ = h.Close() },
+		}, nil
+	case "relay":
+		return newRelayTarget(opts)
+	default:
+		return nil, errors.New("unknown target " + strconv.Quote(name))
+	}
+}
+
+// run logs with h as configured by cfg.
+func run(h slog.Handler, cfg config) result {
+	msg := strings.Repeat("x", cfg.size)
+	attrs := make([]slog.Attr, cfg.attrs)
+	for i := range attrs {
+		attrs[i] = slog.Int("ATTR_"+strconv.Itoa(i), i)
+	}
+
+	var entries, failures atomic.Uint64
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	ctx := context.Background()
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for range cfg.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var pcs [1]uintptr
+			for !stop.Load() {
+				runtime.Callers(1, pcs[:])
+				r := slog.NewRecord(time.Now(), slog.LevelInfo, msg, pcs[0])
+				r.AddAttrs(attrs...)
+				if err := h.Handle(ctx, r); err != nil {
+					failures.Add(1)
+				} else {
+					entries.Add(1)
+				}
+			}
+		}()
+	}
+	time.Sleep(cfg.duration)
+	stop.Store(true)
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	return result{
+		entries:  entries.Load(),
+		failures: failures.Load(),
+		elapsed:  elapsed,
+		allocs:   after.Mallocs - before.Mallocs,
+		bytes:    after.TotalAlloc - before.TotalAlloc,
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+type countingHandler struct {
+	n atomic.Int64
+}
+
+func (h *countingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *countingHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *countingHandler) WithGroup(string) slog.Handler            { return h }
+func (h *countingHandler) Handle(_ context.Context, r slog.Record) error {
+	if h.n.Add(1)%2 == 0 {
+		return errors.New("dropped")
+	}
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	var h countingHandler
+	r := run(&h, config{concurrency: 4, duration: 10 * time.Millisecond, size: 16, attrs: 2})
+	if r.entries == 0 || r.failures == 0 {
+		t.Errorf("expected entries and failures, got %+v", r)
+	}
+	if got := r.entries + r.failures; got != uint64(h.n.Load()) {
+		t.Errorf("expected %d entries, got %d", h.n.Load(), got)
+	}
+}
+
+func TestTargets(t *testing.T) {
+	for _, name := range []string{"recorder", "relay"} {
+		t.Run(name, func(t *testing.T) {
+			tg, err := newTarget(name, &slogjournal.Options{})
+			if err != nil {
+				t.Skip(err)
+			}
+			defer tg.close()
+			r := run(tg.handler, config{concurrency: 2, duration: 10 * time.Millisecond, size: 16, attrs: 2})
+			if r.entries == 0 || r.failures != 0 {
+				t.Errorf("expected entries and no failures, got %+v", r)
+			}
+			if got := tg.received(); got != r.entries {
+				t.Errorf("expected %d entries to arrive, got %d", r.entries, got)
+			}
+		})
+	}
+
+	if _, err := newTarget("journald", &slogjournal.Options{SocketPath: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("expected a missing socket to fail")
+	}
+	if _, err := newTarget("unknown", &slogjournal.Options{}); err == nil {
+		t.Error("expected an unknown target to fail")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+func newRelayTarget(*slogjournal.Options) (*target, error) {
+	return nil, errors.New("the relay target is only supported on unix")
+}
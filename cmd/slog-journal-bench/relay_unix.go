@@ -0,0 +1,60 @@
+//go:build unix
+
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// newRelayTarget returns a target sending entries to a Server listening on
+// a temporary socket.
+func newRelayTarget(opts *slogjournal.Options) (*target, error) {
+	dir, err := os.MkdirTemp("", "slog-journal-bench")
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	var received atomic.Uint64
+	s := &slogjournal.Server{HandleEntry: func(slogjournal.Entry) { received.Add(1) }}
+	go s.Serve(conn)
+
+	opts.SocketPath = path
+	h, err := slogjournal.NewHandler(opts)
+	if err != nil {
+		s.Close()
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &target{
+		handler: h,
+		// Entries still in the buffer of the socket arrive after the last
+		// one was sent.
+		received: func() uint64 {
+			n := received.Load()
+			for {
+				time.Sleep(100 * time.Millisecond)
+				m := received.Load()
+				if m == n {
+					return n
+				}
+				n = m
+			}
+		},
+		close: func() {
+			_ = h.Close()
+			s.Close()
+			os.RemoveAll(dir)
+		},
+	}, nil
+}
@@ -0,0 +1,326 @@
+// Command slog-journal-cat reads structured log lines from standard input and
+// submits each of them to the journal as one entry.
+//
+// Usage:
+//
+//	some-tool --log-format=json | slog-journal-cat -t some-tool
+//
+// Lines may be JSON objects or logfmt, e.g.
+//
+//	{"level":"warn","msg":"disk almost full","mount":"/var","used":0.93}
+//	level=warn msg="disk almost full" mount=/var used=0.93
+//
+// The message and level are taken from the first key of -message-key and
+// -level-key present on a line, and the timestamp from -time-key if it is in
+// RFC 3339 format. Levels are names or numbers of syslog priorities or slog
+// levels, or names mapped to one by -level-map, such as "fatal" and "trace"
+// by default; other levels are logged at the default level. All other keys
+// become journal fields: they are renamed as given by -rename, then
+// converted to valid field names as by [slogjournal.FieldNamesSanitize], so
+// "http.status" becomes HTTP_STATUS. Nested JSON objects are flattened the
+// same way as slog groups.
+// Lines that are neither are logged as the message at the default level.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+type config struct {
+	format     string
+	level      slog.Level
+	messageKey []string
+	levelKey   []string
+	timeKey    []string
+	rename     map[string]string
+	// levelMap maps lower-case level names ParseLevel does not know.
+	levelMap map[string]slog.Level
+}
+
+// defaultLevelMap maps the levels of common loggers that are not syslog
+// priorities or slog levels.
+var defaultLevelMap = map[string]slog.Level{
+	"trace":  slogjournal.LevelTrace,
+	"dpanic": slogjournal.LevelCritical,
+	"fatal":  slogjournal.LevelCritical,
+	"panic":  slogjournal.LevelAlert,
+}
+
+func main() {
+	cfg := config{rename: map[string]string{}, levelMap: maps.Clone(defaultLevelMap)}
+	identifier := flag.String("t", "", "syslog identifier of the entries (default the name of the command)")
+	level := flag.String("p", "info", "default level or syslog priority of the entries")
+	flag.StringVar(&cfg.format, "format", "auto", "input format: json, logfmt or auto")
+	messageKey := flag.String("message-key", "msg,message,MESSAGE", "comma-separated keys of the message")
+	levelKey := flag.String("level-key", "level,severity,PRIORITY", "comma-separated keys of the level")
+	timeKey := flag.String("time-key", "time,ts,timestamp", "comma-separated keys of the timestamp")
+	flag.Func("rename", "rename a key, as `OLD=NEW` (may be repeated)", func(s string) error {
+		old, name, ok := strings.Cut(s, "=")
+		if !ok || old == "" || name == "" {
+			return errors.New("expected OLD=NEW")
+		}
+		cfg.rename[old] = name
+		return nil
+	})
+	flag.Func("level-map", "map a level name to a level or syslog priority, as `NAME=LEVEL` (may be repeated)", func(s string) error {
+		name, level, ok := strings.Cut(s, "=")
+		if !ok || name == "" {
+			return errors.New("expected NAME=LEVEL")
+		}
+		l, err := slogjournal.ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		cfg.levelMap[strings.ToLower(name)] = l
+		return nil
+	})
+	flag.Parse()
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	switch cfg.format {
+	case "auto", "json", "logfmt":
+	default:
+		fatal(fmt.Errorf("unknown format %q", cfg.format))
+	}
+
+	var err error
+	if cfg.level, err = slogjournal.ParseLevel(*level); err != nil {
+		fatal(err)
+	}
+	cfg.messageKey = strings.Split(*messageKey, ",")
+	cfg.levelKey = strings.Split(*levelKey, ",")
+	cfg.timeKey = strings.Split(*timeKey, ",")
+
+	h, err := slogjournal.NewHandler(options(*identifier))
+	if err != nil {
+		fatal(err)
+	}
+	if err := cat(os.Stdin, h, cfg); err != nil {
+		fatal(err)
+	}
+}
+
+// options returns the options of the handler submitting the entries.
+func options(identifier string) *slogjournal.Options {
+	return &slogjournal.Options{
+		Level:            slog.Level(math.MinInt),
+		SourceFields:     slogjournal.NoSource,
+		SyslogIdentifier: identifier,
+		FieldNames:       slogjournal.FieldNamesSanitize,
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "slog-journal-cat:", err)
+	os.Exit(1)
+}
+
+// cat handles every line of r as a record with h.
+func cat(r io.Reader, h slog.Handler, cfg config) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		line := s.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := h.Handle(context.Background(), cfg.record(line)); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+// record returns the record of line.
+func (cfg config) record(line string) slog.Record {
+	var kvs []kv
+	switch {
+	case cfg.format == "json", cfg.format == "auto" && strings.HasPrefix(strings.TrimSpace(line), "{"):
+		kvs = parseJSON(line)
+	case cfg.format == "logfmt", cfg.format == "auto":
+		kvs = parseLogfmt(line)
+	}
+	if kvs == nil {
+		return slog.NewRecord(time.Now(), cfg.level, line, 0)
+	}
+
+	msg, level, t := "", cfg.level, time.Time{}
+	var attrs []slog.Attr
+	for _, f := range kvs {
+		s, isString := f.value.(string)
+		switch {
+		case isString && msg == "" && slices.Contains(cfg.messageKey, f.key):
+			msg = s
+			continue
+		case slices.Contains(cfg.levelKey, f.key):
+			level = cfg.parseLevel(fmt.Sprint(f.value))
+			continue
+		case isString && t.IsZero() && slices.Contains(cfg.timeKey, f.key):
+			if pt, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				t = pt
+				continue
+			}
+		}
+		attrs = append(attrs, cfg.attr(f.key, f.value))
+	}
+	if t.IsZero() {
+		t = time.Now()
+	}
+	r := slog.NewRecord(t, level, msg, 0)
+	r.AddAttrs(attrs...)
+	return r
+}
+
+// parseLevel returns the level named s, or the default level if there is
+// none.
+func (cfg config) parseLevel(s string) slog.Level {
+	if l, ok := cfg.levelMap[strings.ToLower(s)]; ok {
+		return l
+	}
+	if l, err := slogjournal.ParseLevel(s); err == nil {
+		return l
+	}
+	return cfg.level
+}
+
+// attr returns the attribute of the value v of key.
+func (cfg config) attr(key string, v any) slog.Attr {
+	if name, ok := cfg.rename[key]; ok {
+		key = name
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		attrs := make([]slog.Attr, 0, len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			attrs = append(attrs, cfg.attr(k, v[k]))
+		}
+		return slog.Attr{Key: key, Value: slog.GroupValue(attrs...)}
+	case string:
+		return slog.String(key, v)
+	case nil:
+		return slog.String(key, "")
+	case json.Number:
+		return slog.String(key, v.String())
+	case bool:
+		return slog.Bool(key, v)
+	default:
+		b, _ := json.Marshal(v)
+		return slog.String(key, string(b))
+	}
+}
+
+type kv struct {
+	key   string
+	value any
+}
+
+// parseJSON returns the keys and values of the JSON object line in order, or
+// nil if line is not a JSON object.
+func parseJSON(line string) []kv {
+	d := json.NewDecoder(strings.NewReader(line))
+	d.UseNumber()
+	if tok, err := d.Token(); err != nil || tok != json.Delim('{') {
+		return nil
+	}
+	kvs := []kv{}
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			return nil
+		}
+		var v any
+		if err := d.Decode(&v); err != nil {
+			return nil
+		}
+		kvs = append(kvs, kv{tok.(string), v})
+	}
+	if _, err := d.Token(); err != nil {
+		return nil
+	}
+	return kvs
+}
+
+// parseLogfmt returns the keys and values of the logfmt line, or nil if line
+// is not logfmt. Keys without a value are true, but at least one key must
+// have a value so that plain text is not mistaken for logfmt.
+func parseLogfmt(line string) []kv {
+	var kvs []kv
+	pairs := false
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			if !pairs {
+				return nil
+			}
+			return kvs
+		}
+		end := strings.IndexAny(line, "= \t")
+		if end == 0 {
+			return nil
+		}
+		if end < 0 {
+			end = len(line)
+		}
+		key := line[:end]
+		if strings.ContainsRune(key, '"') {
+			return nil
+		}
+		line = line[end:]
+		if !strings.HasPrefix(line, "=") {
+			kvs = append(kvs, kv{key, true})
+			continue
+		}
+		line = line[1:]
+		pairs = true
+		if strings.HasPrefix(line, `"`) {
+			n := quotedLen(line)
+			if n < 0 {
+				return nil
+			}
+			var v string
+			if err := json.Unmarshal([]byte(line[:n]), &v); err != nil {
+				return nil
+			}
+			kvs = append(kvs, kv{key, v})
+			line = line[n:]
+			continue
+		}
+		end = strings.IndexAny(line, " \t")
+		if end < 0 {
+			end = len(line)
+		}
+		kvs = append(kvs, kv{key, line[:end]})
+		line = line[end:]
+	}
+}
+
+// quotedLen returns the length of the quoted string at the start of s, or -1
+// if it is not terminated.
+func quotedLen(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
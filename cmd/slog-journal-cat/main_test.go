@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+type recorder struct {
+	records []slog.Record
+}
+
+func (r *recorder) Enabled(context.Context, slog.Level) bool { return true }
+func (r *recorder) WithAttrs([]slog.Attr) slog.Handler       { return r }
+func (r *recorder) WithGroup(string) slog.Handler            { return r }
+func (r *recorder) Handle(_ context.Context, rec slog.Record) error {
+	r.records = append(r.records, rec)
+	return nil
+}
+
+func attrs(r slog.Record) map[string]string {
+	m := map[string]string{}
+	r.Attrs(func(a slog.Attr) bool {
+		m[a.Key] = a.Value.String()
+		return true
+	})
+	return m
+}
+
+func TestCat(t *testing.T) {
+	cfg := config{
+		format:     "auto",
+		level:      slog.LevelInfo,
+		messageKey: []string{"msg"},
+		levelKey:   []string{"level"},
+		timeKey:    []string{"time"},
+		rename:     map[string]string{"mount": "MOUNT_POINT"},
+	}
+	input := `{"level":"warn","msg":"disk almost full","mount":"/var","used":0.93,"time":"2024-05-01T12:00:00Z"}
+level=crit msg="disk \"full\"" mount=/var http.status=507 retry
+
+just some text
+{"msg":"nested","req":{"id":7},"tags":["a","b"]}
+`
+	var rec recorder
+	if err := cat(strings.NewReader(input), &rec, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.records) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(rec.records))
+	}
+
+	r := rec.records[0]
+	if r.Message != "disk almost full" || r.Level != slog.LevelWarn || !r.Time.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected record %v", r)
+	}
+	if got := attrs(r); got["MOUNT_POINT"] != "/var" || got["used"] != "0.93" || len(got) != 2 {
+		t.Errorf("unexpected attrs %v", got)
+	}
+
+	r = rec.records[1]
+	if r.Message != `disk "full"` || r.Level != slogjournal.LevelCritical {
+		t.Errorf("unexpected record %v", r)
+	}
+	if got := attrs(r); got["http.status"] != "507" || got["retry"] != "true" || got["MOUNT_POINT"] != "/var" {
+		t.Errorf("unexpected attrs %v", got)
+	}
+
+	r = rec.records[2]
+	if r.Message != "just some text" || r.Level != slog.LevelInfo || r.NumAttrs() != 0 {
+		t.Errorf("unexpected record %v", r)
+	}
+
+	r = rec.records[3]
+	if got := attrs(r); got["req"] != "[id=7]" || got["tags"] != `["a","b"]` {
+		t.Errorf("unexpected attrs %v", got)
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	var w entryWriter
+	h, err := slogjournal.NewHandlerWithWriter(&w, options("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config{format: "json", level: slog.LevelInfo, messageKey: []string{"msg"}}
+	input := `{"msg":"names","user":"u","http.status":200,"_private":"p","1st":"f","req":{"id":7}}`
+	if err := cat(strings.NewReader(input), h, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(w.entries))
+	}
+	for name, want := range map[string]string{
+		"USER":        "u",
+		"HTTP_STATUS": "200",
+		"PRIVATE":     "p",
+		"FIELD_1ST":   "f",
+		"REQ_ID":      "7",
+	} {
+		if v, _ := w.entries[0].Get(name); string(v) != want {
+			t.Errorf("expected %s=%s, got %q", name, want, v)
+		}
+	}
+}
+
+// entryWriter records the entries written to it.
+type entryWriter struct {
+	entries []slogjournal.Entry
+}
+
+func (w *entryWriter) Write(b []byte) (int, error) {
+	e, err := slogjournal.ParseEntry(bytes.Clone(b))
+	if err != nil {
+		return 0, err
+	}
+	w.entries = append(w.entries, e)
+	return len(b), nil
+}
+
+func TestCatLevels(t *testing.T) {
+	cfg := config{
+		format:     "json",
+		level:      slog.LevelInfo,
+		messageKey: []string{"msg"},
+		levelKey:   []string{"level"},
+		levelMap:   map[string]slog.Level{"fatal": slogjournal.LevelCritical, "verbose": slog.LevelDebug},
+	}
+	input := `{"level":"FATAL","msg":"a"}
+{"level":"verbose","msg":"b"}
+{"level":"unknown","msg":"c"}
+{"level":"5","msg":"d"}
+{"msg":"e","obj":{"b":2,"a":1,"c":3}}
+`
+	var rec recorder
+	if err := cat(strings.NewReader(input), &rec, cfg); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []slog.Level{slogjournal.LevelCritical, slog.LevelDebug, slog.LevelInfo, slogjournal.LevelNotice} {
+		r := rec.records[i]
+		if r.Level != want || r.NumAttrs() != 0 {
+			t.Errorf("%s: expected level %v and no attrs, got %v and %v", r.Message, want, r.Level, attrs(r))
+		}
+	}
+	if got := attrs(rec.records[4]); got["obj"] != "[a=1 b=2 c=3]" {
+		t.Errorf("expected the keys of nested objects in order, got %v", got)
+	}
+}
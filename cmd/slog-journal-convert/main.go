@@ -0,0 +1,177 @@
+// Command slog-journal-convert converts journal entries between the native
+// protocol, the journal export format and journalctl's JSON format.
+//
+// Usage:
+//
+//	slog-journal-convert -from native -to json entry.native...
+//	journalctl -o export | slog-journal-convert -from export -to json
+//	slog-journal-convert -from json -to native -dir fixtures/ entries.json
+//
+// The formats are:
+//
+//   - native: one entry per file, as sent to journald in a single datagram.
+//   - export: entries separated by an empty line, as written by
+//     journalctl -o export and read by systemd-journal-remote.
+//   - json: one JSON object per line, as written by journalctl -o json.
+//
+// Input is read from the files given as arguments, or from standard input.
+// Native output of more than one entry needs -dir, which receives one file
+// per entry; all other output goes to standard output. Entries written in the
+// export and JSON formats without a __REALTIME_TIMESTAMP get one from their
+// SYSLOG_TIMESTAMP, if any, or from the current time, since
+// systemd-journal-remote requires it.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+func main() {
+	from := flag.String("from", "native", "input format: native, export or json")
+	to := flag.String("to", "json", "output format: native, export or json")
+	dir := flag.String("dir", "", "directory to write native entries to, one file per entry")
+	flag.Parse()
+
+	entries, err := read(*from, flag.Args())
+	if err != nil {
+		fatal(err)
+	}
+	if *to == "native" && *dir != "" {
+		err = writeNativeDir(*dir, entries)
+	} else {
+		w := bufio.NewWriter(os.Stdout)
+		err = write(w, *to, entries)
+		if err == nil {
+			err = w.Flush()
+		}
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "slog-journal-convert:", err)
+	os.Exit(1)
+}
+
+// read reads the entries of the files named by args in format, or of
+// standard input if there are none.
+func read(format string, args []string) ([]slogjournal.Entry, error) {
+	var parse func([]byte) ([]slogjournal.Entry, error)
+	switch format {
+	case "native":
+		parse = func(b []byte) ([]slogjournal.Entry, error) {
+			e, err := slogjournal.ParseEntry(b)
+			return []slogjournal.Entry{e}, err
+		}
+	case "export":
+		parse = slogjournal.ParseExport
+	case "json":
+		parse = parseJSON
+	default:
+		return nil, fmt.Errorf("unknown input format %q", format)
+	}
+
+	if len(args) == 0 {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		return parse(b)
+	}
+	var entries []slogjournal.Entry
+	for _, name := range args {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		e, err := parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		entries = append(entries, e...)
+	}
+	return entries, nil
+}
+
+// write writes entries to w in format.
+func write(w io.Writer, format string, entries []slogjournal.Entry) error {
+	for _, e := range entries {
+		var b []byte
+		switch format {
+		case "native":
+			if len(entries) > 1 {
+				return errors.New("native output of more than one entry needs -dir")
+			}
+			b = e.AppendNative(nil)
+		case "export":
+			b = withRealtime(e).AppendExport(nil)
+		case "json":
+			var err error
+			if b, err = json.Marshal(withRealtime(e)); err != nil {
+				return err
+			}
+			b = append(b, '\n')
+		default:
+			return fmt.Errorf("unknown output format %q", format)
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNativeDir writes every entry in the native format to its own file in dir.
+func writeNativeDir(dir string, entries []slogjournal.Entry) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for i, e := range entries {
+		name := filepath.Join(dir, fmt.Sprintf("entry-%04d.native", i+1))
+		if err := os.WriteFile(name, e.AppendNative(nil), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withRealtime returns e with a __REALTIME_TIMESTAMP field.
+func withRealtime(e slogjournal.Entry) slogjournal.Entry {
+	if _, ok := e.Get("__REALTIME_TIMESTAMP"); ok {
+		return e
+	}
+	ts, ok := e.Get("SYSLOG_TIMESTAMP")
+	if _, err := strconv.ParseInt(string(ts), 10, 64); !ok || err != nil {
+		ts = strconv.AppendInt(nil, time.Now().UnixMicro(), 10)
+	}
+	return append(slogjournal.Entry{{Name: "__REALTIME_TIMESTAMP", Value: ts}}, e...)
+}
+
+// parseJSON parses entries in the JSON format, one object per line.
+func parseJSON(b []byte) ([]slogjournal.Entry, error) {
+	var entries []slogjournal.Entry
+	d := json.NewDecoder(bytes.NewReader(b))
+	for {
+		var e slogjournal.Entry
+		if err := d.Decode(&e); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+func TestConvert(t *testing.T) {
+	entries := []slogjournal.Entry{
+		{{Name: "MESSAGE", Value: []byte("first")}, {Name: "SYSLOG_TIMESTAMP", Value: []byte("1714564800000000")}},
+		{{Name: "MESSAGE", Value: []byte("line 1\n\nline 2")}, {Name: "BINARY", Value: []byte{0, 1, '\n'}}},
+	}
+
+	for _, format := range []string{"export", "json"} {
+		var buf bytes.Buffer
+		if err := write(&buf, format, entries); err != nil {
+			t.Fatal(err)
+		}
+		var got []slogjournal.Entry
+		var err error
+		if format == "export" {
+			got, err = slogjournal.ParseExport(buf.Bytes())
+		} else {
+			got, err = parseJSON(buf.Bytes())
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(got) != len(entries) {
+			t.Fatalf("%s: expected %d entries, got %d", format, len(entries), len(got))
+		}
+		if ts, _ := got[0].Get("__REALTIME_TIMESTAMP"); string(ts) != "1714564800000000" {
+			t.Errorf("%s: expected __REALTIME_TIMESTAMP from SYSLOG_TIMESTAMP, got %q", format, ts)
+		}
+		for i, e := range entries {
+			for _, f := range e {
+				if v, ok := got[i].Get(f.Name); !ok || !bytes.Equal(v, f.Value) {
+					t.Errorf("%s: entry %d: expected %s=%q, got %q", format, i, f.Name, f.Value, v)
+				}
+			}
+		}
+	}
+
+	if err := write(new(bytes.Buffer), "native", entries); err == nil {
+		t.Error("expected error for native output of several entries")
+	}
+}
@@ -0,0 +1,111 @@
+// Command slog-journal-deadletter inspects and resubmits the entries in a
+// dead-letter file written by a handler with Options.DeadLetterPath set.
+//
+// Usage:
+//
+//	slog-journal-deadletter show /var/lib/myservice/deadletter > entries.export
+//	slog-journal-deadletter submit entries.export
+//
+// show writes the entries of the dead-letter file to standard output in the
+// journal export format, and why journald rejects them to standard error.
+// After correcting the entries, e.g. by renaming fields, submit writes them
+// to the journal. It reads entries in the export format from the files given
+// as arguments or from standard input, and rejects entries that still
+// violate the rules of journald. Remove the dead-letter file once its
+// entries are resubmitted.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "show":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		err = show(os.Stdout, os.Stderr, os.Args[2])
+	case "submit":
+		var h *slogjournal.Handler
+		if h, err = slogjournal.NewHandler(nil); err == nil {
+			err = submit(h, os.Args[2:])
+		}
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "slog-journal-deadletter:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s show FILE\n       %s submit [FILE...]\n", os.Args[0], os.Args[0])
+	os.Exit(2)
+}
+
+// show writes the entries of the dead-letter file name to w in the export
+// format and their violations to errw.
+func show(w, errw io.Writer, name string) error {
+	entries, err := slogjournal.ReadDeadLetters(name)
+	if err != nil {
+		return err
+	}
+	var b []byte
+	for i, e := range entries {
+		var verr *slogjournal.ValidationError
+		if errors.As(e.Validate(), &verr) {
+			for _, v := range verr.Violations {
+				fmt.Fprintf(errw, "entry %d: %s\n", i+1, v)
+			}
+		}
+		b = append(e.AppendNative(b), '\n')
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// submit writes the entries in the export format of the files named by args,
+// or of standard input if there are none, to the journal with h.
+func submit(h *slogjournal.Handler, args []string) error {
+	var entries []slogjournal.Entry
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+	for _, name := range args {
+		var b []byte
+		var err error
+		if name == "-" {
+			b, err = io.ReadAll(os.Stdin)
+		} else {
+			b, err = os.ReadFile(name)
+		}
+		if err != nil {
+			return err
+		}
+		e, err := slogjournal.ParseExport(b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		entries = append(entries, e...)
+	}
+
+	var errs []error
+	for i, e := range entries {
+		if err := h.WriteEntry(e); err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: %w", i+1, err))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d of %d entries submitted\n", len(entries)-len(errs), len(entries))
+	return errors.Join(errs...)
+}
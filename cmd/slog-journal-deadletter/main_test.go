@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+func TestShow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deadletter")
+	h, err := slogjournal.NewHandler(&slogjournal.Options{DeadLetterPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "invalid", 0)
+	record.AddAttrs(slog.String("key", "value"))
+	_ = h.Handle(context.TODO(), record)
+
+	var out, errOut bytes.Buffer
+	if err := show(&out, &errOut, path); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := slogjournal.ParseExport(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if v, _ := entries[0].Get("key"); string(v) != "value" {
+		t.Errorf("expected key=value, got %q", entries[0])
+	}
+	if !strings.Contains(errOut.String(), `entry 1: field "key"`) {
+		t.Errorf("expected a violation of key, got %q", errOut.String())
+	}
+}
@@ -0,0 +1,5 @@
+// Package example holds the code slog-journal-gen generates for
+// schemas.json, to test that it compiles and logs as documented.
+package example
+
+//go:generate go run github.com/systemd/slog-journal/cmd/slog-journal-gen -package example -o events_gen.go schemas.json
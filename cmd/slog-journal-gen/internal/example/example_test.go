@@ -0,0 +1,37 @@
+package example
+
+import (
+	"context"
+	"log/slog"
+	"net/netip"
+	"testing"
+
+	slogjournal "github.com/systemd/slog-journal"
+	"github.com/systemd/slog-journal/journaltest"
+)
+
+var server = netip.MustParseAddr("192.0.2.1")
+
+func TestLogWithGroup(t *testing.T) {
+	handler, rec := journaltest.NewHandler(t, &slogjournal.Options{SchemaMode: slogjournal.SchemaReject})
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(handler.WithGroup("DB")))
+
+	LogQueryFailed(context.Background(), "SELECT 1", server, QueryFailedAttempts(3))
+	e := rec.Last(t)
+	journaltest.FieldEquals(t, e, "MESSAGE_ID", MessageIDQueryFailed)
+	journaltest.NoField(t, e, "DB_MESSAGE_ID")
+	journaltest.FieldEquals(t, e, "QUERY", "SELECT 1")
+	journaltest.FieldEquals(t, e, "SERVER_IP", "192.0.2.1")
+	journaltest.FieldEquals(t, e, "ATTEMPTS", "3")
+	journaltest.NoField(t, e, "DB_QUERY")
+}
+
+func TestLogTo(t *testing.T) {
+	handler, rec := journaltest.NewHandler(t, &slogjournal.Options{SchemaMode: slogjournal.SchemaReject})
+	LogQueryFailedTo(context.Background(), slog.New(handler), "SELECT 1", server)
+	e := rec.Last(t)
+	journaltest.FieldEquals(t, e, "MESSAGE", "query failed")
+	journaltest.FieldEquals(t, e, "SERVER_IP", "192.0.2.1")
+	journaltest.FieldEquals(t, e, "CODE_FUNC", "github.com/systemd/slog-journal/cmd/slog-journal-gen/internal/example.TestLogTo")
+}
@@ -0,0 +1,302 @@
+// Command slog-journal-gen generates typed logging functions from event
+// schemas, so that events are logged with correctly named and typed journal
+// fields.
+//
+// Usage:
+//
+//	slog-journal-gen -package events -o events_gen.go schemas.json
+//
+// The input is a JSON array of [slogjournal.Schema] values. For every schema
+// named e.g. UserLoginFailed, the generated file declares
+//
+//   - MessageIDUserLoginFailed, the MESSAGE_ID of the event,
+//   - LogUserLoginFailed(ctx, required fields..., attrs...), which logs the
+//     event with [slog.Default],
+//   - LogUserLoginFailedTo(ctx, logger, required fields..., attrs...), which
+//     logs the event with logger,
+//   - a function per optional field, e.g. UserLoginFailedAttempts(uint64),
+//     returning an Attr to pass to LogUserLoginFailed,
+//
+// and registers the schema with [slogjournal.RegisterSchema]. Fields of type
+// ip are netip.Addr values. Parameters that would shadow other names the
+// functions use, such as ctx for a CTX field, get a trailing underscore,
+// like Go keywords.
+//
+// It is meant to be used with go:generate:
+//
+//	//go:generate go run github.com/systemd/slog-journal/cmd/slog-journal-gen -package events -o events_gen.go schemas.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"log/slog"
+	"os"
+	"strings"
+	"text/template"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+func main() {
+	pkg := flag.String("package", "main", "package name of the generated file")
+	out := flag.String("o", "", "output file (default stdout)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] schemas.json\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	b, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+	var schemas []slogjournal.Schema
+	if err := json.Unmarshal(b, &schemas); err != nil {
+		fatal(fmt.Errorf("%s: %w", flag.Arg(0), err))
+	}
+	src, err := generate(*pkg, schemas)
+	if err != nil {
+		fatal(err)
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(*out, src, 0o644)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "slog-journal-gen:", err)
+	os.Exit(1)
+}
+
+type field struct {
+	slogjournal.FieldSpec
+	Param  string
+	Func   string
+	GoType string
+	Attr   string
+	// Conv converts the Go value to the value of Attr, e.g. ".String()".
+	Conv string
+	Kind string
+}
+
+type event struct {
+	slogjournal.Schema
+	Required []field
+	Optional []field
+	LevelSrc string
+	// Helper is the name of the unexported function logging the event.
+	Helper string
+}
+
+// generate returns the formatted Go source of the logging functions of schemas.
+func generate(pkg string, schemas []slogjournal.Schema) ([]byte, error) {
+	var events []event
+	netIP := false
+	for _, s := range schemas {
+		if !token.IsIdentifier(s.Name) || !token.IsExported(s.Name) {
+			return nil, fmt.Errorf("schema %s: name %q is not an exported Go identifier", s.MessageID, s.Name)
+		}
+		e := event{Schema: s, LevelSrc: levelSource(s.Level), Helper: "log" + s.Name}
+		// reserved are the names the functions of the event use.
+		reserved := map[string]bool{"ctx": true, "logger": true, "attrs": true, "slog": true, e.Helper: true}
+		if e.Message == "" {
+			e.Message = s.Name
+		}
+		for _, spec := range s.Fields {
+			camel := camelCase(spec.Name)
+			if camel == "" {
+				return nil, fmt.Errorf("schema %s: invalid field name %q", s.Name, spec.Name)
+			}
+			f := field{FieldSpec: spec, Param: paramName(camel), Func: s.Name + camel}
+			if reserved[f.Param] {
+				f.Param += "_"
+			}
+			switch spec.Type {
+			case slogjournal.TypeInt:
+				f.GoType, f.Attr, f.Kind = "int64", "slog.Int64", "TypeInt"
+			case slogjournal.TypeUint:
+				f.GoType, f.Attr, f.Kind = "uint64", "slog.Uint64", "TypeUint"
+			case slogjournal.TypeFloat:
+				f.GoType, f.Attr, f.Kind = "float64", "slog.Float64", "TypeFloat"
+			case slogjournal.TypeBool:
+				f.GoType, f.Attr, f.Kind = "bool", "slog.Bool", "TypeBool"
+			case slogjournal.TypeIP:
+				f.GoType, f.Attr, f.Conv, f.Kind = "netip.Addr", "slog.String", ".String()", "TypeIP"
+				netIP = true
+			default:
+				f.GoType, f.Attr, f.Kind = "string", "slog.String", "TypeString"
+			}
+			if spec.Required {
+				e.Required = append(e.Required, f)
+			} else {
+				e.Optional = append(e.Optional, f)
+			}
+		}
+		events = append(events, e)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Package string
+		NetIP   bool
+		Events  []event
+	}{pkg, netIP, events}); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+// levelSource returns the Go expression of l.
+func levelSource(l slog.Level) string {
+	switch l {
+	case slog.LevelDebug:
+		return "slog.LevelDebug"
+	case slog.LevelInfo:
+		return "slog.LevelInfo"
+	case slog.LevelWarn:
+		return "slog.LevelWarn"
+	case slog.LevelError:
+		return "slog.LevelError"
+	default:
+		return fmt.Sprintf("slog.Level(%d)", l)
+	}
+}
+
+// initialisms are kept in upper case in Go names.
+var initialisms = map[string]bool{"ID": true, "IP": true, "URL": true, "HTTP": true, "UID": true, "GID": true, "PID": true}
+
+// camelCase returns the exported Go name of a field, e.g. ClientIP for CLIENT_IP.
+func camelCase(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		switch {
+		case word == "":
+		case initialisms[word]:
+			b.WriteString(word)
+		default:
+			b.WriteString(word[:1] + strings.ToLower(word[1:]))
+		}
+	}
+	if s := b.String(); token.IsIdentifier(s) {
+		return s
+	}
+	return ""
+}
+
+// paramName returns the Go parameter name of the field named camel, e.g.
+// clientIP for ClientIP.
+func paramName(camel string) string {
+	n := 1
+	for _, w := range []string{"HTTP", "URL", "UID", "GID", "PID", "ID", "IP"} {
+		if strings.HasPrefix(camel, w) {
+			n = len(w)
+			break
+		}
+	}
+	p := strings.ToLower(camel[:n]) + camel[n:]
+	if token.IsKeyword(p) {
+		p += "_"
+	}
+	return p
+}
+
+var tmpl = template.Must(template.New("").Parse(`// Code generated by slog-journal-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"log/slog"
+	{{- if .NetIP}}
+	"net/netip"
+	{{- end}}
+	"runtime"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+func init() {
+{{- range .Events}}
+	slogjournal.RegisterSchema(slogjournal.Schema{
+		MessageID: {{printf "%q" .MessageID}},
+		Name:      {{printf "%q" .Name}},
+		Message:   {{printf "%q" .Message}},
+		Level:     {{.LevelSrc}},
+		Fields: []slogjournal.FieldSpec{
+		{{- range .Required}}
+			{Name: {{printf "%q" .Name}}, Type: slogjournal.{{.Kind}}, Required: true{{if .Doc}}, Doc: {{printf "%q" .Doc}}{{end}}},
+		{{- end}}
+		{{- range .Optional}}
+			{Name: {{printf "%q" .Name}}, Type: slogjournal.{{.Kind}}{{if .Doc}}, Doc: {{printf "%q" .Doc}}{{end}}},
+		{{- end}}
+		},
+		Closed: {{.Closed}},
+	})
+{{- end}}
+}
+{{range $e := .Events}}
+// MessageID{{.Name}} is the MESSAGE_ID of {{.Name}} events.
+const MessageID{{.Name}} = {{printf "%q" .MessageID}}
+
+// Log{{.Name}} logs a {{.Name}} event with slog.Default.
+{{- range .Required}}{{if .Doc}}
+// {{.Param}}: {{.Doc}}{{end}}{{end}}
+func Log{{.Name}}(ctx context.Context, {{range .Required}}{{.Param}} {{.GoType}}, {{end}}attrs ...slog.Attr) {
+	{{.Helper}}(ctx, slog.Default(), []slog.Attr{
+	{{- range .Required}}
+		{{.Attr}}({{printf "%q" .Name}}, {{.Param}}{{.Conv}}),
+	{{- end}}
+	}, attrs)
+}
+
+// Log{{.Name}}To logs a {{.Name}} event with logger, see Log{{.Name}}.
+func Log{{.Name}}To(ctx context.Context, logger *slog.Logger, {{range .Required}}{{.Param}} {{.GoType}}, {{end}}attrs ...slog.Attr) {
+	{{.Helper}}(ctx, logger, []slog.Attr{
+	{{- range .Required}}
+		{{.Attr}}({{printf "%q" .Name}}, {{.Param}}{{.Conv}}),
+	{{- end}}
+	}, attrs)
+}
+
+// {{.Helper}} logs a {{.Name}} event with l, reporting the caller of
+// Log{{.Name}} or Log{{.Name}}To as its source.
+func {{.Helper}}(ctx context.Context, l *slog.Logger, fields, attrs []slog.Attr) {
+	if !l.Enabled(ctx, {{.LevelSrc}}) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // skip [Callers, {{.Helper}}, Log{{.Name}}]
+	r := slog.NewRecord(time.Now(), {{.LevelSrc}}, {{printf "%q" .Message}}, pcs[0])
+	r.AddAttrs(slog.String(slogjournal.MessageIDKey, MessageID{{.Name}}))
+	r.AddAttrs(fields...)
+	r.AddAttrs(attrs...)
+	_ = l.Handler().Handle(ctx, r)
+}
+{{range .Optional}}
+// {{.Func}} returns the optional {{.Name}} field of {{$e.Name}} events.
+{{- if .Doc}}
+// {{.Doc}}{{end}}
+func {{.Func}}(v {{.GoType}}) slog.Attr {
+	return {{.Attr}}({{printf "%q" .Name}}, v{{.Conv}})
+}
+{{end}}
+{{- end}}`))
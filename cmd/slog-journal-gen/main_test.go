@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+func TestGenerate(t *testing.T) {
+	src, err := generate("events", []slogjournal.Schema{{
+		MessageID: "15a0876e7594405288b2e7450d120334",
+		Name:      "UserLoginFailed",
+		Message:   "login failed",
+		Level:     slog.LevelWarn,
+		Fields: []slogjournal.FieldSpec{
+			{Name: "USER", Type: slogjournal.TypeString, Required: true},
+			{Name: "CLIENT_IP", Type: slogjournal.TypeString, Required: true},
+			{Name: "ATTEMPTS", Type: slogjournal.TypeUint},
+			{Name: "TYPE", Type: slogjournal.TypeBool},
+			{Name: "CTX", Type: slogjournal.TypeString, Required: true},
+			{Name: "ATTRS", Type: slogjournal.TypeInt, Required: true},
+			{Name: "LOGGER", Type: slogjournal.TypeIP},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package events\n",
+		`const MessageIDUserLoginFailed = "15a0876e7594405288b2e7450d120334"`,
+		"func LogUserLoginFailed(ctx context.Context, user string, clientIP string, ctx_ string, attrs_ int64, attrs ...slog.Attr) {",
+		"func LogUserLoginFailedTo(ctx context.Context, logger *slog.Logger, user string, clientIP string, ctx_ string, attrs_ int64, attrs ...slog.Attr) {",
+		"logUserLoginFailed(ctx, slog.Default(), []slog.Attr{",
+		"func logUserLoginFailed(ctx context.Context, l *slog.Logger, fields, attrs []slog.Attr) {",
+		`slog.Int64("ATTRS", attrs_),`,
+		"func UserLoginFailedLogger(v netip.Addr) slog.Attr {",
+		`return slog.String("LOGGER", v.String())`,
+		`"net/netip"`,
+		`slog.String("CLIENT_IP", clientIP),`,
+		"func UserLoginFailedAttempts(v uint64) slog.Attr {",
+		"func UserLoginFailedType(v bool) slog.Attr {",
+		`{Name: "ATTEMPTS", Type: slogjournal.TypeUint},`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateExample(t *testing.T) {
+	b, err := os.ReadFile("internal/example/schemas.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schemas []slogjournal.Schema
+	if err := json.Unmarshal(b, &schemas); err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate("example", schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("internal/example/events_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Error("internal/example/events_gen.go is out of date, run go generate ./...")
+	}
+}
+
+func TestGenerateInvalidName(t *testing.T) {
+	if _, err := generate("events", []slogjournal.Schema{{Name: "userLogin"}}); err == nil {
+		t.Error("expected error for unexported schema name")
+	}
+	if _, err := generate("events", []slogjournal.Schema{{Name: "Login", Fields: []slogjournal.FieldSpec{{Name: "_"}}}}); err == nil {
+		t.Error("expected error for invalid field name")
+	}
+}
+
+func TestParamName(t *testing.T) {
+	for camel, want := range map[string]string{
+		"User":       "user",
+		"ClientIP":   "clientIP",
+		"IPAddress":  "ipAddress",
+		"HTTPStatus": "httpStatus",
+		"Type":       "type_",
+	} {
+		if got := paramName(camel); got != want {
+			t.Errorf("paramName(%q) = %q, want %q", camel, got, want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Command slog-journal-verify checks journal entries against the rules of
+// journald and a declared field policy, so that CI can catch log format
+// regressions.
+//
+// Usage:
+//
+//	journalctl -o export -t myservice | slog-journal-verify -schemas schemas.json -require-message-id
+//	slog-journal-verify -from json -fields USER,ATTEMPTS entries.json
+//
+// Entries are read in the native, export or json format, as described in
+// slog-journal-convert, from the files given as arguments or from standard
+// input. Fields starting with an underscore are added by journald and are not
+// checked. For every entry, it reports
+//
+//   - field names journald rejects and values exceeding its size limits,
+//   - a missing MESSAGE_ID, if -require-message-id is set,
+//   - violations of the schema registered for the MESSAGE_ID, if any, read
+//     from the JSON array of schemas given by -schemas,
+//   - fields that are neither well-known journal fields, nor declared by the
+//     schema of the entry, nor listed in -fields, if -fields is set.
+//
+// It exits with status 1 if any entry has violations.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// wellKnownFields are the fields documented in systemd.journal-fields(7) that
+// clients may set, and the fields the handler adds.
+var wellKnownFields = []string{
+	"MESSAGE", "MESSAGE_ID", "PRIORITY", "CODE_FILE", "CODE_LINE", "CODE_FUNC",
+	"ERRNO", "INVOCATION_ID", "USER_INVOCATION_ID", "SYSLOG_FACILITY",
+	"SYSLOG_IDENTIFIER", "SYSLOG_PID", "SYSLOG_TIMESTAMP", "SYSLOG_RAW",
+	"DOCUMENTATION", "TID", "UNIT", "USER_UNIT",
+	"CODE_LOCATION", "LEVEL", slogjournal.RequestIDKey, slogjournal.SignatureKey,
+	"RESOLVE_ERROR", slogjournal.SchemaViolationKey, slogjournal.TruncatedKey,
+}
+
+type policy struct {
+	requireMessageID bool
+	// fields lists the allowed fields besides the well-known ones and those
+	// declared by schemas, or is nil if any field is allowed.
+	fields []string
+}
+
+func main() {
+	from := flag.String("from", "export", "input format: native, export or json")
+	schemaFile := flag.String("schemas", "", "JSON file with an array of schemas to check entries against")
+	fields := flag.String("fields", "", "comma-separated list of allowed fields; if empty, any field is allowed")
+	var p policy
+	flag.BoolVar(&p.requireMessageID, "require-message-id", false, "report entries without a MESSAGE_ID")
+	flag.Parse()
+
+	if *schemaFile != "" {
+		if err := loadSchemas(*schemaFile); err != nil {
+			fatal(err)
+		}
+	}
+	if *fields != "" {
+		p.fields = strings.Split(*fields, ",")
+	}
+	entries, err := read(*from, flag.Args())
+	if err != nil {
+		fatal(err)
+	}
+
+	bad := 0
+	for i, e := range entries {
+		violations := p.check(e)
+		if len(violations) > 0 {
+			bad++
+		}
+		for _, v := range violations {
+			fmt.Printf("entry %d: %s\n", i+1, v)
+		}
+	}
+	fmt.Printf("%d entries, %d with violations\n", len(entries), bad)
+	if bad > 0 {
+		os.Exit(1)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "slog-journal-verify:", err)
+	os.Exit(1)
+}
+
+// loadSchemas registers the schemas in the JSON file name.
+func loadSchemas(name string) error {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	var schemas []slogjournal.Schema
+	if err := json.Unmarshal(b, &schemas); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	for _, s := range schemas {
+		slogjournal.RegisterSchema(s)
+	}
+	return nil
+}
+
+// check returns the violations of e.
+func (p policy) check(e slogjournal.Entry) []slogjournal.Violation {
+	e = slices.DeleteFunc(slices.Clone(e), func(f slogjournal.Field) bool {
+		return strings.HasPrefix(f.Name, "_")
+	})
+
+	var violations []slogjournal.Violation
+	add := func(err error) {
+		var verr *slogjournal.ValidationError
+		if errors.As(err, &verr) {
+			violations = append(violations, verr.Violations...)
+		} else if err != nil {
+			violations = append(violations, slogjournal.Violation{Reason: err.Error()})
+		}
+	}
+	add(e.Validate())
+
+	var schema slogjournal.Schema
+	id, ok := e.Get(slogjournal.MessageIDKey)
+	if ok {
+		if s, ok := slogjournal.LookupSchema(string(id)); ok {
+			schema = s
+			add(s.Validate(e))
+		}
+	} else if p.requireMessageID {
+		violations = append(violations, slogjournal.Violation{Field: slogjournal.MessageIDKey, Reason: "field is missing"})
+	}
+
+	if p.fields != nil {
+		for _, f := range e {
+			if slices.Contains(wellKnownFields, f.Name) || slices.Contains(p.fields, f.Name) ||
+				slices.ContainsFunc(schema.Fields, func(spec slogjournal.FieldSpec) bool { return spec.Name == f.Name }) {
+				continue
+			}
+			violations = append(violations, slogjournal.Violation{Field: f.Name, Reason: "field is not allowed"})
+		}
+	}
+	return violations
+}
+
+// read reads the entries of the files named by args in format, or of
+// standard input if there are none.
+func read(format string, args []string) ([]slogjournal.Entry, error) {
+	var parse func([]byte) ([]slogjournal.Entry, error)
+	switch format {
+	case "native":
+		parse = func(b []byte) ([]slogjournal.Entry, error) {
+			e, err := slogjournal.ParseEntry(b)
+			return []slogjournal.Entry{e}, err
+		}
+	case "export":
+		parse = slogjournal.ParseExport
+	case "json":
+		parse = parseJSON
+	default:
+		return nil, fmt.Errorf("unknown input format %q", format)
+	}
+
+	if len(args) == 0 {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		return parse(b)
+	}
+	var entries []slogjournal.Entry
+	for _, name := range args {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		e, err := parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		entries = append(entries, e...)
+	}
+	return entries, nil
+}
+
+// parseJSON parses entries in the JSON format, one object per line.
+func parseJSON(b []byte) ([]slogjournal.Entry, error) {
+	var entries []slogjournal.Entry
+	d := json.NewDecoder(bytes.NewReader(b))
+	for {
+		var e slogjournal.Entry
+		if err := d.Decode(&e); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+}
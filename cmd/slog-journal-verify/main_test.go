@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+func TestCheck(t *testing.T) {
+	slogjournal.RegisterSchema(slogjournal.Schema{
+		MessageID: "c81b0a1f2a7345a689d3c24892f126e6",
+		Fields:    []slogjournal.FieldSpec{{Name: "USER", Required: true}, {Name: "ATTEMPTS", Type: slogjournal.TypeUint}},
+	})
+	entries, err := slogjournal.ParseExport([]byte(`__CURSOR=s=1
+_PID=42
+MESSAGE=login failed
+MESSAGE_ID=c81b0a1f2a7345a689d3c24892f126e6
+USER=alice
+ATTEMPTS=3
+
+MESSAGE=login failed
+MESSAGE_ID=c81b0a1f2a7345a689d3c24892f126e6
+ATTEMPTS=many
+client_ip=::1
+
+MESSAGE=hello
+EXTRA=1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := policy{requireMessageID: true, fields: []string{}}
+
+	if v := p.check(entries[0]); len(v) != 0 {
+		t.Errorf("expected no violations, got %v", v)
+	}
+
+	got := map[string]bool{}
+	for _, v := range p.check(entries[1]) {
+		got[v.Field] = true
+	}
+	for _, field := range []string{"USER", "ATTEMPTS", "client_ip"} {
+		if !got[field] {
+			t.Errorf("expected violation of %s, got %v", field, got)
+		}
+	}
+
+	got = map[string]bool{}
+	for _, v := range p.check(entries[2]) {
+		got[v.Field] = true
+	}
+	if !got[slogjournal.MessageIDKey] || !got["EXTRA"] {
+		t.Errorf("expected missing MESSAGE_ID and EXTRA not allowed, got %v", got)
+	}
+	if v := (policy{}).check(entries[2]); len(v) != 0 {
+		t.Errorf("expected no violations without policy, got %v", v)
+	}
+}
@@ -0,0 +1,329 @@
+package slogjournal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// maxDatagramSize is the size of the largest datagram received as an
+// entry. Larger entries are passed as file descriptors.
+const maxDatagramSize = 8 * 1024 * 1024
+
+// Encoder encodes entries in the [native protocol] format, or with
+// [NewExportEncoder] in the journal export format, so that forwarders and
+// proxies can reuse the protocol without going through slog, e.g. to send
+// entries to journald, one per datagram, on a *net.UnixConn connected to
+// /run/systemd/journal/socket. An Encoder is safe for concurrent use.
+//
+// [native protocol]: https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
+type Encoder struct {
+	export bool
+}
+
+// NewEncoder returns an Encoder for the native protocol format, as read by
+// [NewNativeDecoder], [ParseEntry] and journald.
+func NewEncoder() *Encoder {
+	return &Encoder{}
+}
+
+// NewExportEncoder returns an Encoder for the [journal export format],
+// which ends every entry with an empty line, as read by [NewDecoder],
+// [ParseExport] and systemd-journal-remote. systemd-journal-remote requires
+// a __REALTIME_TIMESTAMP field.
+//
+// [journal export format]: https://systemd.io/JOURNAL_EXPORT_FORMATS/
+func NewExportEncoder() *Encoder {
+	return &Encoder{export: true}
+}
+
+// Encode returns the entry with fields, ordered by name. It fails if a field
+// name is empty or contains '=' or a newline, which would corrupt the
+// entry. Names journald merely drops, such as lower-case ones, are encoded
+// as they are; see [ValidationError] for those.
+func (enc *Encoder) Encode(fields map[string][]byte) ([]byte, error) {
+	e := make(Entry, 0, len(fields))
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		e = append(e, Field{name, fields[name]})
+	}
+	return enc.EncodeEntry(e)
+}
+
+// EncodeEntry is like Encode, but keeps the order of the fields of e, which
+// may have several fields of the same name.
+func (enc *Encoder) EncodeEntry(e Entry) ([]byte, error) {
+	for _, f := range e {
+		if f.Name == "" || strings.ContainsAny(f.Name, "=\n") {
+			return nil, fmt.Errorf("cannot encode field name %q", f.Name)
+		}
+	}
+	if enc.export {
+		return e.AppendExport(nil), nil
+	}
+	return e.AppendNative(nil), nil
+}
+
+// Decoder reads entries from an io.Reader, one at a time: in the journal
+// export format with [NewDecoder], so that streams such as the output of
+// journalctl -o export are not read into memory at once, or in the native
+// protocol format with [NewNativeDecoder].
+type Decoder struct {
+	r *bufio.Reader
+	// native, if not nil, is read instead of r, one entry per Read.
+	native io.Reader
+	buf    []byte
+	// skip is the size of binary values above which they are discarded.
+	skip uint64
+}
+
+// NewDecoder returns a Decoder reading entries in the journal export format
+// from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// NewNativeDecoder returns a Decoder reading entries in the native protocol
+// format from r, one per call of r.Read, such as the datagrams received on
+// a *net.UnixConn standing in for /run/systemd/journal/socket. Entries
+// passed in file descriptors are not read; use a Server to receive those.
+func NewNativeDecoder(r io.Reader) *Decoder {
+	return &Decoder{native: r}
+}
+
+// SkipLargeValues makes Decode discard the values of binary fields larger
+// than n bytes as it reads them, rather than keep them in memory, so that
+// exports with large fields such as the COREDUMP of systemd-coredump can be
+// scanned. It has no effect on entries in the native protocol format, which
+// are read as a whole. Such fields are returned with their name and a nil
+// Value, which fields with an empty value never have. A n of 0 keeps all
+// values.
+func (d *Decoder) SkipLargeValues(n int) {
+	d.skip = uint64(max(n, 0))
+}
+
+// Decode returns the next entry. It returns io.EOF when there are no more
+// entries, and io.ErrUnexpectedEOF if r ends within a binary field.
+func (d *Decoder) Decode() (Entry, error) {
+	if d.native != nil {
+		return d.decodeNative()
+	}
+	var e Entry
+	for {
+		line, err := d.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		eof := err == io.EOF
+		line = bytes.TrimSuffix(line, []byte{'\n'})
+		switch name, value, ok := bytes.Cut(line, []byte{'='}); {
+		case len(line) == 0:
+			if len(e) > 0 {
+				return e, nil
+			}
+			if eof {
+				return nil, io.EOF
+			}
+		case ok:
+			e = append(e, Field{string(name), value})
+		case eof:
+			return nil, errMalformedEntry
+		default:
+			v, err := d.readBinary()
+			if err != nil {
+				return nil, err
+			}
+			e = append(e, Field{string(line), v})
+			continue
+		}
+		if eof {
+			return e, nil
+		}
+	}
+}
+
+// Entries returns an iterator over the entries read from d that satisfy
+// matches, each of the form FIELD=value, like the matches of journalctl:
+// matches of the same field are alternatives, and those of different fields
+// must all be satisfied. The iterator stops at the end of the input, and
+// after yielding an error, including that of ctx once it is done. It only
+// checks ctx between entries, not while waiting for input.
+//
+//	for e, err := range d.Entries(ctx, "PRIORITY=3", "PRIORITY=4") {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (d *Decoder) Entries(ctx context.Context, matches ...string) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		var filter map[string][]string
+		for _, m := range matches {
+			name, value, ok := strings.Cut(m, "=")
+			if !ok {
+				yield(nil, fmt.Errorf("invalid match %q", m))
+				return
+			}
+			if filter == nil {
+				filter = make(map[string][]string)
+			}
+			filter[name] = append(filter[name], value)
+		}
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			e, err := d.Decode()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if matchesAll(e, filter) && !yield(e, nil) {
+				return
+			}
+		}
+	}
+}
+
+// matchesAll reports whether e has, for every field in filter, one of its
+// values.
+func matchesAll(e Entry, filter map[string][]string) bool {
+	for name, values := range filter {
+		found := false
+		for _, f := range e {
+			if f.Name == name && slices.Contains(values, string(f.Value)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteJSON writes the entries read from d to w, one JSON object per line
+// in the shape of journalctl -o json, until the end of the input. Entries
+// are converted one at a time, so that exports of any size can be.
+func (d *Decoder) WriteJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for {
+		e, err := d.Decode()
+		if err == io.EOF {
+			return bw.Flush()
+		}
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		bw.Write(b)
+		bw.WriteByte('\n')
+	}
+}
+
+// WriteCSV writes the entries read from d to w as CSV, until the end of the
+// input: a header row of fields, then a row per entry with the values of
+// fields, which are empty for the fields an entry lacks. The values of a
+// field occurring more than once in an entry are joined by newlines.
+func (d *Decoder) WriteCSV(w io.Writer, fields ...string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(fields); err != nil {
+		return err
+	}
+	row := make([]string, len(fields))
+	for {
+		e, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		for i, name := range fields {
+			var values []string
+			for _, f := range e {
+				if f.Name == name {
+					values = append(values, string(f.Value))
+				}
+			}
+			row[i] = strings.Join(values, "\n")
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// decodeNative reads the next entry in the native protocol format.
+func (d *Decoder) decodeNative() (Entry, error) {
+	if d.buf == nil {
+		d.buf = make([]byte, maxDatagramSize)
+	}
+	for {
+		n, err := d.native.Read(d.buf)
+		// Empty datagrams are ignored, like journald does.
+		if n > 0 {
+			return ParseEntry(bytes.Clone(d.buf[:n]))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readBinary reads the little-endian 64-bit length and the value of a
+// binary field, and the newline following it, if any.
+func (d *Decoder) readBinary() ([]byte, error) {
+	var size [8]byte
+	if _, err := io.ReadFull(d.r, size[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	n := binary.LittleEndian.Uint64(size[:])
+	if n > maxFieldSize {
+		return nil, fmt.Errorf("journal field of %d bytes exceeds the maximum of %d", n, maxFieldSize)
+	}
+	var v []byte
+	if d.skip > 0 && n > d.skip {
+		if _, err := d.r.Discard(int(n)); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	} else {
+		v = make([]byte, n)
+		if _, err := io.ReadFull(d.r, v); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+	switch c, err := d.r.ReadByte(); {
+	case err == io.EOF:
+	case err != nil:
+		return nil, err
+	case c != '\n':
+		return nil, errMalformedEntry
+	}
+	return v, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
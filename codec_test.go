@@ -0,0 +1,249 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// datagramReader returns one of its datagrams per Read, like a
+// *net.UnixConn.
+type datagramReader [][]byte
+
+func (r *datagramReader) Read(p []byte) (int, error) {
+	if len(*r) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, (*r)[0])
+	*r = (*r)[1:]
+	return n, nil
+}
+
+func TestEncoder(t *testing.T) {
+	e := Entry{{"MESSAGE", []byte("Hello, World!")}, {"PAYLOAD", []byte("a\nb")}, {"TAG", []byte("1")}, {"TAG", []byte("2")}}
+	b, err := NewEncoder().EncodeEntry(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseEntry(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.EqualFunc(got, e, fieldEqual) {
+		t.Errorf("expected %q, got %q", e, got)
+	}
+
+	b, err = NewEncoder().Encode(map[string][]byte{"PAYLOAD": []byte("a\nb"), "MESSAGE": []byte("Hello, World!")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "MESSAGE=Hello, World!\nPAYLOAD\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n"; string(b) != want {
+		t.Errorf("expected fields ordered by name %q, got %q", want, b)
+	}
+
+	for _, name := range []string{"", "A=B", "A\nB"} {
+		if b, err := NewEncoder().Encode(map[string][]byte{name: []byte("x")}); err == nil || b != nil {
+			t.Errorf("expected field name %q to be rejected, got %v", name, err)
+		}
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	entries := []Entry{
+		{{"__REALTIME_TIMESTAMP", []byte("1")}, {"MESSAGE", []byte("first")}},
+		{{"MESSAGE", []byte("multi\nline")}, {"EMPTY", []byte("")}},
+		{{"BINARY", []byte{0, '\n', 0xff}}, {"MESSAGE", []byte("third")}},
+	}
+	var buf bytes.Buffer
+	enc := NewExportEncoder()
+	for _, e := range entries {
+		b, err := enc.EncodeEntry(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(b)
+	}
+	if parsed, err := ParseExport(buf.Bytes()); err != nil || len(parsed) != len(entries) {
+		t.Fatalf("expected ParseExport to read %d entries, got %d, %v", len(entries), len(parsed), err)
+	}
+
+	d := NewDecoder(&buf)
+	for _, want := range entries {
+		got, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.EqualFunc(got, want, fieldEqual) {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	fields := []map[string][]byte{
+		{"MESSAGE": []byte("first"), "PRIORITY": []byte("6")},
+		{"MESSAGE": []byte("multi\nline"), "EMPTY": []byte("")},
+		{"BINARY": {0, '\n', 0xff}, "MESSAGE": []byte("third")},
+	}
+	for _, tt := range []struct {
+		name   string
+		enc    *Encoder
+		decode func(entries [][]byte) *Decoder
+	}{
+		{"native", NewEncoder(), func(entries [][]byte) *Decoder {
+			r := datagramReader(append(entries[:1:1], append([][]byte{{}}, entries[1:]...)...))
+			return NewNativeDecoder(&r)
+		}},
+		{"export", NewExportEncoder(), func(entries [][]byte) *Decoder {
+			return NewDecoder(bytes.NewReader(bytes.Join(entries, nil)))
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries [][]byte
+			for _, f := range fields {
+				b, err := tt.enc.Encode(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries = append(entries, b)
+			}
+			d := tt.decode(entries)
+			for _, want := range fields {
+				e, err := d.Decode()
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := make(map[string][]byte)
+				for _, f := range e {
+					got[f.Name] = f.Value
+				}
+				if !maps.EqualFunc(got, want, bytes.Equal) {
+					t.Errorf("expected %q, got %q", want, got)
+				}
+			}
+			if _, err := d.Decode(); err != io.EOF {
+				t.Errorf("expected io.EOF, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecoderTruncated(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want error
+	}{
+		{"MESSAGE=unterminated", nil},
+		{"\n\nMESSAGE=after empty lines\n", nil},
+		{"BINARY\n\x05\x00\x00\x00\x00\x00\x00\x00ab", io.ErrUnexpectedEOF},
+		{"BINARY\n\x02\x00\x00\x00\x00\x00\x00\x00abc\n", errMalformedEntry},
+		{"NAME", errMalformedEntry},
+	} {
+		_, err := NewDecoder(strings.NewReader(tt.in)).Decode()
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Decode(%q): expected %v, got %v", tt.in, tt.want, err)
+		}
+	}
+}
+
+func fieldEqual(a, b Field) bool {
+	return a.Name == b.Name && bytes.Equal(a.Value, b.Value)
+}
+
+func TestDecoderWriteJSONAndCSV(t *testing.T) {
+	export := "MESSAGE=first\nPRIORITY=6\n\n" +
+		"MESSAGE=second\nTAG=a\nTAG=b\n\n"
+
+	var buf bytes.Buffer
+	if err := NewDecoder(strings.NewReader(export)).WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"MESSAGE":"first","PRIORITY":"6"}` + "\n" + `{"MESSAGE":"second","TAG":["a","b"]}` + "\n"
+	if buf.String() != want {
+		t.Errorf("WriteJSON = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := NewDecoder(strings.NewReader(export)).WriteCSV(&buf, "MESSAGE", "PRIORITY", "TAG"); err != nil {
+		t.Fatal(err)
+	}
+	want = "MESSAGE,PRIORITY,TAG\nfirst,6,\nsecond,,\"a\nb\"\n"
+	if buf.String() != want {
+		t.Errorf("WriteCSV = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestDecoderEntries(t *testing.T) {
+	export := "MESSAGE=first\nPRIORITY=3\nUNIT=a\n\n" +
+		"MESSAGE=second\nPRIORITY=6\nUNIT=a\n\n" +
+		"MESSAGE=third\nPRIORITY=4\nUNIT=b\n\n" +
+		"MESSAGE=fourth\nPRIORITY=4\nUNIT=a\n\n"
+
+	var messages []string
+	d := NewDecoder(strings.NewReader(export))
+	for e, err := range d.Entries(context.Background(), "PRIORITY=3", "PRIORITY=4", "UNIT=a") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, _ := e.Get("MESSAGE")
+		messages = append(messages, string(v))
+	}
+	if want := []string{"first", "fourth"}; !slices.Equal(messages, want) {
+		t.Errorf("expected %v, got %v", want, messages)
+	}
+
+	// Breaking out of the loop leaves the remaining entries to d.
+	d = NewDecoder(strings.NewReader(export))
+	for range d.Entries(context.Background()) {
+		break
+	}
+	if e, err := d.Decode(); err != nil || string(e[0].Value) != "second" {
+		t.Errorf("expected the second entry after breaking out, got %v, %v", e, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, err := range NewDecoder(strings.NewReader(export)).Entries(ctx) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	}
+	for _, err := range NewDecoder(strings.NewReader(export)).Entries(context.Background(), "PRIORITY") {
+		if err == nil {
+			t.Error("expected an error for an invalid match")
+		}
+	}
+}
+
+func TestDecoderSkipLargeValues(t *testing.T) {
+	e := Entry{
+		{"MESSAGE", []byte("crashed")},
+		{"COREDUMP", bytes.Repeat([]byte{0, '\n'}, 1024)},
+		{"EMPTY", []byte{}},
+	}
+	d := NewDecoder(bytes.NewReader(slices.Concat(e.AppendExport(nil), e.AppendExport(nil))))
+	d.SkipLargeValues(100)
+	for range 2 {
+		got, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, ok := got.Get("COREDUMP"); !ok || v != nil {
+			t.Errorf("expected COREDUMP without its value, got %q, %v", v, ok)
+		}
+		if v, _ := got.Get("MESSAGE"); string(v) != "crashed" {
+			t.Errorf("expected MESSAGE=crashed, got %q", v)
+		}
+		if v, _ := got.Get("EMPTY"); v == nil {
+			t.Error("expected an empty value to not be nil")
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package slogjournal
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"unicode"
+)
+
+// consoleWriter writes entries in a human-readable format, one per line:
+// the priority in angle brackets, the message and the remaining fields, e.g.
+//
+//	<warning> disk almost full MOUNT=/var FREE_BYTES=1024
+//
+// With color, the priority is colored by severity and the fields are dimmed.
+type consoleWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	color bool
+}
+
+// priorityNames are the names of the priorities, as used by journalctl.
+var priorityNames = [8]string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// priorityColors are the ANSI escape sequences that color the priorities,
+// like journalctl does.
+var priorityColors = [8]string{"\x1b[1;31m", "\x1b[1;31m", "\x1b[1;31m", "\x1b[31m", "\x1b[33m", "\x1b[1m", "", "\x1b[2m"}
+
+const (
+	colorDim   = "\x1b[2m"
+	colorReset = "\x1b[0m"
+)
+
+// consoleFields are the fields consoleWriter does not print as fields.
+var consoleFields = map[string]bool{"MESSAGE": true, "PRIORITY": true, "SYSLOG_TIMESTAMP": true, "SYSLOG_IDENTIFIER": true}
+
+// newConsoleWriter returns a consoleWriter writing to f, with color if f is
+// a terminal and $NO_COLOR is not set to a non-empty value (see
+// https://no-color.org).
+func newConsoleWriter(f *os.File) *consoleWriter {
+	noColor := os.Getenv("NO_COLOR") != ""
+	fi, err := f.Stat()
+	return &consoleWriter{
+		w:     f,
+		color: !noColor && err == nil && fi.Mode()&os.ModeCharDevice != 0,
+	}
+}
+
+// Write writes a single entry in the native protocol format as a line.
+func (w *consoleWriter) Write(p []byte) (int, error) {
+	fields, err := ParseEntry(p)
+	if err != nil {
+		return 0, err
+	}
+	pri := PriorityInfo
+	if v, ok := fields.Get("PRIORITY"); ok {
+		if n, err := strconv.Atoi(string(v)); err == nil && n >= 0 && n < len(priorityNames) {
+			pri = Priority(n)
+		}
+	}
+	msg, _ := fields.Get("MESSAGE")
+
+	var b []byte
+	if w.color {
+		b = append(b, priorityColors[pri]...)
+	}
+	b = append(b, '<')
+	b = append(b, priorityNames[pri]...)
+	b = append(b, "> "...)
+	b = append(b, msg...)
+	if w.color && priorityColors[pri] != "" {
+		b = append(b, colorReset...)
+	}
+	if w.color {
+		b = append(b, colorDim...)
+	}
+	for _, f := range fields {
+		if consoleFields[f.Name] {
+			continue
+		}
+		b = append(b, ' ')
+		b = append(b, f.Name...)
+		b = append(b, '=')
+		if needsQuote(f.Value) {
+			b = strconv.AppendQuote(b, string(f.Value))
+		} else {
+			b = append(b, f.Value...)
+		}
+	}
+	if w.color {
+		b = append(b, colorReset...)
+	}
+	b = append(b, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.w.Write(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// needsQuote reports whether the value v of a field must be quoted to be
+// printed unambiguously on a single line.
+func needsQuote(v []byte) bool {
+	if len(v) == 0 {
+		return true
+	}
+	for _, r := range string(v) {
+		if r == ' ' || r == '"' || r == '=' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+var _ io.Writer = &consoleWriter{}
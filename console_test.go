@@ -0,0 +1,60 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConsoleWriter(t *testing.T) {
+	for _, color := range []bool{false, true} {
+		buf := new(bytes.Buffer)
+		handler, err := NewHandler(&Options{SourceFields: NoSource})
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.w = &consoleWriter{w: buf, color: color}
+
+		record := slog.NewRecord(time.Now(), slog.LevelWarn, "disk almost full", 0)
+		record.AddAttrs(slog.String("MOUNT", "/var"), slog.String("LABEL", "my disk"))
+		if err := handler.Handle(context.TODO(), record); err != nil {
+			t.Fatal(err)
+		}
+		want := "<warning> disk almost full MOUNT=/var LABEL=\"my disk\"\n"
+		if color {
+			want = "\x1b[33m<warning> disk almost full\x1b[0m\x1b[2m MOUNT=/var LABEL=\"my disk\"\x1b[0m\n"
+		}
+		if got := buf.String(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestNewConsoleWriter(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if newConsoleWriter(f).color {
+		t.Error("expected no color for a regular file")
+	}
+}
+
+func TestNewConsoleWriterNoColor(t *testing.T) {
+	// A character device like a terminal.
+	f, err := os.Open("/dev/null")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	for env, color := range map[string]bool{"": true, "1": false} {
+		t.Setenv("NO_COLOR", env)
+		if got := newConsoleWriter(f).color; got != color {
+			t.Errorf("NO_COLOR=%q: expected color %t, got %t", env, color, got)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package slogjournal
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Names of the service credentials read when Options.Credentials is set.
+const (
+	CredentialLevel      = "slogjournal.level"
+	CredentialIdentifier = "slogjournal.identifier"
+	CredentialRedact     = "slogjournal.redact"
+	CredentialSigningKey = "slogjournal.signing-key"
+)
+
+// loadCredentials overrides h.opts with the settings found in the credentials
+// directory dir. Missing credentials are ignored.
+func (h *Handler) loadCredentials(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	read := func(name string) (string, bool, error) {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		return strings.TrimSpace(string(b)), true, nil
+	}
+
+	if s, ok, err := read(CredentialLevel); err != nil {
+		return err
+	} else if ok {
+		level, err := ParseLevel(s)
+		if err != nil {
+			return fmt.Errorf("credential %s: %w", CredentialLevel, err)
+		}
+		if v, ok := h.opts.Level.(interface{ Set(slog.Level) }); ok {
+			v.Set(level)
+		} else {
+			h.opts.Level = level
+		}
+	}
+
+	if s, ok, err := read(CredentialIdentifier); err != nil {
+		return err
+	} else if ok {
+		h.opts.SyslogIdentifier = s
+	}
+
+	if s, ok, err := read(CredentialRedact); err != nil {
+		return err
+	} else if ok {
+		h.opts.Redact = append(slices.Clip(h.opts.Redact), strings.Fields(s)...)
+	}
+
+	if s, ok, err := read(CredentialSigningKey); err != nil {
+		return err
+	} else if ok {
+		h.opts.SigningKey = []byte(s)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCredentials(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		CredentialLevel:      "warning\n",
+		CredentialIdentifier: "credential-app\n",
+		CredentialRedact:     "PASSWORD\nGROUP_TOKEN\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+
+	buf := new(bytes.Buffer)
+	level := new(slog.LevelVar)
+	handler, err := NewHandler(&Options{Level: level, SyslogIdentifier: "app", Credentials: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	if level.Level() != slog.LevelWarn {
+		t.Error("expected LevelWarn, got", level.Level())
+	}
+
+	record := slog.NewRecord(time.Now(), slog.LevelWarn, "Hello, World!", 0)
+	record.AddAttrs(slog.String("PASSWORD", "hunter2"), slog.String("USER", "alice"))
+	h := handler.WithGroup("GROUP").WithAttrs([]slog.Attr{slog.String("TOKEN", "secret")})
+	_ = h.Handle(context.TODO(), record)
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["SYSLOG_IDENTIFIER"] != "credential-app" {
+		t.Error("expected SYSLOG_IDENTIFIER=credential-app", kv)
+	}
+	if kv["GROUP_PASSWORD"] != "hunter2" || kv["GROUP_USER"] != "alice" || kv["GROUP_TOKEN"] != "REDACTED" {
+		t.Error("unexpected redaction", kv)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]slog.Level{
+		"debug":  slog.LevelDebug,
+		"trace":  LevelTrace,
+		"notice": LevelNotice,
+		"crit":   LevelCritical,
+		"INFO+2": slog.LevelInfo + 2,
+		"3":      slog.LevelError,
+	} {
+		if got, err := ParseLevel(s); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected error")
+	}
+}
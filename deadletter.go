@@ -0,0 +1,54 @@
+package slogjournal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ReadDeadLetters returns the entries in the dead-letter file at path, see
+// Options.DeadLetterPath. A truncated entry at the end of the file, e.g. from
+// a crash while writing it, is ignored.
+func ReadDeadLetters(path string) ([]Entry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for len(b) > 0 {
+		p, rest, ok := nextSpooled(b)
+		if !ok {
+			break
+		}
+		e, err := ParseEntry(p)
+		if err != nil {
+			return entries, fmt.Errorf("%s: entry %d: %w", path, len(entries)+1, err)
+		}
+		entries = append(entries, e)
+		b = rest
+	}
+	return entries, nil
+}
+
+// WriteEntry writes e to the journal as is, without the fields h adds to
+// the records it handles. It is meant for resubmitting entries, e.g. those
+// read with [ReadDeadLetters] once they are corrected. If e violates the
+// rules of journald, WriteEntry returns a *ValidationError and does not
+// write it.
+func (h *Handler) WriteEntry(e Entry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	return h.write(context.Background(), e.AppendNative(nil))
+}
+
+// deadLetter appends the entry b, which violates the rules of journald as
+// described by verr, to the dead-letter file, and returns verr.
+func (h *Handler) deadLetter(b []byte, verr error) error {
+	if err := h.deadLetters.append(b); err != nil {
+		return errors.Join(verr, fmt.Errorf("writing dead letter: %w", err))
+	}
+	h.diagnose(DiagnosticDeadLetter, "entry written to the dead-letter file", verr)
+	return verr
+}
@@ -0,0 +1,72 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeadLetters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deadletter")
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{DeadLetterPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "valid", 0)
+	record.AddAttrs(slog.String("KEY", "value"))
+	if err := handler.Handle(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() == 0 {
+		t.Fatal("expected the valid entry to be written")
+	}
+
+	buf.Reset()
+	record = slog.NewRecord(time.Now(), slog.LevelInfo, "invalid", 0)
+	record.AddAttrs(slog.String("key", "value"))
+	var verr *ValidationError
+	if err := handler.Handle(context.TODO(), record); !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Error("expected the invalid entry not to be written")
+	}
+
+	entries, err := ReadDeadLetters(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(entries))
+	}
+	e := entries[0]
+	if msg, _ := e.Get("MESSAGE"); string(msg) != "invalid" {
+		t.Errorf("expected the invalid entry, got %q", e)
+	}
+
+	if err := handler.WriteEntry(e); !errors.As(err, &verr) {
+		t.Errorf("expected *ValidationError, got %v", err)
+	}
+	for i := range e {
+		if e[i].Name == "key" {
+			e[i].Name = "KEY"
+		}
+	}
+	if err := handler.WriteEntry(e); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseEntry(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalEntries(got, e) {
+		t.Errorf("expected %q, got %q", e, got)
+	}
+}
@@ -0,0 +1,116 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MessageIDRepeated is the MESSAGE_ID of the entries that count the repeats
+// coalesced by Options.DedupWindow.
+const MessageIDRepeated = "3e0a4fd1b9e24c7f8d6c1a5b2f7e9d40"
+
+// RepeatedKey is the field of the entries written for coalesced repeats
+// that counts them.
+const RepeatedKey = "REPEATED"
+
+// deduper coalesces the records with the same key written within window of
+// each other.
+type deduper struct {
+	window time.Duration
+	key    func(r slog.Record) string
+
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+}
+
+// dedupEntry is the last record written with a key, and the number of
+// times it was repeated since.
+type dedupEntry struct {
+	written time.Time
+	level   slog.Level
+	message string
+	repeats uint64
+}
+
+// repeated is the summary of the repeats of a record.
+type repeated struct {
+	level   slog.Level
+	message string
+	n       uint64
+}
+
+func newDeduper(window time.Duration, key func(r slog.Record) string) *deduper {
+	return &deduper{window: window, key: key, entries: make(map[string]*dedupEntry)}
+}
+
+// check reports whether r repeats a record written less than window before
+// now, in which case it is counted rather than written. Otherwise it returns
+// the summaries of repeats to write before r.
+func (d *deduper) check(r slog.Record, now time.Time) (repeat bool, summaries []repeated) {
+	key := r.Message
+	if d.key != nil {
+		key = d.key(r)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e := d.entries[key]
+	if e != nil && now.Sub(e.written) < d.window {
+		e.repeats++
+		return true, nil
+	}
+	if e == nil {
+		// Like the windows of the rate limiter, the entries are bounded.
+		if len(d.entries) >= maxRateLimitKeys {
+			summaries = d.expire(now)
+		}
+		e = &dedupEntry{}
+		d.entries[key] = e
+	} else if e.repeats > 0 {
+		summaries = append(summaries, repeated{e.level, e.message, e.repeats})
+	}
+	*e = dedupEntry{written: now, level: r.Level, message: r.Message}
+	return false, summaries
+}
+
+// expire forgets the records written window or longer before now, and
+// returns the summaries of their repeats.
+func (d *deduper) expire(now time.Time) []repeated {
+	var summaries []repeated
+	for k, e := range d.entries {
+		if now.Sub(e.written) < d.window {
+			continue
+		}
+		if e.repeats > 0 {
+			summaries = append(summaries, repeated{e.level, e.message, e.repeats})
+		}
+		delete(d.entries, k)
+	}
+	return summaries
+}
+
+// flush returns the summaries of all repeats not yet written.
+func (d *deduper) flush() []repeated {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var summaries []repeated
+	for _, e := range d.entries {
+		if e.repeats > 0 {
+			summaries = append(summaries, repeated{e.level, e.message, e.repeats})
+			e.repeats = 0
+		}
+	}
+	return summaries
+}
+
+// writeRepeated writes an entry for each of summaries, at the level of the
+// repeated record.
+func (h *Handler) writeRepeated(summaries []repeated) {
+	for _, s := range summaries {
+		r := slog.NewRecord(time.Now(), s.level, "message repeated "+strconv.FormatUint(s.n, 10)+" times: "+s.message, 0)
+		r.AddAttrs(slog.String(MessageIDKey, MessageIDRepeated), slog.Uint64(RepeatedKey, s.n))
+		_ = h.root().Handle(context.Background(), r)
+	}
+}
@@ -0,0 +1,82 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	w := &queueWriter{}
+	handler, err := NewHandlerWithWriter(w, &Options{DedupWindow: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(handler)
+	for range 3 {
+		logger.Warn("connection refused", "ATTEMPT", 1)
+		logger.Info("retrying")
+	}
+	if len(w.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(w.entries))
+	}
+
+	// End the window of the first message.
+	handler.dedup.entries["connection refused"].written = time.Now().Add(-time.Hour)
+	logger.Warn("connection refused")
+	if len(w.entries) != 4 {
+		t.Fatalf("expected a summary and an entry, got %d entries", len(w.entries)-2)
+	}
+	summary := w.entries[2]
+	for k, want := range map[string]string{
+		MessageIDKey: MessageIDRepeated,
+		"MESSAGE":    "message repeated 2 times: connection refused",
+		"PRIORITY":   "4",
+		RepeatedKey:  "2",
+	} {
+		if v, _ := summary.Get(k); string(v) != want {
+			t.Errorf("expected %s=%s, got %q", k, want, v)
+		}
+	}
+	if _, ok := summary.Get("ATTEMPT"); ok {
+		t.Error("expected the summary without the attributes of the record")
+	}
+
+	// The repeats of the second message are written on Close.
+	if err := handler.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.entries) != 5 {
+		t.Fatalf("expected a summary on Close, got %d entries", len(w.entries)-4)
+	}
+	if got := w.message(4); got != "message repeated 2 times: retrying" {
+		t.Errorf("expected the repeats of retrying, got %q", got)
+	}
+}
+
+func TestDedupKey(t *testing.T) {
+	w := &queueWriter{}
+	handler, err := NewHandlerWithWriter(w, &Options{
+		DedupWindow: time.Hour,
+		DedupKey: func(r slog.Record) string {
+			key := r.Message
+			r.Attrs(func(a slog.Attr) bool {
+				if a.Key == "HOST" {
+					key += " " + a.Value.String()
+				}
+				return true
+			})
+			return key
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(handler)
+	for _, host := range []string{"a", "b", "a", "b"} {
+		logger.Error("connection refused", "HOST", host)
+	}
+	if len(w.entries) != 2 {
+		t.Fatalf("expected an entry per host, got %d", len(w.entries))
+	}
+}
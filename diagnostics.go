@@ -0,0 +1,100 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// MessageIDDiagnostic is the MESSAGE_ID of the entries written for
+// diagnostics with Options.DiagnosticEntries set.
+const MessageIDDiagnostic = "666c6eeec5264c08a2b7ae6f6e14b1ec"
+
+// DiagnosticKey is the field naming the DiagnosticKind of a diagnostic entry.
+const DiagnosticKey = "DIAGNOSTIC"
+
+// DiagnosticKind is the kind of a condition a handler reports about itself.
+type DiagnosticKind int
+
+const (
+	// DiagnosticLargeEntry reports an entry too large for a datagram, which
+	// was passed to journald in a file descriptor instead.
+	DiagnosticLargeEntry DiagnosticKind = iota
+	// DiagnosticSpooling reports that journald became unavailable and
+	// entries are spooled to Options.SpoolPath.
+	DiagnosticSpooling
+	// DiagnosticReplayed reports that the spooled entries were replayed
+	// into the journal.
+	DiagnosticReplayed
+	// DiagnosticDeadLetter reports an entry written to
+	// Options.DeadLetterPath.
+	DiagnosticDeadLetter
+	// DiagnosticWriterFailed reports that writing to journald failed: the
+	// handler returned by NewLazyHandler failed to connect, journald became
+	// unreachable, or a queued entry could not be written.
+	DiagnosticWriterFailed
+	// DiagnosticReconnected reports that journald is reachable again after
+	// it became unreachable.
+	DiagnosticReconnected
+	// DiagnosticRateLimited reports that the rate limit of
+	// Options.RateLimitInterval and Options.RateLimitBurst started
+	// suppressing entries.
+	DiagnosticRateLimited
+	// DiagnosticSanitized reports that Options.FieldNames renamed a field
+	// journald would reject. It is reported once per field name.
+	DiagnosticSanitized
+)
+
+var diagnosticKindNames = []string{"large-entry", "spooling", "replayed", "dead-letter", "writer-failed", "reconnected", "rate-limited", "sanitized"}
+
+func (k DiagnosticKind) String() string {
+	if k < 0 || int(k) >= len(diagnosticKindNames) {
+		return "DiagnosticKind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return diagnosticKindNames[k]
+}
+
+// Diagnostic describes a condition of the handler itself, such as falling
+// back to spooling, that is not an error of any single Handle call.
+type Diagnostic struct {
+	Kind DiagnosticKind
+	// Message describes the condition.
+	Message string
+	// Err is the error that caused the condition, if any.
+	Err error
+}
+
+// noDiagnose is the diagnose function of writers not created by a handler.
+func noDiagnose(DiagnosticKind, string, error) {}
+
+// diagnose reports a diagnostic according to Options.OnDiagnostic and
+// Options.DiagnosticEntries.
+func (h *Handler) diagnose(kind DiagnosticKind, msg string, err error) {
+	d := Diagnostic{kind, msg, err}
+	if h.opts.OnDiagnostic != nil {
+		h.opts.OnDiagnostic(d)
+	}
+	if !h.opts.DiagnosticEntries || kind == DiagnosticWriterFailed {
+		return
+	}
+	// Writing the entry may itself cause a diagnostic, which is not
+	// written again.
+	if !h.stats.diagnosing.CompareAndSwap(false, true) {
+		return
+	}
+	defer h.stats.diagnosing.Store(false)
+	r := slog.NewRecord(time.Now(), slog.LevelDebug, "slog-journal: "+msg, 0)
+	r.AddAttrs(slog.String(MessageIDKey, MessageIDDiagnostic), slog.String(DiagnosticKey, kind.String()))
+	if err != nil {
+		r.AddAttrs(slog.String("ERROR", err.Error()))
+	}
+	root := h.root()
+	root.opts.DeadLetterPath = ""
+	// Diagnostics of the writer behind a queue are reported on the
+	// goroutine draining the queue, which must not wait for room in it.
+	if a, ok := root.w.(*asyncWriter); ok {
+		root.w = a.w
+	}
+	_ = root.Handle(context.Background(), r)
+}
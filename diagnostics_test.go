@@ -0,0 +1,79 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiagnosticEntries(t *testing.T) {
+	buf := new(bytes.Buffer)
+	var diagnostics []Diagnostic
+	handler, err := NewHandler(&Options{
+		DeadLetterPath:    filepath.Join(t.TempDir(), "deadletter"),
+		DiagnosticEntries: true,
+		Level:             slog.LevelInfo,
+		OnDiagnostic:      func(d Diagnostic) { diagnostics = append(diagnostics, d) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "invalid", 0)
+	record.AddAttrs(slog.String("key", "value"))
+	_ = handler.Handle(context.TODO(), record)
+
+	if len(diagnostics) != 1 || diagnostics[0].Kind != DiagnosticDeadLetter || diagnostics[0].Err == nil {
+		t.Fatalf("expected a dead-letter diagnostic, got %v", diagnostics)
+	}
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{MessageIDKey: MessageIDDiagnostic, DiagnosticKey: "dead-letter", "PRIORITY": "7"} {
+		if kv[k] != v {
+			t.Errorf("expected %s=%s, got %q", k, v, kv[k])
+		}
+	}
+}
+
+func TestDiagnosticRateLimitedAndSanitized(t *testing.T) {
+	var kinds []DiagnosticKind
+	var messages []string
+	handler, err := NewHandler(&Options{
+		FieldNames:        FieldNamesSanitize,
+		RateLimitInterval: time.Hour,
+		RateLimitBurst:    2,
+		DiagnosticEntries: true,
+		OnDiagnostic: func(d Diagnostic) {
+			kinds = append(kinds, d.Kind)
+			messages = append(messages, d.Message)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	handler.w = buf
+
+	for range 4 {
+		record := slog.NewRecord(time.Now(), slog.LevelInfo, "message", 0)
+		record.AddAttrs(slog.String("http.status", "200"))
+		_ = handler.Handle(context.TODO(), record)
+	}
+	want := []DiagnosticKind{DiagnosticSanitized, DiagnosticRateLimited}
+	if !slices.Equal(kinds, want) {
+		t.Fatalf("expected diagnostics %v, got %v: %q", want, kinds, messages)
+	}
+	if messages[0] != `renamed field "http.status" to HTTP_STATUS` {
+		t.Errorf("unexpected message %q", messages[0])
+	}
+	if n := bytes.Count(buf.Bytes(), []byte("DIAGNOSTIC=")); n != 2 {
+		t.Errorf("expected 2 diagnostic entries, got %d", n)
+	}
+}
@@ -0,0 +1,83 @@
+package slogjournal
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+)
+
+type valueEncoder struct {
+	typ    reflect.Type
+	encode func(any) []byte
+}
+
+var valueEncoders = struct {
+	sync.RWMutex
+	types      map[reflect.Type]func(any) []byte
+	interfaces []valueEncoder
+}{
+	types: map[reflect.Type]func(any) []byte{},
+}
+
+// RegisterValueEncoder registers enc as the encoder of [slog.KindAny] values
+// of type T, replacing the default [slog.Value.String] formatting. If T is an
+// interface type, enc is used for all values implementing it that have no
+// encoder registered for their concrete type.
+//
+//	slogjournal.RegisterValueEncoder(func(ip net.IP) []byte {
+//		return []byte(ip.String())
+//	})
+//
+// RegisterValueEncoder is meant to be called during initialization.
+// Registering an encoder for a type again replaces the previous one.
+func RegisterValueEncoder[T any](enc func(T) []byte) {
+	typ := reflect.TypeFor[T]()
+	encode := func(v any) []byte { return enc(v.(T)) }
+
+	valueEncoders.Lock()
+	defer valueEncoders.Unlock()
+	if typ.Kind() != reflect.Interface {
+		valueEncoders.types[typ] = encode
+		return
+	}
+	for i, e := range valueEncoders.interfaces {
+		if e.typ == typ {
+			valueEncoders.interfaces[i].encode = encode
+			return
+		}
+	}
+	valueEncoders.interfaces = append(valueEncoders.interfaces, valueEncoder{typ, encode})
+}
+
+// unregisterValueEncoder undoes [RegisterValueEncoder] for T.
+func unregisterValueEncoder[T any]() {
+	typ := reflect.TypeFor[T]()
+	valueEncoders.Lock()
+	defer valueEncoders.Unlock()
+	delete(valueEncoders.types, typ)
+	valueEncoders.interfaces = slices.DeleteFunc(valueEncoders.interfaces, func(e valueEncoder) bool {
+		return e.typ == typ
+	})
+}
+
+// encodeValue encodes v with its registered encoder, if any.
+func encodeValue(v any) ([]byte, bool) {
+	if v == nil {
+		return nil, false
+	}
+	valueEncoders.RLock()
+	defer valueEncoders.RUnlock()
+	if len(valueEncoders.types) == 0 && len(valueEncoders.interfaces) == 0 {
+		return nil, false
+	}
+	typ := reflect.TypeOf(v)
+	if encode, ok := valueEncoders.types[typ]; ok {
+		return encode(v), true
+	}
+	for _, e := range valueEncoders.interfaces {
+		if typ.Implements(e.typ) {
+			return e.encode(v), true
+		}
+	}
+	return nil, false
+}
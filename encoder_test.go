@@ -0,0 +1,59 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type testDigest [4]byte
+
+type testMarker interface{ marker() string }
+
+type testMarked struct{}
+
+func (testMarked) marker() string { return "marked" }
+
+func TestRegisterValueEncoder(t *testing.T) {
+	RegisterValueEncoder(func(d testDigest) []byte {
+		return []byte(hex.EncodeToString(d[:]))
+	})
+	RegisterValueEncoder(func(m testMarker) []byte {
+		return []byte("<" + m.marker() + ">")
+	})
+	t.Cleanup(func() {
+		unregisterValueEncoder[testDigest]()
+		unregisterValueEncoder[testMarker]()
+	})
+
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	record.AddAttrs(
+		slog.Any("DIGEST", testDigest{0xde, 0xad, 0xbe, 0xef}),
+		slog.Any("MARKED", testMarked{}),
+		slog.Any("OTHER", []int{1, 2}),
+	)
+	_ = handler.Handle(context.TODO(), record)
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["DIGEST"] != "deadbeef" {
+		t.Errorf("expected DIGEST=deadbeef, got %q", kv["DIGEST"])
+	}
+	if kv["MARKED"] != "<marked>" {
+		t.Errorf("expected MARKED=<marked>, got %q", kv["MARKED"])
+	}
+	if kv["OTHER"] != "[1 2]" {
+		t.Errorf("expected OTHER=[1 2], got %q", kv["OTHER"])
+	}
+}
@@ -0,0 +1,40 @@
+package slogjournal
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"syscall"
+)
+
+// Fields of errors expanded by Options.ExpandErrors.
+const (
+	ErrorKey      = "ERROR"
+	ErrorTypeKey  = "ERROR_TYPE"
+	StacktraceKey = "STACKTRACE"
+	ErrnoKey      = "ERRNO"
+)
+
+// StackTracer is implemented by errors that carry the stack trace of where
+// they were created. Options.ExpandErrors writes it to the STACKTRACE field.
+type StackTracer interface {
+	StackTrace() string
+}
+
+// appendError appends err as the fields described by Options.ExpandErrors.
+func (h *Handler) appendError(b []byte, prefix string, err error) []byte {
+	b = h.appendKV(b, prefix+ErrorKey, []byte(err.Error()))
+	b = h.appendKV(b, prefix+ErrorTypeKey, []byte(fmt.Sprintf("%T", err)))
+	var st StackTracer
+	if errors.As(err, &st) {
+		if s := st.StackTrace(); s != "" {
+			b = h.appendKV(b, prefix+StacktraceKey, []byte(s))
+		}
+	}
+	var errno syscall.Errno
+	if errors.As(err, &errno) && errno != 0 {
+		var num [20]byte
+		b = h.appendKV(b, prefix+ErrnoKey, strconv.AppendUint(num[:0], uint64(errno), 10))
+	}
+	return b
+}
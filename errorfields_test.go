@@ -0,0 +1,62 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type stackError struct{ error }
+
+func (stackError) StackTrace() string { return "main.main()\n\tmain.go:12" }
+
+func TestExpandErrors(t *testing.T) {
+	errno := syscall.Errno(2)
+	pathErr := &fs.PathError{Op: "open", Path: "/missing", Err: errno}
+	for _, tt := range []struct {
+		name string
+		err  error
+		want map[string]string
+	}{
+		{"plain", errors.New("boom"), map[string]string{
+			ErrorKey: "boom", ErrorTypeKey: "*errors.errorString", StacktraceKey: "", ErrnoKey: "",
+		}},
+		{"errno", pathErr, map[string]string{
+			ErrorKey: "open /missing: " + errno.Error(), ErrorTypeKey: "*fs.PathError", ErrnoKey: "2",
+		}},
+		{"stack", fmt.Errorf("wrapped: %w", stackError{os.ErrClosed}), map[string]string{
+			ErrorKey: "wrapped: file already closed", ErrorTypeKey: "*fmt.wrapError", StacktraceKey: "main.main()\n\tmain.go:12",
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			handler, err := NewHandler(&Options{ExpandErrors: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler.w = buf
+			r := slog.NewRecord(time.Now(), slog.LevelError, "failed", 0)
+			r.AddAttrs(slog.Any("ERR", tt.err))
+			_ = handler.Handle(context.TODO(), r)
+			kv, err := deserializeKeyValue(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := kv["ERR"]; ok {
+				t.Error("expected no ERR field", kv)
+			}
+			for k, want := range tt.want {
+				if kv[k] != want {
+					t.Errorf("expected %s=%q, got %q", k, want, kv[k])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package slogjournal
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// errorMessageID maps the errors matched by match to a MESSAGE_ID.
+type errorMessageID struct {
+	match func(error) bool
+	id    string
+}
+
+var errorMessageIDs = struct {
+	sync.RWMutex
+	ids []errorMessageID
+}{}
+
+// RegisterErrorMessageID registers id as the MESSAGE_ID of entries logged
+// with an error attribute for which errors.Is(err, target) is true. Handlers
+// add a MESSAGE_ID field to such entries unless they have one already, so
+// that known errors are identifiable, e.g. by alerting rules and catalog
+// entries, without every call site adding it:
+//
+//	slogjournal.RegisterErrorMessageID(fs.ErrPermission, "0f62a1a4a20a4b8c9b1fd4d5bf1c3c8e")
+//
+// If more than one registration matches an error, the first one wins.
+// RegisterErrorMessageID is meant to be called during initialization.
+func RegisterErrorMessageID(target error, id string) {
+	registerErrorMessageID(func(err error) bool { return errors.Is(err, target) }, id)
+}
+
+// RegisterErrorTypeMessageID registers id as the MESSAGE_ID of entries logged
+// with an error attribute for which errors.As finds an error of type T, like
+// [RegisterErrorMessageID]:
+//
+//	slogjournal.RegisterErrorTypeMessageID[*fs.PathError]("0f62a1a4a20a4b8c9b1fd4d5bf1c3c8e")
+func RegisterErrorTypeMessageID[T error](id string) {
+	registerErrorMessageID(func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}, id)
+}
+
+func registerErrorMessageID(match func(error) bool, id string) {
+	errorMessageIDs.Lock()
+	defer errorMessageIDs.Unlock()
+	errorMessageIDs.ids = append(errorMessageIDs.ids, errorMessageID{match, id})
+}
+
+// lookupErrorMessageID returns the MESSAGE_ID registered for err.
+func lookupErrorMessageID(err error) (string, bool) {
+	errorMessageIDs.RLock()
+	defer errorMessageIDs.RUnlock()
+	for _, e := range errorMessageIDs.ids {
+		if e.match(err) {
+			return e.id, true
+		}
+	}
+	return "", false
+}
+
+var messageIDField = []byte("\n" + MessageIDKey + "=")
+
+// recordMessageID returns the MESSAGE_ID registered for the first error
+// attribute of r, or else Options.DefaultMessageID, unless r or h already
+// have a MESSAGE_ID.
+func (h *Handler) recordMessageID(r slog.Record) (string, bool) {
+	errorMessageIDs.RLock()
+	n := len(errorMessageIDs.ids)
+	errorMessageIDs.RUnlock()
+	if n == 0 && h.opts.DefaultMessageID == "" {
+		return "", false
+	}
+	var err error
+	hasID := false
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == MessageIDKey && ValidMessageID(a.Value.Resolve().String()) {
+			hasID = true
+			return false
+		}
+		if a.Value.Kind() == slog.KindAny && err == nil {
+			err, _ = a.Value.Any().(error)
+		}
+		return true
+	})
+	if hasID {
+		return "", false
+	}
+	if bytes.HasPrefix(h.preformatted, messageIDField[1:]) || bytes.Contains(h.preformatted, messageIDField) {
+		return "", false
+	}
+	if err != nil && n > 0 {
+		if id, ok := lookupErrorMessageID(err); ok {
+			return id, true
+		}
+	}
+	return h.opts.DefaultMessageID, h.opts.DefaultMessageID != ""
+}
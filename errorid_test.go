@@ -0,0 +1,70 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestErrorMessageID(t *testing.T) {
+	errorMessageIDs.Lock()
+	saved := errorMessageIDs.ids
+	errorMessageIDs.ids = nil
+	errorMessageIDs.Unlock()
+	defer func() {
+		errorMessageIDs.Lock()
+		errorMessageIDs.ids = saved
+		errorMessageIDs.Unlock()
+	}()
+
+	RegisterErrorMessageID(fs.ErrPermission, "0f62a1a4a20a4b8c9b1fd4d5bf1c3c8e")
+	RegisterErrorTypeMessageID[*fs.PathError]("3b5e1f5a0b4c4e0e8f1c6a7d9e2b4c6d")
+
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	tests := []struct {
+		name    string
+		handler slog.Handler
+		attrs   []slog.Attr
+		want    string
+	}{
+		{"Is", handler, []slog.Attr{slog.Any("ERROR", fmt.Errorf("open: %w", fs.ErrPermission))}, "0f62a1a4a20a4b8c9b1fd4d5bf1c3c8e"},
+		{"As", handler, []slog.Attr{slog.Any("ERROR", &fs.PathError{Op: "open", Path: "/x", Err: fs.ErrNotExist})}, "3b5e1f5a0b4c4e0e8f1c6a7d9e2b4c6d"},
+		{"Unregistered", handler, []slog.Attr{slog.Any("ERROR", errors.New("boom"))}, ""},
+		{"Explicit", handler, []slog.Attr{slog.Any("ERROR", fs.ErrPermission), slog.String(MessageIDKey, "5c1d2e7f9a3b4c8d8e6f0a1b2c3d4e5f")}, "5c1d2e7f9a3b4c8d8e6f0a1b2c3d4e5f"},
+		{"WithAttrs", handler.WithAttrs([]slog.Attr{slog.String(MessageIDKey, "5c1d2e7f9a3b4c8d8e6f0a1b2c3d4e5f")}), []slog.Attr{slog.Any("ERROR", fs.ErrPermission)}, "5c1d2e7f9a3b4c8d8e6f0a1b2c3d4e5f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			record := slog.NewRecord(time.Now(), slog.LevelError, "failed", 0)
+			record.AddAttrs(tt.attrs...)
+			if err := tt.handler.Handle(context.TODO(), record); err != nil {
+				t.Fatal(err)
+			}
+			e, err := ParseEntry(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, f := range e {
+				if f.Name == MessageIDKey {
+					ids = append(ids, string(f.Value))
+				}
+			}
+			if tt.want == "" && len(ids) != 0 || tt.want != "" && (len(ids) != 1 || ids[0] != tt.want) {
+				t.Errorf("expected MESSAGE_ID %q, got %q", tt.want, ids)
+			}
+		})
+	}
+}
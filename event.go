@@ -0,0 +1,94 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// MessageIDKey is the journal field that identifies the kind of an entry.
+const MessageIDKey = "MESSAGE_ID"
+
+// MessageID returns an Attr that sets the MESSAGE_ID of an entry to id, 32
+// hexadecimal characters as generated by systemd-id128 new. Entries can
+// then be filtered with journalctl MESSAGE_ID=id and explained by the
+// message catalog. The field is not prefixed by the groups of the handler.
+// If id is invalid, it is left out and Handle returns a *ValidationError.
+func MessageID(id string) slog.Attr {
+	return slog.String(MessageIDKey, id)
+}
+
+// ValidMessageID reports whether id is a valid MESSAGE_ID: a 128-bit ID
+// formatted as 32 hexadecimal characters.
+func ValidMessageID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	for _, c := range []byte(id) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// Event describes a recurring event, such as a service starting or a
+// health check failing, so that it is defined once and logged consistently.
+// Declare events as package-level variables:
+//
+//	var checkFailed = slogjournal.Event{
+//		ID:      "f20bb34dbced4b6c8453932f5742278d",
+//		Level:   slog.LevelWarn,
+//		Message: "health check failed",
+//	}
+//
+// and log them with [LogEvent] or [Event.Log]:
+//
+//	checkFailed.Log(ctx, logger, slog.String("CHECK", name))
+type Event struct {
+	// ID is the MESSAGE_ID of the event, 32 lowercase hexadecimal
+	// characters as generated by systemd-id128 new.
+	ID string
+	// Level is the level the event is logged at.
+	Level slog.Level
+	// Message is the message the event is logged with.
+	Message string
+	// Attrs are added to every entry of the event.
+	Attrs []slog.Attr
+}
+
+// With returns a copy of e that adds attrs to every entry.
+func (e Event) With(attrs ...slog.Attr) Event {
+	e.Attrs = append(e.Attrs[:len(e.Attrs):len(e.Attrs)], attrs...)
+	return e
+}
+
+// Log logs e with l. attrs are added after the MESSAGE_ID and the attributes
+// of e.
+func (e Event) Log(ctx context.Context, l *slog.Logger, attrs ...slog.Attr) {
+	logEvent(ctx, l, e, attrs)
+}
+
+// LogEvent logs evt with l. It is the same as evt.Log(ctx, l, attrs...).
+func LogEvent(ctx context.Context, l *slog.Logger, evt Event, attrs ...slog.Attr) {
+	logEvent(ctx, l, evt, attrs)
+}
+
+func logEvent(ctx context.Context, l *slog.Logger, e Event, attrs []slog.Attr) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.Enabled(ctx, e.Level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // skip [Callers, logEvent, LogEvent]
+	r := slog.NewRecord(time.Now(), e.Level, e.Message, pcs[0])
+	if e.ID != "" {
+		r.AddAttrs(slog.String(MessageIDKey, e.ID))
+	}
+	r.AddAttrs(e.Attrs...)
+	r.AddAttrs(attrs...)
+	_ = l.Handler().Handle(ctx, r)
+}
@@ -0,0 +1,146 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvent(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+	logger := slog.New(handler)
+
+	evt := Event{
+		ID:      "f20bb34dbced4b6c8453932f5742278d",
+		Level:   slog.LevelWarn,
+		Message: "health check failed",
+		Attrs:   []slog.Attr{slog.String("COMPONENT", "checker")},
+	}
+	evt.With(slog.String("CHECK", "disk")).Log(context.Background(), logger, slog.Int("ATTEMPT", 3))
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{
+		"MESSAGE":    "health check failed",
+		"PRIORITY":   "4",
+		MessageIDKey: evt.ID,
+		"COMPONENT":  "checker",
+		"CHECK":      "disk",
+		"ATTEMPT":    "3",
+	} {
+		if kv[k] != v {
+			t.Errorf("expected %s=%s, got %q", k, v, kv[k])
+		}
+	}
+	if !strings.HasSuffix(kv["CODE_FILE"], "event_test.go") {
+		t.Errorf("expected CODE_FILE of the caller, got %q", kv["CODE_FILE"])
+	}
+	if len(evt.Attrs) != 1 {
+		t.Errorf("With modified the event: %v", evt.Attrs)
+	}
+
+	buf.Reset()
+	evt.Log(context.Background(), logger.WithGroup("DB"))
+	kv, err = deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv[MessageIDKey] != evt.ID || kv["DB_COMPONENT"] != "checker" {
+		t.Errorf("expected the MESSAGE_ID of the event not to be prefixed by the group, got %v", kv)
+	}
+
+	buf.Reset()
+	LogEvent(context.Background(), logger, Event{ID: evt.ID, Level: slog.LevelDebug})
+	if buf.Len() != 0 {
+		t.Errorf("expected disabled event to be dropped, got %q", buf)
+	}
+}
+
+func TestMessageID(t *testing.T) {
+	const defaultID = "0d3a0bc3a4b04bdcb8a6b1f1c0b24e8d"
+	const id = "f20bb34dbced4b6c8453932f5742278d"
+
+	for _, tt := range []struct {
+		id   string
+		want bool
+	}{
+		{id, true},
+		{strings.ToUpper(id), true},
+		{"", false},
+		{id[:31], false},
+		{id[:31] + "g", false},
+		{"f20bb34d-bced-4b6c-8453-932f5742278d", false},
+	} {
+		if got := ValidMessageID(tt.id); got != tt.want {
+			t.Errorf("ValidMessageID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+
+	if _, err := NewHandler(&Options{DefaultMessageID: "startup"}); err == nil {
+		t.Error("expected an invalid default MESSAGE_ID to be rejected")
+	}
+
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{DefaultMessageID: defaultID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+	for _, tt := range []struct {
+		attrs []slog.Attr
+		want  string
+	}{
+		{nil, defaultID},
+		{[]slog.Attr{MessageID(id)}, id},
+	} {
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+		r.AddAttrs(tt.attrs...)
+		_ = handler.Handle(context.Background(), r)
+		kv, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kv[MessageIDKey] != tt.want {
+			t.Errorf("expected MESSAGE_ID=%s, got %q", tt.want, kv[MessageIDKey])
+		}
+	}
+
+	// MESSAGE_ID is not prefixed by groups, and invalid IDs are left out.
+	for _, tt := range []struct {
+		id      string
+		want    string
+		invalid bool
+	}{
+		{id, id, false},
+		{"startup", defaultID, true},
+	} {
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+		r.AddAttrs(MessageID(tt.id))
+		err := handler.WithGroup("DB").Handle(context.Background(), r)
+		if _, ok := err.(*ValidationError); ok != tt.invalid {
+			t.Errorf("MESSAGE_ID %q: unexpected error %v", tt.id, err)
+		}
+		kv, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kv[MessageIDKey] != tt.want || kv["DB_MESSAGE_ID"] != "" {
+			t.Errorf("expected MESSAGE_ID=%s under a group, got %v", tt.want, kv)
+		}
+	}
+
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	r.AddAttrs(MessageID("startup"))
+	if err := handler.Validate(context.Background(), r); err == nil || !strings.Contains(err.Error(), MessageIDKey) {
+		t.Errorf("expected an invalid MESSAGE_ID to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build slogjournal_nosys
+
+package slogjournal
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSource   = advapi32.NewProc("RegisterEventSourceW")
+	procReportEvent           = advapi32.NewProc("ReportEventW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+)
+
+func registerEventSource(source *uint16) (syscall.Handle, error) {
+	r, _, err := procRegisterEventSource.Call(0, uintptr(unsafe.Pointer(source)))
+	if r == 0 {
+		return 0, err
+	}
+	return syscall.Handle(r), nil
+}
+
+func reportEvent(h syscall.Handle, etype uint16, id uint32, strs []*uint16) error {
+	r, _, err := procReportEvent.Call(uintptr(h), uintptr(etype), 0, uintptr(id), 0,
+		uintptr(len(strs)), 0, uintptr(unsafe.Pointer(&strs[0])), 0)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func deregisterEventSource(h syscall.Handle) error {
+	r, _, err := procDeregisterEventSource.Call(uintptr(h))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+//go:build !slogjournal_nosys
+
+package slogjournal
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+func registerEventSource(source *uint16) (syscall.Handle, error) {
+	h, err := windows.RegisterEventSource(nil, source)
+	return syscall.Handle(h), err
+}
+
+func reportEvent(h syscall.Handle, etype uint16, id uint32, strs []*uint16) error {
+	return windows.ReportEvent(windows.Handle(h), etype, 0, id, 0, uint16(len(strs)), 0, &strs[0], nil)
+}
+
+func deregisterEventSource(h syscall.Handle) error {
+	return windows.DeregisterEventSource(windows.Handle(h))
+}
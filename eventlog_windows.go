@@ -0,0 +1,132 @@
+package slogjournal
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"syscall"
+	"unicode/utf8"
+)
+
+// eventID is the event identifier of all events written to the event log.
+const eventID = 1
+
+// maxEventStringLen is the maximum length of an insertion string of an
+// event, in UTF-16 code units.
+const maxEventStringLen = 31839
+
+// Event types, EVENTLOG_*_TYPE in winnt.h.
+const (
+	eventlogErrorType       = 0x1
+	eventlogWarningType     = 0x2
+	eventlogInformationType = 0x4
+)
+
+// eventLogWriter writes entries to the Windows Event Log. The MESSAGE field
+// becomes the first insertion string of the event, and all other fields
+// follow as NAME=VALUE insertion strings, which show up as the Data elements
+// of the event's EventData.
+type eventLogWriter struct {
+	handle syscall.Handle
+}
+
+// newWriter returns the writer for the Windows Event Log.
+func (h *Handler) newWriter() (io.Writer, error) {
+	source, err := syscall.UTF16PtrFromString(string(h.identifier))
+	if err != nil {
+		return nil, err
+	}
+	handle, err := registerEventSource(source)
+	if err != nil {
+		return nil, err
+	}
+	return &eventLogWriter{handle: handle}, nil
+}
+
+func (w *eventLogWriter) sink() string {
+	return "Windows Event Log"
+}
+
+// Write writes a single entry in the native protocol format as an event.
+func (w *eventLogWriter) Write(p []byte) (int, error) {
+	fields, err := ParseEntry(p)
+	if err != nil {
+		return 0, err
+	}
+	etype := uint16(eventlogInformationType)
+	strs := []*uint16{nil}
+	for _, f := range fields {
+		switch f.Name {
+		case "MESSAGE":
+			msg, err := eventStringPtr(eventString(f.Value))
+			if err != nil {
+				return 0, err
+			}
+			strs[0] = msg
+			continue
+		case "PRIORITY":
+			etype = priorityToEventType(f.Value)
+		}
+		s, err := eventStringPtr(f.Name + "=" + eventString(f.Value))
+		if err != nil {
+			return 0, err
+		}
+		strs = append(strs, s)
+	}
+	if strs[0] == nil {
+		strs[0], _ = syscall.UTF16PtrFromString("")
+	}
+	if err := reportEvent(w.handle, etype, eventID, strs); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close deregisters the event source.
+func (w *eventLogWriter) Close() error {
+	return deregisterEventSource(w.handle)
+}
+
+// eventStringPtr converts s to an insertion string, truncated to
+// maxEventStringLen, which ReportEvent rejects longer strings beyond.
+func eventStringPtr(s string) (*uint16, error) {
+	u, err := syscall.UTF16FromString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(u) > maxEventStringLen+1 {
+		u = u[:maxEventStringLen+1]
+		// Do not keep the high surrogate of a split surrogate pair.
+		if c := u[maxEventStringLen-1]; c >= 0xd800 && c < 0xdc00 {
+			u = u[:maxEventStringLen]
+		}
+		u[len(u)-1] = 0
+	}
+	return &u[0], nil
+}
+
+// priorityToEventType maps a PRIORITY value to an event type.
+func priorityToEventType(v []byte) uint16 {
+	pri, err := strconv.Atoi(string(v))
+	switch {
+	case err != nil:
+		return eventlogInformationType
+	case Priority(pri) <= PriorityError:
+		return eventlogErrorType
+	case Priority(pri) == PriorityWarning:
+		return eventlogWarningType
+	default:
+		return eventlogInformationType
+	}
+}
+
+// eventString returns v as a string that can be passed to the event log.
+// Binary values are quoted.
+func eventString(v []byte) string {
+	if bytes.IndexByte(v, 0) != -1 || !utf8.Valid(v) {
+		return strconv.Quote(string(v))
+	}
+	return string(v)
+}
+
+var _ io.WriteCloser = &eventLogWriter{}
@@ -0,0 +1,65 @@
+package slogjournal
+
+import (
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func TestPriorityToEventType(t *testing.T) {
+	for pri, want := range map[Priority]uint16{
+		PriorityEmergency: eventlogErrorType,
+		PriorityError:     eventlogErrorType,
+		PriorityWarning:   eventlogWarningType,
+		PriorityNotice:    eventlogInformationType,
+		PriorityDebug:     eventlogInformationType,
+	} {
+		if got := priorityToEventType([]byte(strconv.Itoa(int(pri)))); got != want {
+			t.Errorf("priorityToEventType(%d) = %d, want %d", pri, got, want)
+		}
+	}
+}
+
+func TestEventLogWriter(t *testing.T) {
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b []byte
+	b = handler.appendKV(b, "MESSAGE", []byte("Hello, World!"))
+	b = handler.appendKV(b, "PRIORITY", []byte("4"))
+	if _, err := handler.w.Write(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEventStringPtr(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		want int
+	}{
+		{"short", 5},
+		{strings.Repeat("x", maxEventStringLen+10), maxEventStringLen},
+		{strings.Repeat("x", maxEventStringLen-1) + "😀", maxEventStringLen - 1},
+	} {
+		p, err := eventStringPtr(tt.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(utf16PtrToString(p)); got != tt.want {
+			t.Errorf("expected %d characters, got %d", tt.want, got)
+		}
+	}
+}
+
+// utf16PtrToString returns the NUL-terminated UTF-16 string at p, without
+// golang.org/x/sys/windows so that the test builds with slogjournal_nosys.
+func utf16PtrToString(p *uint16) string {
+	n := 0
+	for *(*uint16)(unsafe.Add(unsafe.Pointer(p), 2*n)) != 0 {
+		n++
+	}
+	return syscall.UTF16ToString(unsafe.Slice(p, n))
+}
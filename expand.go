@@ -0,0 +1,96 @@
+package slogjournal
+
+import (
+	"encoding"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// structField describes a field of a struct expanded by Options.ExpandStructs.
+type structField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+}
+
+// structFields caches the structFields of struct types.
+var structFields sync.Map // reflect.Type -> []structField
+
+// expandStruct returns the fields of v as Attrs if v is a struct, or a
+// non-nil pointer to one, that does not format itself as a
+// fmt.Stringer, error or encoding.TextMarshaler.
+func expandStruct(v any) ([]slog.Attr, bool) {
+	switch v.(type) {
+	case fmt.Stringer, error, encoding.TextMarshaler:
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, false
+	}
+	fields := typeFields(rv.Type())
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		fv, err := rv.FieldByIndexErr(f.index)
+		if err != nil || f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		attrs = append(attrs, slog.Any(f.name, fv.Interface()))
+	}
+	return attrs, true
+}
+
+// typeFields returns the exported fields of the struct type t, including
+// those of embedded structs, named by their journal struct tag or else
+// their Go name in upper snake case.
+func typeFields(t reflect.Type) []structField {
+	if fields, ok := structFields.Load(t); ok {
+		return fields.([]structField)
+	}
+	var fields []structField
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() {
+			continue
+		}
+		tag := sf.Tag.Get("journal")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			if ft := sf.Type; sf.Anonymous && (ft.Kind() == reflect.Struct || ft.Kind() == reflect.Pointer && ft.Elem().Kind() == reflect.Struct) {
+				// The fields of embedded structs are promoted.
+				continue
+			}
+			name = snakeCase(sf.Name)
+		}
+		fields = append(fields, structField{sf.Index, name, opts == "omitempty"})
+	}
+	structFields.Store(t, fields)
+	return fields
+}
+
+// snakeCase returns the Go name name in upper snake case, e.g. USER_ID for
+// UserID and HTTP_STATUS for HTTPStatus.
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) &&
+			(!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
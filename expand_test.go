@@ -0,0 +1,79 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+type expandBase struct {
+	RequestID string
+}
+
+type expandUser struct {
+	*expandBase
+	UserID   int
+	Name     string `journal:"USER_NAME"`
+	Password string `journal:"-"`
+	Email    string `journal:",omitempty"`
+	Addr     netip.Addr
+	Created  time.Time
+	Manager  *expandUser
+	internal int
+}
+
+func TestExpandStructs(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{ExpandStructs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	u := &expandUser{
+		expandBase: &expandBase{RequestID: "r1"},
+		UserID:     42,
+		Name:       "alice",
+		Password:   "secret",
+		Addr:       netip.MustParseAddr("192.0.2.1"),
+		Created:    time.UnixMicro(1700000000000000),
+		Manager:    &expandUser{UserID: 7},
+	}
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	record.AddAttrs(slog.Any("USER", u))
+	if err := handler.Handle(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{
+		"USER_REQUEST_ID":      "r1",
+		"USER_USER_ID":         "42",
+		"USER_USER_NAME":       "alice",
+		"USER_ADDR":            "192.0.2.1",
+		"USER_CREATED":         "1700000000000000",
+		"USER_MANAGER_USER_ID": "7",
+	} {
+		if kv[k] != v {
+			t.Errorf("expected %s=%s, got %q", k, v, kv[k])
+		}
+	}
+	for _, k := range []string{"USER_PASSWORD", "USER_EMAIL", "USER_INTERNAL", "USER_MANAGER_REQUEST_ID", "USER"} {
+		if _, ok := kv[k]; ok {
+			t.Errorf("expected no %s field", k)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{"UserID": "USER_ID", "HTTPStatus": "HTTP_STATUS", "Name": "NAME", "A": "A"} {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package slogjournal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// NewExportWriter returns a writer for [NewHandlerWithWriter] that writes
+// entries to w in the [journal export format], so that the entries of hosts
+// without a journal, or without a network connection, can be shipped as
+// files and imported with systemd-journal-remote:
+//
+//	f, _ := os.OpenFile("app.export", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+//	h, _ := slogjournal.NewHandlerWithWriter(slogjournal.NewExportWriter(f), nil)
+//
+// and later
+//
+//	systemd-journal-remote --output=/var/log/journal/remote/ app.export
+//
+// Every entry gets a __REALTIME_TIMESTAMP field, which
+// systemd-journal-remote requires, from its SYSLOG_TIMESTAMP if that is in
+// microseconds, or else from the time it is written. Values that are not
+// printable UTF-8 are written in the binary form. Each entry is written to
+// w in a single call of w.Write, and entries written concurrently are not
+// interleaved. If w is an io.Closer, [Handler.Close] closes it.
+//
+// [journal export format]: https://systemd.io/JOURNAL_EXPORT_FORMATS/
+func NewExportWriter(w io.Writer) io.Writer {
+	return &exportWriter{w: w}
+}
+
+// exportWriter converts entries in the native protocol format to the
+// journal export format.
+type exportWriter struct {
+	w io.Writer
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Write writes a single entry in the native protocol format.
+func (w *exportWriter) Write(p []byte) (int, error) {
+	e, err := ParseEntry(p)
+	if err != nil {
+		return 0, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = appendRealtime(w.buf[:0], e)
+	w.buf = e.AppendExport(w.buf)
+	if _, err := w.w.Write(w.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *exportWriter) Close() error {
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (w *exportWriter) sink() string {
+	return "export format to " + sinkOf(w.w)
+}
+
+// appendRealtime appends a __REALTIME_TIMESTAMP field for e to b, unless e
+// has one.
+func appendRealtime(b []byte, e Entry) []byte {
+	if _, ok := e.Get("__REALTIME_TIMESTAMP"); ok {
+		return b
+	}
+	b = append(b, "__REALTIME_TIMESTAMP="...)
+	ts, _ := e.Get("SYSLOG_TIMESTAMP")
+	if usec, err := strconv.ParseInt(string(ts), 10, 64); err == nil {
+		b = strconv.AppendInt(b, usec, 10)
+	} else {
+		b = strconv.AppendInt(b, time.Now().UnixMicro(), 10)
+	}
+	return append(b, '\n')
+}
+
+// AppendExport appends e in the [journal export format] to b, followed by
+// the empty line that ends an entry. Values that are not printable UTF-8,
+// including those containing a newline, use the binary form, like
+// journalctl -o export.
+//
+// [journal export format]: https://systemd.io/JOURNAL_EXPORT_FORMATS/
+func (e Entry) AppendExport(b []byte) []byte {
+	for _, f := range e {
+		if printable(f.Value) {
+			b = append(b, f.Name...)
+			b = append(b, '=')
+			b = append(b, f.Value...)
+		} else {
+			b = append(b, f.Name...)
+			b = append(b, '\n')
+			b = binary.LittleEndian.AppendUint64(b, uint64(len(f.Value)))
+			b = append(b, f.Value...)
+		}
+		b = append(b, '\n')
+	}
+	return append(b, '\n')
+}
+
+// printable reports whether v is valid UTF-8 without control characters
+// other than tabs.
+func printable(v []byte) bool {
+	if !utf8.Valid(v) {
+		return false
+	}
+	return bytes.IndexFunc(v, func(r rune) bool { return (r < ' ' && r != '\t') || r == 0x7f }) == -1
+}
+
+var _ io.Writer = &exportWriter{}
@@ -0,0 +1,87 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// closingBuffer is a bytes.Buffer that records whether it was closed.
+type closingBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closingBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestExportWriter(t *testing.T) {
+	buf := &closingBuffer{}
+	handler, err := NewHandlerWithWriter(NewExportWriter(buf), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(handler)
+	logger.Info("first", "KEY", "line 1\nline 2", "BINARY", "\x00\x01")
+	logger.Warn("second", "TAB", "a\tb")
+
+	for _, binary := range []string{"KEY\n", "BINARY\n"} {
+		if !bytes.Contains(buf.Bytes(), []byte(binary)) {
+			t.Errorf("expected %q in the binary form", binary)
+		}
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("TAB=a\tb\n")) {
+		t.Error("expected TAB in the text form")
+	}
+
+	entries, err := ParseExport(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	e := entries[0]
+	if e[0].Name != "__REALTIME_TIMESTAMP" {
+		t.Errorf("expected __REALTIME_TIMESTAMP first, got %s", e[0].Name)
+	}
+	ts, _ := e.Get("SYSLOG_TIMESTAMP")
+	if rt, _ := e.Get("__REALTIME_TIMESTAMP"); !bytes.Equal(rt, ts) {
+		t.Errorf("expected __REALTIME_TIMESTAMP=%s, got %s", ts, rt)
+	}
+	for k, want := range map[string]string{"MESSAGE": "first", "KEY": "line 1\nline 2", "BINARY": "\x00\x01"} {
+		if v, _ := e.Get(k); string(v) != want {
+			t.Errorf("expected %s=%q, got %q", k, want, v)
+		}
+	}
+
+	if err := handler.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !buf.closed {
+		t.Error("expected the writer to be closed")
+	}
+}
+
+func TestExportWriterTimestampLayout(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandlerWithWriter(NewExportWriter(buf), &Options{TimestampLayout: time.RFC3339})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().UnixMicro()
+	slog.New(handler).Info("Hello, World!")
+	after := time.Now().UnixMicro()
+	e, err := NewDecoder(buf).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt, _ := e.Get("__REALTIME_TIMESTAMP")
+	if usec, err := strconv.ParseInt(string(rt), 10, 64); err != nil || usec < before || usec > after {
+		t.Errorf("expected the time of writing in microseconds, got %q", rt)
+	}
+}
@@ -0,0 +1,42 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// FieldMarshaler is implemented by types that write themselves as several
+// journal fields, e.g. a user as USER_ID and USER_NAME rather than a
+// single formatted value. The fields are written like the attributes of a
+// group with the key of the attribute holding the value, so that
+//
+//	slog.Any("PEER", addr)
+//
+// writes PEER_HOST and PEER_PORT for an addr returning HOST and PORT. An
+// attribute with an empty key writes the fields without a prefix. Field
+// values may be binary. The fields go through ReplaceAttr, and are written
+// in the order of their names. If MarshalJournal fails, the error is
+// written to the RESOLVE_ERROR field instead.
+//
+// FieldMarshaler takes precedence over String and Error methods, but not
+// over encoders registered with [RegisterValueEncoder].
+type FieldMarshaler interface {
+	MarshalJournal() (map[string][]byte, error)
+}
+
+// marshalFields returns the fields of m as Attrs, sorted by name.
+func marshalFields(m FieldMarshaler) ([]slog.Attr, error) {
+	fields, err := m.MarshalJournal()
+	if err != nil {
+		return nil, err
+	}
+	attrs := make([]slog.Attr, 0, len(fields))
+	for name, v := range fields {
+		attrs = append(attrs, slog.String(name, string(v)))
+	}
+	slices.SortFunc(attrs, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return attrs, nil
+}
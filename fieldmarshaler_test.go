@@ -0,0 +1,64 @@
+package slogjournal
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+type peer struct {
+	host string
+	port string
+	err  error
+}
+
+func (p peer) String() string { return p.host + ":" + p.port }
+
+func (p peer) MarshalJournal() (map[string][]byte, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return map[string][]byte{"HOST": []byte(p.host), "PORT": []byte(p.port)}, nil
+}
+
+func TestFieldMarshaler(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts Options
+		attr slog.Attr
+		want map[string]string
+	}{
+		{"fields", Options{}, slog.Any("PEER", peer{host: "example.org", port: "443"}), map[string]string{
+			"PEER_HOST": "example.org", "PEER_PORT": "443",
+		}},
+		{"inline", Options{}, slog.Any("", peer{host: "example.org", port: "443"}), map[string]string{
+			"HOST": "example.org", "PORT": "443",
+		}},
+		{"group", Options{}, slog.Group("G", slog.Any("PEER", peer{host: "example.org", port: "443"})), map[string]string{
+			"G_PEER_HOST": "example.org",
+		}},
+		{"json", Options{GroupEncoding: GroupsJSON}, slog.Group("G", slog.Any("PEER", peer{host: "example.org", port: "443"})), map[string]string{
+			"G": `{"PEER":{"HOST":"example.org","PORT":"443"}}`,
+		}},
+		{"error", Options{}, slog.Any("PEER", peer{err: errors.New("boom")}), map[string]string{
+			"RESOLVE_ERROR": "PEER: boom",
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &queueWriter{}
+			handler, err := NewHandlerWithWriter(w, &tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slog.New(handler).Info("Hello, World!", tt.attr)
+			if v, ok := w.entries[0].Get("PEER"); ok {
+				t.Errorf("expected no PEER field, got %q", v)
+			}
+			for k, want := range tt.want {
+				if v, _ := w.entries[0].Get(k); string(v) != want {
+					t.Errorf("expected %s=%s, got %q", k, want, v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package slogjournal
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FieldNameMode controls how a Handler treats field names that journald
+// rejects: names that are empty, longer than 64 characters, start with a
+// digit or an underscore, or contain characters other than A-Z, 0-9 and _.
+type FieldNameMode int
+
+const (
+	// FieldNamesKeep writes fields with invalid names as they are, which
+	// journald drops.
+	FieldNamesKeep FieldNameMode = iota
+	// FieldNamesSanitize turns invalid names into valid ones: letters are
+	// upper-cased, other invalid characters replaced by _, leading
+	// underscores removed, names starting with a digit prefixed by FIELD_,
+	// and long names truncated to 64 characters, e.g. http.status-code
+	// becomes HTTP_STATUS_CODE.
+	FieldNamesSanitize
+	// FieldNamesDrop leaves out fields with invalid names.
+	FieldNamesDrop
+	// FieldNamesReject drops entries with fields with invalid names. Handle
+	// returns a *ValidationError listing them.
+	FieldNamesReject
+)
+
+// sanitizeFieldName returns a valid field name for the invalid name k, as
+// described by FieldNamesSanitize.
+func sanitizeFieldName(k string) string {
+	k = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, k)
+	k = strings.TrimLeft(k, "_")
+	switch {
+	case k == "":
+		k = "FIELD"
+	case k[0] >= '0' && k[0] <= '9':
+		k = "FIELD_" + k
+	}
+	if len(k) > maxFieldNameLen {
+		k = k[:maxFieldNameLen]
+	}
+	return k
+}
+
+// checkFieldNames returns a *ValidationError listing the fields of the entry
+// b with invalid names, or nil.
+func checkFieldNames(b []byte) error {
+	fields, err := ParseEntry(b)
+	if err != nil {
+		return err
+	}
+	var violations []Violation
+	for _, f := range fields {
+		if reason := checkFieldName(f.Name); reason != "" {
+			violations = append(violations, Violation{Field: f.Name, Reason: reason})
+		}
+	}
+	if len(violations) > 0 {
+		return &ValidationError{violations}
+	}
+	return nil
+}
+
+// maxSanitizedNames is the number of renamed field names above which
+// further renames are no longer reported.
+const maxSanitizedNames = 1024
+
+// sanitizedNames collects the field names renamed by FieldNamesSanitize, so
+// that each is reported once, after the entry with it was written.
+type sanitizedNames struct {
+	mu      sync.Mutex
+	seen    map[string]bool
+	pending [][2]string
+}
+
+// add records that k was renamed to name.
+func (s *sanitizedNames) add(k, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[k] || len(s.seen) >= maxSanitizedNames {
+		return
+	}
+	if s.seen == nil {
+		s.seen = make(map[string]bool)
+	}
+	// Copies, so that the names built by the caller do not escape.
+	key := strings.Clone(k)
+	s.seen[key] = true
+	s.pending = append(s.pending, [2]string{key, strings.Clone(name)})
+}
+
+// reportSanitized reports the renames recorded since the last call as
+// DiagnosticSanitized.
+func (h *Handler) reportSanitized() {
+	s := &h.stats.sanitized
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	for _, p := range pending {
+		h.diagnose(DiagnosticSanitized, "renamed field "+strconv.Quote(p[0])+" to "+p[1], nil)
+	}
+}
@@ -0,0 +1,76 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSanitizeFieldName(t *testing.T) {
+	for in, want := range map[string]string{
+		"http.status-code":      "HTTP_STATUS_CODE",
+		"_PID":                  "PID",
+		"1KEY":                  "FIELD_1KEY",
+		"":                      "FIELD",
+		"__":                    "FIELD",
+		strings.Repeat("K", 70): strings.Repeat("K", 64),
+	} {
+		if got := sanitizeFieldName(in); got != want {
+			t.Errorf("sanitizeFieldName(%q) = %q, want %q", in, got, want)
+		}
+		if reason := checkFieldName(sanitizeFieldName(in)); reason != "" {
+			t.Errorf("sanitizeFieldName(%q) is invalid: %s", in, reason)
+		}
+	}
+}
+
+func TestFieldNames(t *testing.T) {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	record.AddAttrs(slog.Int("http.status-code", 200), slog.String("VALID", "value"))
+
+	for _, tt := range []struct {
+		mode FieldNameMode
+		want map[string]string
+	}{
+		{FieldNamesKeep, map[string]string{"http.status-code": "200", "VALID": "value"}},
+		{FieldNamesSanitize, map[string]string{"HTTP_STATUS_CODE": "200", "VALID": "value"}},
+		{FieldNamesDrop, map[string]string{"HTTP_STATUS_CODE": "", "http.status-code": "", "VALID": "value"}},
+	} {
+		buf := new(bytes.Buffer)
+		handler, err := NewHandler(&Options{FieldNames: tt.mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.w = buf
+		if err := handler.Handle(context.TODO(), record); err != nil {
+			t.Fatal(err)
+		}
+		kv, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range tt.want {
+			if kv[k] != v {
+				t.Errorf("mode %d: expected %s=%q, got %q", tt.mode, k, v, kv[k])
+			}
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{FieldNames: FieldNamesReject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+	var verr *ValidationError
+	if err := handler.Handle(context.TODO(), record); !errors.As(err, &verr) || verr.Violations[0].Field != "http.status-code" {
+		t.Errorf("expected violation of http.status-code, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Error("expected the entry to be dropped")
+	}
+}
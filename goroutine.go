@@ -0,0 +1,26 @@
+package slogjournal
+
+import (
+	"bytes"
+	"runtime"
+)
+
+// Fields added by Options.ThreadID and Options.GoroutineID.
+const (
+	ThreadIDKey    = "TID"
+	GoroutineIDKey = "GOROUTINE_ID"
+)
+
+var goroutinePrefix = []byte("goroutine ")
+
+// appendGoroutineID appends the ID of the calling goroutine, as printed in
+// its stack trace, to b.
+func appendGoroutineID(b []byte) []byte {
+	var stack [64]byte
+	s := stack[:runtime.Stack(stack[:], false)]
+	s = bytes.TrimPrefix(s, goroutinePrefix)
+	if i := bytes.IndexByte(s, ' '); i > 0 {
+		return append(b, s[:i]...)
+	}
+	return b
+}
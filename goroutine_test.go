@@ -0,0 +1,47 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestGoroutineID(t *testing.T) {
+	w := &queueWriter{}
+	handler, err := NewHandlerWithWriter(w, &Options{ThreadID: true, GoroutineID: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(handler)
+
+	runtime.LockOSThread()
+	logger.Info("first")
+	tid, hasTID := gettid()
+	runtime.UnlockOSThread()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		logger.Info("second")
+	}()
+	wg.Wait()
+
+	first, _ := w.entries[0].Get(GoroutineIDKey)
+	second, _ := w.entries[1].Get(GoroutineIDKey)
+	if _, err := strconv.ParseUint(string(first), 10, 64); err != nil {
+		t.Errorf("expected a numeric %s, got %q", GoroutineIDKey, first)
+	}
+	if string(first) == string(second) {
+		t.Errorf("expected goroutines to have different IDs, got %s twice", first)
+	}
+
+	v, ok := w.entries[0].Get(ThreadIDKey)
+	if ok != hasTID {
+		t.Fatalf("expected %s only where the thread ID is available", ThreadIDKey)
+	}
+	if hasTID && string(v) != strconv.Itoa(tid) {
+		t.Errorf("expected %s=%d, got %q", ThreadIDKey, tid, v)
+	}
+}
@@ -0,0 +1,154 @@
+package slogjournal
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+// GroupEncoding controls how a Handler writes groups and slog.Any values.
+type GroupEncoding int
+
+const (
+	// GroupsFlatten writes a field per attribute of a group, named by the
+	// keys of the enclosing groups and the attribute joined by _, e.g.
+	// HTTP_STATUS, and slog.Any values formatted with fmt.
+	GroupsFlatten GroupEncoding = iota
+	// GroupsJSON writes a group attribute, and a slog.Any value that does
+	// not format itself, as a single field whose value is a JSON object or
+	// value, e.g. HTTP={"STATUS":200,"METHOD":"GET"}. Values in the JSON are
+	// encoded like their flattened fields: durations and times are numbers
+	// of microseconds. Groups opened with WithGroup still prefix the names
+	// of fields.
+	GroupsJSON
+)
+
+// appendJSONField appends the field k with v, a group or slog.Any value,
+// encoded as JSON.
+func (h *Handler) appendJSONField(b []byte, k string, v slog.Value, depth int) []byte {
+	js, err := h.appendJSONValue(nil, v, depth)
+	if err != nil {
+		return h.appendResolveError(b, k, err)
+	}
+	return h.appendKV(b, k, js)
+}
+
+// appendJSONValue appends the resolved value v as JSON.
+func (h *Handler) appendJSONValue(b []byte, v slog.Value, depth int) ([]byte, error) {
+	switch v.Kind() {
+	case slog.KindGroup:
+		if depth == maxResolveDepth {
+			return nil, errGroupTooDeep
+		}
+		var err error
+		if b, _, err = h.appendJSONMembers(append(b, '{'), v.Group(), true, depth+1); err != nil {
+			return nil, err
+		}
+		return append(b, '}'), nil
+	case slog.KindString:
+		return appendJSONString(b, v.String()), nil
+	case slog.KindInt64:
+		return strconv.AppendInt(b, v.Int64(), 10), nil
+	case slog.KindUint64:
+		return strconv.AppendUint(b, v.Uint64(), 10), nil
+	case slog.KindBool:
+		return strconv.AppendBool(b, v.Bool()), nil
+	case slog.KindDuration:
+		return h.appendDuration(b, v.Duration(), true), nil
+	case slog.KindTime:
+		return h.appendTime(b, v.Time(), true), nil
+	case slog.KindAny:
+		a := v.Any()
+		if ev, ok := encodeValue(a); ok {
+			return appendJSONString(b, string(ev)), nil
+		}
+		if m, ok := a.(FieldMarshaler); ok {
+			attrs, err := marshalFields(m)
+			if err != nil {
+				return nil, err
+			}
+			return h.appendJSONValue(b, slog.GroupValue(attrs...), depth)
+		}
+		if h.opts.ExpandStructs {
+			if attrs, ok := expandStruct(a); ok {
+				return h.appendJSONValue(b, slog.GroupValue(attrs...), depth)
+			}
+		}
+		if formatsItself(a) {
+			return appendJSONString(b, v.String()), nil
+		}
+		if js, err := json.Marshal(a); err == nil {
+			return append(b, js...), nil
+		}
+		return appendJSONString(b, v.String()), nil
+	default:
+		// Floats that JSON cannot represent, such as NaN, become strings.
+		if js, err := json.Marshal(v.Any()); err == nil {
+			return append(b, js...), nil
+		}
+		return appendJSONString(b, v.String()), nil
+	}
+}
+
+// appendJSONMembers appends the attributes attrs as the members of a JSON
+// object, inlining groups with empty keys. first reports whether no member
+// has been appended to the object yet.
+func (h *Handler) appendJSONMembers(b []byte, attrs []slog.Attr, first bool, depth int) ([]byte, bool, error) {
+	for _, a := range attrs {
+		var err error
+		if a.Value, err = resolve(a.Value); err != nil {
+			return nil, first, err
+		}
+		if rep := h.opts.ReplaceAttr; rep != nil && a.Value.Kind() != slog.KindGroup {
+			a = rep(h.groups, a)
+			if a.Value, err = resolve(a.Value); err != nil {
+				return nil, first, err
+			}
+		}
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+		if a.Value.Kind() == slog.KindGroup {
+			if len(a.Value.Group()) == 0 {
+				continue
+			}
+			if a.Key == "" {
+				if b, first, err = h.appendJSONMembers(b, a.Value.Group(), first, depth); err != nil {
+					return nil, first, err
+				}
+				continue
+			}
+			if rep := h.opts.ReplaceGroup; rep != nil {
+				a.Key = rep(a.Key)
+			}
+		}
+		if !first {
+			b = append(b, ',')
+		}
+		first = false
+		b = appendJSONString(b, a.Key)
+		b = append(b, ':')
+		if b, err = h.appendJSONValue(b, a.Value, depth); err != nil {
+			return nil, first, err
+		}
+	}
+	return b, first, nil
+}
+
+// formatsItself reports whether v is formatted by a String, Error or
+// MarshalText method, which GroupsJSON keeps using.
+func formatsItself(v any) bool {
+	switch v.(type) {
+	case fmt.Stringer, error, encoding.TextMarshaler:
+		return true
+	}
+	return false
+}
+
+// appendJSONString appends s as a JSON string.
+func appendJSONString(b []byte, s string) []byte {
+	js, _ := json.Marshal(s)
+	return append(b, js...)
+}
@@ -0,0 +1,67 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestGroupsJSON(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+	for _, tt := range []struct {
+		name string
+		attr slog.Attr
+		key  string
+		want string
+	}{
+		{"group", slog.Group("HTTP", slog.Int("STATUS", 200), slog.String("METHOD", "GET")), "HTTP", `{"STATUS":200,"METHOD":"GET"}`},
+		{"nested", slog.Group("A", slog.Group("B", slog.Bool("C", true)), slog.Duration("D", time.Second)), "A", `{"B":{"C":true},"D":1000000}`},
+		{"inline", slog.Group("A", slog.Group("", slog.Int("B", 1)), slog.Group("EMPTY"), slog.Int("C", 2)), "A", `{"B":1,"C":2}`},
+		{"any", slog.Any("IDS", []int{1, 2, 3}), "IDS", `[1,2,3]`},
+		{"struct", slog.Any("POINT", point{1, 2}), "POINT", `{"X":1,"Y":2}`},
+		{"error", slog.Any("ERR", errors.New("boom")), "ERR", `boom`},
+		{"error in group", slog.Group("G", slog.Any("ERR", errors.New("boom"))), "G", `{"ERR":"boom"}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			handler, err := NewHandler(&Options{GroupEncoding: GroupsJSON})
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler.w = buf
+			r := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+			r.AddAttrs(tt.attr)
+			_ = handler.Handle(context.TODO(), r)
+			kv, err := deserializeKeyValue(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kv[tt.key] != tt.want {
+				t.Errorf("expected %s=%s, got %q", tt.key, tt.want, kv[tt.key])
+			}
+		})
+	}
+}
+
+func TestGroupsJSONWithGroup(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{GroupEncoding: GroupsJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+	h := handler.WithGroup("DB").WithAttrs([]slog.Attr{slog.Group("QUERY", slog.String("TABLE", "users"))})
+	_ = h.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"TABLE":"users"}`; kv["DB_QUERY"] != want {
+		t.Errorf("expected DB_QUERY=%s, got %q", want, kv["DB_QUERY"])
+	}
+}
@@ -0,0 +1,73 @@
+package slogjournal
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The fields added by Options.HostFields.
+const (
+	HostnameKey      = "HOSTNAME"
+	MachineOSKey     = "MACHINE_OS"
+	KernelVersionKey = "KERNEL_VERSION"
+)
+
+// hostFields returns the fields added by Options.HostFields. Fields whose
+// value cannot be determined are left out.
+var hostFields = sync.OnceValue(func() Entry {
+	var e Entry
+	if name, err := os.Hostname(); err == nil {
+		e = append(e, Field{HostnameKey, []byte(name)})
+	}
+	if name := osReleaseName(); name != "" {
+		e = append(e, Field{MachineOSKey, []byte(name)})
+	}
+	if b, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		e = append(e, Field{KernelVersionKey, bytes.TrimSpace(b)})
+	}
+	return e
+})
+
+// osReleaseName returns the PRETTY_NAME of the operating system from
+// os-release(5), or the empty string.
+func osReleaseName() string {
+	for _, path := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+		return parseOSRelease(f)
+	}
+	return ""
+}
+
+// parseOSRelease returns the PRETTY_NAME, or if missing the NAME, of an
+// os-release file.
+func parseOSRelease(r io.Reader) string {
+	var name string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		k, v, ok := strings.Cut(s.Text(), "=")
+		if !ok {
+			continue
+		}
+		if uv, err := strconv.Unquote(v); err == nil {
+			v = uv
+		} else {
+			v = strings.Trim(v, `'"`)
+		}
+		switch k {
+		case "PRETTY_NAME":
+			return v
+		case "NAME":
+			name = v
+		}
+	}
+	return name
+}
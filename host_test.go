@@ -0,0 +1,44 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOSRelease(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"NAME=Fedora Linux\nPRETTY_NAME=\"Fedora Linux 40 (Workstation Edition)\"\n", "Fedora Linux 40 (Workstation Edition)"},
+		{"# comment\nNAME='Arch Linux'\n", "Arch Linux"},
+		{"ID=debian\n", ""},
+	}
+	for _, tt := range tests {
+		if got := parseOSRelease(strings.NewReader(tt.in)); got != tt.want {
+			t.Errorf("expected %q, got %q", tt.want, got)
+		}
+	}
+}
+
+func TestHostFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{HostFields: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := os.Hostname(); kv[HostnameKey] != name {
+		t.Errorf("expected HOSTNAME=%s, got %q", name, kv[HostnameKey])
+	}
+}
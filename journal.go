@@ -4,21 +4,29 @@ package slogjournal
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
-	"log/syslog"
 	"os"
 	"path"
+	"path/filepath"
 	"runtime"
+	"runtime/debug"
 	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
+	"unsafe"
 )
 
-// Names of levels corresponding to syslog.Priority values.
+// Names of levels corresponding to Priority values.
 const (
+	LevelTrace     slog.Level = slog.LevelDebug - 4
 	LevelNotice    slog.Level = slog.LevelInfo + 1
 	LevelCritical  slog.Level = slog.LevelError + 1
 	LevelAlert     slog.Level = slog.LevelError + 2
@@ -34,46 +42,91 @@ const (
 // [org.freedesktop.LogControl1]: https://www.freedesktop.org/software/systemd/man/latest/org.freedesktop.LogControl1.html
 type LevelVar struct {
 	slog.LevelVar
+
+	// Trace makes DEBUG_INVOCATION select LevelTrace instead of slog.LevelDebug.
+	Trace bool
+
+	once sync.Once
 }
 
 // Return v's level.
-// When invoked for the first time, checks if the environment variable DEBUG_INVOCATION is set and if so, sets the level to slog.LevelDebug before returning it.
+// When invoked for the first time, checks if the environment variable DEBUG_INVOCATION is set and if so, sets the level to slog.LevelDebug
+// (or LevelTrace if v.Trace is set) before returning it.
 func (v *LevelVar) Level() slog.Level {
-	sync.OnceFunc(func() {
+	v.once.Do(func() {
 		if os.Getenv("DEBUG_INVOCATION") != "" {
-			v.Set(slog.LevelDebug)
+			if v.Trace {
+				v.Set(LevelTrace)
+			} else {
+				v.Set(slog.LevelDebug)
+			}
 		}
-	})()
+	})
 	return v.LevelVar.Level()
 }
 
-func levelToPriority(l slog.Level) syslog.Priority {
-	switch l {
-	case slog.LevelDebug:
-		return syslog.LOG_DEBUG
-	case slog.LevelInfo:
-		return syslog.LOG_INFO
-	case LevelNotice:
-		return syslog.LOG_NOTICE
-	case slog.LevelWarn:
-		return syslog.LOG_WARNING
-	case slog.LevelError:
-		return syslog.LOG_ERR
-	case LevelCritical:
-		return syslog.LOG_CRIT
-	case LevelAlert:
-		return syslog.LOG_ALERT
-	case LevelEmergency:
-		return syslog.LOG_EMERG
+// LevelToPriority is the default mapping of levels to the priorities of the
+// PRIORITY field. Levels registered with [RegisterLevel] map to their
+// priority. Other levels map to the priority of the closest level of this
+// package or of log/slog at or below them, e.g. slog.LevelWarn+1 to
+// PriorityWarning; levels above LevelEmergency map to PriorityEmergency and
+// levels below slog.LevelInfo to PriorityDebug.
+func LevelToPriority(l slog.Level) Priority {
+	if rl, ok := registeredLevel(l); ok {
+		return rl.pri
+	}
+	switch {
+	case l >= LevelEmergency:
+		return PriorityEmergency
+	case l >= LevelAlert:
+		return PriorityAlert
+	case l >= LevelCritical:
+		return PriorityCritical
+	case l >= slog.LevelError:
+		return PriorityError
+	case l >= slog.LevelWarn:
+		return PriorityWarning
+	case l >= LevelNotice:
+		return PriorityNotice
+	case l >= slog.LevelInfo:
+		return PriorityInfo
 	default:
-		return syslog.LOG_INFO
+		return PriorityDebug
 	}
 }
 
+// SourceFields selects the fields that describe the source location of a
+// record.
+type SourceFields uint8
+
+const (
+	// CodeFile selects the CODE_FILE field.
+	CodeFile SourceFields = 1 << iota
+	// CodeLine selects the CODE_LINE field.
+	CodeLine
+	// CodeFunc selects the CODE_FUNC field.
+	CodeFunc
+	// CodeLocation selects a single CODE_LOCATION field of the form
+	// "file.go:123 (pkg.Func)". It is cheaper to store than the three
+	// separate fields.
+	CodeLocation
+	// NoSource omits the source location altogether.
+	NoSource
+
+	// DefaultSourceFields are the fields emitted when Options.SourceFields is zero.
+	DefaultSourceFields = CodeFile | CodeLine | CodeFunc
+)
+
 // Options configure the Journal handler.
 type Options struct {
 	Level slog.Leveler
 
+	// LevelToPriority, if non-nil, maps the levels of records to the
+	// priorities of their PRIORITY field, e.g. for applications with a
+	// scheme of their own such as negative verbosity levels. It may fall
+	// back to the default mapping, [LevelToPriority].
+	LevelToPriority func(slog.Level) Priority
+
 	// ReplaceAttr is called on all non-builtin Attrs before they are written.
 	// This can be useful for processing attributes to be in the correct format
 	// for log statements outside of your own code as the journal only accepts
@@ -85,6 +138,442 @@ type Options struct {
 	// log statements outside of your own code as the journal only accepts
 	// keys of the form ^[A-Z_][A-Z0-9_]*$.
 	ReplaceGroup func(group string) string
+
+	// CodeFilePrefix, if non-empty, is stripped from the beginning of CODE_FILE
+	// values. This keeps the build machine's directory layout out of the
+	// journal.
+	CodeFilePrefix string
+
+	// CodeFileRelative, if true, makes CODE_FILE relative to the root of the
+	// main module, e.g. "internal/server/server.go". Code outside the main
+	// module is reported by its package import path, e.g.
+	// "net/http/server.go". It takes precedence over CodeFilePrefix.
+	CodeFileRelative bool
+
+	// SourceFields selects which fields describe the source location of a
+	// record. If zero, DefaultSourceFields is used.
+	SourceFields SourceFields
+
+	// LevelFields adds extra fields to records by level. The Attrs of an entry
+	// are added to all records whose level is at least the entry's level, e.g.
+	// {slog.LevelError: {slog.Bool("PAGES", true)}} marks error-and-above
+	// records for an alerting pipeline.
+	LevelFields map[slog.Level][]slog.Attr
+
+	// ValueCacheSize, if positive, enables a cache of the encoded fields of
+	// up to ValueCacheSize large string values. Repeated values, such as long
+	// user agents or SQL statements, are then only encoded once.
+	ValueCacheSize int
+
+	// DryRun, if true, makes Handle validate entries against the rules of
+	// journald instead of writing them. Handle returns a *ValidationError
+	// listing the violations of an entry, if any. See also [Handler.Validate].
+	DryRun bool
+
+	// InvocationID, if true, adds an INVOCATION_ID field holding
+	// $INVOCATION_ID to every entry. journald records the invocation ID of
+	// services as the trusted _SYSTEMD_INVOCATION_ID field itself, but
+	// entries shipped by other means do not get it.
+	InvocationID bool
+
+	// RuntimeUsec, if true, adds a RUNTIME_USEC field holding the
+	// microseconds between the start of the process and the time of the
+	// record, measured with the monotonic clock. Unlike SYSLOG_TIMESTAMP and
+	// the timestamps of journald, it is not affected by changes of the wall
+	// clock, e.g. on devices without a battery-backed real-time clock.
+	RuntimeUsec bool
+
+	// ThreadID, if true, adds a TID field holding the ID of the thread
+	// that handles the record, like sd_journal_send does. The runtime
+	// moves goroutines between threads, so it only tells which entries
+	// were logged by the same goroutine for goroutines locked to their
+	// thread with runtime.LockOSThread. It is only added on Linux.
+	ThreadID bool
+
+	// GoroutineID, if true, adds a GOROUTINE_ID field holding the ID of
+	// the goroutine that handles the record, as printed in stack traces,
+	// so that the entries of concurrent goroutines can be told apart, e.g.
+	// with journalctl GOROUTINE_ID=42.
+	GoroutineID bool
+
+	// HostFields, if true, adds HOSTNAME, MACHINE_OS and KERNEL_VERSION
+	// fields to every entry, holding the host name, the PRETTY_NAME of
+	// os-release(5) and the kernel release. They are determined once per
+	// process. journald records the host as trusted fields itself, but
+	// entries shipped off the host by other means do not get them.
+	HostFields bool
+
+	// OmitTimestamp, if true, leaves out the SYSLOG_TIMESTAMP field with the
+	// time of the record. journald timestamps entries when it receives them
+	// anyway, so this saves the bytes of the field where the difference does
+	// not matter. Spooled entries are then replayed with the time they are
+	// replayed at.
+	OmitTimestamp bool
+
+	// TimestampKey is the name of the field with the time of the record.
+	// If empty, SYSLOG_TIMESTAMP is used.
+	TimestampKey string
+
+	// TimestampLayout is the layout, as understood by time.Time.Format, of
+	// the field with the time of the record. If empty, the time is written
+	// as microseconds since the epoch, the resolution of the journal's own
+	// timestamps. Use time.RFC3339Nano for a time that reads well in
+	// journalctl -o verbose, or time.Stamp for the classic syslog format.
+	TimestampLayout string
+
+	// SyslogIdentifier is the SYSLOG_IDENTIFIER of all entries. If empty,
+	// the base name of the program, filepath.Base(os.Args[0]), is used.
+	// Set it in wrappers and forwarders whose entries should be attributed
+	// to another program.
+	SyslogIdentifier string
+
+	// Redact lists fields, by their full name including group prefixes, whose
+	// values are replaced by "REDACTED".
+	Redact []string
+
+	// Credentials, if true, reads settings from the systemd service
+	// credentials in $CREDENTIALS_DIRECTORY (see LoadCredential= in
+	// systemd.exec(5)). Settings found there take precedence over the
+	// corresponding options:
+	//   - slogjournal.level: the minimum level, e.g. "debug" or "INFO+2"
+	//   - slogjournal.identifier: the SYSLOG_IDENTIFIER
+	//   - slogjournal.redact: whitespace-separated fields to redact, in
+	//     addition to Redact
+	//   - slogjournal.signing-key: the SigningKey
+	Credentials bool
+
+	// SpoolPath, if non-empty, names a file that entries are appended to
+	// while journald is not running, e.g. early during boot or while it
+	// restarts. Spooled entries are replayed into the journal in order once
+	// it is reachable again; they keep their SYSLOG_TIMESTAMP field with the
+	// original time of the record. The spool is limited to 64 MiB, entries
+	// beyond that are dropped.
+	SpoolPath string
+
+	// DeadLetterPath, if non-empty, names a file that entries violating the
+	// rules of journald, which journald would drop in part or entirely, are
+	// appended to instead of being sent. Handle validates every entry, like
+	// DryRun, and returns a *ValidationError for those. Use
+	// [ReadDeadLetters] to inspect them and [Handler.WriteEntry] to
+	// resubmit them once corrected, or the slog-journal-deadletter command.
+	// Like the spool, the file is limited to 64 MiB.
+	DeadLetterPath string
+
+	// OnDiagnostic, if non-nil, is called with the conditions the handler
+	// reports about itself, such as starting to spool entries because
+	// journald is unavailable. It is called synchronously by Handle, so it
+	// must be fast and must not log with the handler.
+	OnDiagnostic func(Diagnostic)
+
+	// DiagnosticEntries, if true, makes the handler write the conditions it
+	// reports about itself as entries at LevelDebug, with the MESSAGE_ID
+	// [MessageIDDiagnostic] and a DIAGNOSTIC field naming their
+	// DiagnosticKind, in addition to calling OnDiagnostic.
+	DiagnosticEntries bool
+
+	// JSONFallback, if true, makes the handler print entries to stdout as
+	// JSON objects, one per line, if it runs in a container without a
+	// journald socket. The objects have the shape of journalctl -o json, so
+	// log collectors configured for the journal's JSON output, such as
+	// fluent-bit or vector, work unchanged.
+	JSONFallback bool
+
+	// ConsoleFallback, if true, makes the handler print entries to stderr in
+	// a human-readable format if there is no journald socket, e.g. while
+	// developing on a machine without systemd. Each entry is a line with
+	// its priority in angle brackets, its message and its other fields. If
+	// stderr is a terminal, the priority is colored and the fields are
+	// dimmed, unless $NO_COLOR is non-empty. JSONFallback takes precedence in
+	// containers.
+	ConsoleFallback bool
+
+	// SigningKey, if non-empty, makes the handler sign every entry with an
+	// HMAC-SHA256 keyed by SigningKey, in a SIGNATURE field. Use
+	// [VerifySignature] to verify the signature of an entry.
+	SigningKey []byte
+
+	// SchemaMode controls whether entries are checked against the schema
+	// registered for their MESSAGE_ID with [RegisterSchema], and what happens
+	// to entries that violate it.
+	SchemaMode SchemaMode
+
+	// StaticBufferSize, if positive, makes the handler encode every entry
+	// into a single buffer of this many bytes, allocated once by NewHandler,
+	// for memory-constrained targets. Fields that do not fit are dropped and
+	// the entry gets a TRUNCATED=1 field instead, so entries never exceed
+	// this size. The buffer is shared by the handlers derived with WithAttrs
+	// and WithGroup, which serializes their Handle calls.
+	//
+	// Values are still converted to strings before they are encoded, and
+	// SchemaMode and SigningKey parse every entry, so records are not
+	// entirely free of allocations.
+	StaticBufferSize int
+
+	// FieldPartSize, if positive, splits values longer than FieldPartSize
+	// bytes into the fields NAME_PART_0 to NAME_PART_<n-1> of at most
+	// FieldPartSize bytes each, followed by NAME_PARTS=n, instead of a
+	// single field NAME. NAME is shortened in the names of the parts so
+	// that they stay within 64 characters. journald drops fields larger
+	// than 768 MiB, and entries larger than 770 MiB entirely; tools
+	// forwarding the journal often have lower limits. Use
+	// [Entry.GetJoined] to join the parts.
+	FieldPartSize int
+
+	// WriterShards is the number of sockets entries are sent to journald on.
+	// The runtime serializes writes on a socket, so handlers logging from
+	// many goroutines at once can set it to e.g. runtime.GOMAXPROCS(0) to
+	// send entries concurrently. Each entry is still sent in a single
+	// datagram, and the entries logged by a single goroutine stay in order.
+	// The default is 1.
+	WriterShards int
+
+	// QueueSize, if positive, makes Handle queue entries, up to QueueSize
+	// of them, instead of writing them itself. A goroutine of the handler
+	// writes them, so that latency-sensitive code never waits for journald.
+	// Entries the goroutine fails to write are counted as dropped by Close
+	// and reported as DiagnosticWriterFailed rather than handed to Fallback.
+	// Use [Handler.Flush] to wait for the queued entries to be written, and
+	// [Handler.Close] to write them and stop the goroutine.
+	QueueSize int
+
+	// QueueFull controls what Handle does when the queue is full, see
+	// [QueuePolicy]. The default, QueueBlock, waits for room in the queue.
+	QueueFull QueuePolicy
+
+	// QueueMaxAge, if positive, makes the queue drop entries still queued
+	// QueueMaxAge after they were handled, rather than write them late
+	// after journald stalled. Dropped entries are counted by Close as
+	// EXPIRED. See also [WithQueueDeadline].
+	QueueMaxAge time.Duration
+
+	// RateLimitInterval and RateLimitBurst, if both positive, make the
+	// handler write at most RateLimitBurst entries in every
+	// RateLimitInterval and suppress the others, like RateLimitIntervalSec=
+	// and RateLimitBurst= of journald.conf(5), so that a logging loop does
+	// not get the whole service rate limited by journald. The first entry
+	// written after some were suppressed is preceded by an entry at
+	// LevelWarn with the MESSAGE_ID [MessageIDSuppressed] that counts them
+	// in the field SUPPRESSED. Handle returns nil for suppressed entries.
+	// The limit is shared by the handlers derived from the handler.
+	RateLimitInterval time.Duration
+	RateLimitBurst    int
+
+	// RateLimitKey, if non-nil, makes the rate limit apply to the records
+	// with the same key separately, e.g. those logged by the same
+	// subsystem, rather than to all records. The key is added to the
+	// entries counting suppressed entries in the field RATE_LIMIT_KEY. The
+	// number of distinct keys should be bounded: beyond 1024 keys, those
+	// whose interval ended are forgotten, and their suppressed entries are
+	// counted right away.
+	RateLimitKey func(r slog.Record) string
+
+	// DedupWindow, if positive, makes the handler coalesce the records
+	// that repeat a record it wrote less than DedupWindow before, e.g.
+	// those of a retry loop, instead of writing them. Once the record is
+	// written again after DedupWindow, or the handler is closed, an entry
+	// with the MESSAGE_ID [MessageIDRepeated] at the level of the record
+	// counts the repeats in the field REPEATED, like "message repeated 3
+	// times: connection refused". Records repeat each other if they have
+	// the same message, regardless of their attributes, unless DedupKey is
+	// set. Coalescing is shared by the handlers derived from the handler.
+	DedupWindow time.Duration
+
+	// DedupKey, if non-nil, returns the key of records that repeat each
+	// other if they are equal, e.g. the message and some of the attributes,
+	// instead of the message.
+	DedupKey func(r slog.Record) string
+
+	// SocketPath is the path of the socket journald listens on for entries.
+	// If empty, it is /run/systemd/journal/socket. It is only used where
+	// entries are sent to journald.
+	SocketPath string
+
+	// Namespace, if non-empty, selects the journal namespace entries are
+	// sent to, that is the journald@NAMESPACE.service instance listening on
+	// /run/systemd/journal.NAMESPACE/socket (see systemd-journald.service(8)).
+	// SocketPath takes precedence. The namespace must be running, e.g. by
+	// enabling systemd-journald@NAMESPACE.socket or by a service with
+	// LogNamespace=NAMESPACE: entries sent to a namespace that does not
+	// exist are dropped like those sent while journald is not running,
+	// unless SpoolPath or Fallback is set.
+	Namespace string
+
+	// Socket, if non-nil, is a datagram socket connected to journald that
+	// entries are sent on, instead of a socket opened by the handler. It
+	// lets sandboxed processes without access to /run log through a socket
+	// passed to them, e.g. by socket activation (see [ListenFDsSocket]) or
+	// by a privileged parent process. The handler uses a duplicate of the
+	// file descriptor, so the caller may close Socket. WriterShards,
+	// JSONFallback and ConsoleFallback are ignored. It is only used on Unix
+	// systems other than macOS with cgo.
+	Socket *os.File
+
+	// OnConnect, if non-nil, is called with the path of the journal socket
+	// when the handler opens its socket, which NewLazyHandler defers to the
+	// first entry, and when journald is reachable again after OnDisconnect.
+	// The path is empty for Socket. It is only used where entries are sent
+	// to journald.
+	OnConnect func(path string)
+
+	// OnDisconnect, if non-nil, is called with the path of the journal
+	// socket and the error when sending an entry fails because journald is
+	// not reachable, e.g. because it is restarting. It is not called again
+	// until journald is reachable again.
+	OnDisconnect func(path string, err error)
+
+	// ReconnectBackoff, if positive, makes the handler stop trying to send
+	// entries for ReconnectBackoff once journald is unreachable, doubling
+	// the delay after every failed attempt up to 30 seconds, instead of
+	// trying for every entry. Entries handled in the meantime fail as if
+	// journald was still unreachable: they are spooled, handed to Fallback
+	// or dropped. Entries are addressed to the socket path, so they reach a
+	// restarted journald without reconnecting.
+	ReconnectBackoff time.Duration
+
+	// Fallback, if non-nil, handles the records the handler fails to write,
+	// e.g. because journald is not running, such as in containers and CI.
+	// NewHandler does not fail if it cannot set up its connection to
+	// journald then, but hands every record to Fallback instead. The
+	// attributes and groups of WithAttrs and WithGroup calls are passed on
+	// to Fallback, so the same logger works inside and outside of systemd:
+	//
+	//	h, _ := slogjournal.NewHandler(&slogjournal.Options{
+	//		Fallback: slog.NewTextHandler(os.Stderr, nil),
+	//	})
+	//
+	// Entries are spooled rather than handed to Fallback if SpoolPath is
+	// set.
+	Fallback slog.Handler
+
+	// Also, if non-nil, handles every record the handler handles as well,
+	// e.g. a slog.JSONHandler writing to stdout for a container log scraper.
+	// It only gets the records the handler is enabled for, and of those the
+	// ones it is enabled for itself. The attributes and groups of WithAttrs
+	// and WithGroup calls are passed on to Also, like to Fallback. Handle
+	// returns the errors of both.
+	Also slog.Handler
+
+	// Strict makes WithAttrs and WithGroup check the field names they
+	// produce, so that invalid names are caught when loggers are set up
+	// rather than silently dropped by journald. See [StrictMode].
+	Strict StrictMode
+
+	// FieldNames controls how the fields with names journald rejects are
+	// written, e.g. those of attributes with keys like "http.status-code".
+	// By default, they are written as they are and dropped by journald.
+	// See [FieldNameMode].
+	FieldNames FieldNameMode
+
+	// GroupOverrides overrides the options of the handlers returned by
+	// WithGroup, keyed by their group path: the group names after
+	// ReplaceGroup, joined by dots, e.g. "db" or "http.client". This gives
+	// subsystems of a process their own policies while sharing the socket
+	// to the journal.
+	GroupOverrides map[string]GroupOverride
+
+	// VerifyRoundTrip, if true, makes Handle decode every entry it writes
+	// with [ParseEntry] and check that it decodes to the names and values
+	// of the fields it was encoded from, e.g. not to a field A with the
+	// value B=v for an attribute "A=B" with the value v. It bypasses the
+	// cache of ValueCacheSize. Entries that do not are still written, and
+	// Handle returns a *RoundTripError. It is meant for tests and staging
+	// environments, to catch encoding bugs before they lose fields in
+	// production.
+	VerifyRoundTrip bool
+
+	// ExpandStructs, if true, expands struct values, and pointers to them,
+	// into a field per struct field, like a group, instead of formatting
+	// them as a single value. Fields are named by their journal struct tag,
+	// e.g. `journal:"USER_ID"`, or else by their Go name in upper snake
+	// case. The tag `journal:"-"` omits a field, and the option omitempty
+	// omits it if it is the zero value. Types with a String, Error or
+	// MarshalText method, or an encoder registered with
+	// [RegisterValueEncoder], are formatted as before.
+	ExpandStructs bool
+
+	// GroupEncoding controls whether groups and slog.Any values are
+	// flattened into a field per attribute or written as a single field
+	// holding JSON, which keeps deep structures in one place for consumers
+	// of journalctl -o json. See [GroupEncoding].
+	GroupEncoding GroupEncoding
+
+	// DurationEncoding controls how time.Duration values of attributes are
+	// written: as microseconds, the default, as nanoseconds or as a string
+	// like 1.5s. See [DurationEncoding].
+	DurationEncoding DurationEncoding
+
+	// TimeLayout is the layout, as understood by time.Time.Format, of
+	// time.Time values of attributes, e.g. time.RFC3339Nano. If empty, they
+	// are written as microseconds since the epoch, like the time of the
+	// record by default. See also TimestampLayout.
+	TimeLayout string
+
+	// ExpandErrors, if true, writes attributes whose value is an error as
+	// the fields ERROR with its message, ERROR_TYPE with its type, e.g.
+	// *fs.PathError, STACKTRACE if an error in its chain implements
+	// [StackTracer], and ERRNO if its chain holds a syscall.Errno, instead of
+	// a single field named by the key of the attribute. Failures can then be
+	// queried like journalctl ERROR_TYPE=*fs.PathError. The fields of
+	// errors in groups are prefixed by the group as usual.
+	ExpandErrors bool
+
+	// Baggage, if non-nil, returns the W3C baggage carried by the context
+	// passed to Handle, in the format of the baggage HTTP header. The
+	// members listed in BaggageMembers are added as fields. With
+	// OpenTelemetry, use
+	//
+	//	Baggage: func(ctx context.Context) string {
+	//		return baggage.FromContext(ctx).String()
+	//	},
+	Baggage func(ctx context.Context) string
+
+	// BaggageMembers lists the baggage members added as fields, named by
+	// the member sanitized as by FieldNamesSanitize, e.g. USER_TIER for
+	// user.tier. Other members are
+	// ignored, so that the number of distinct fields stays bounded.
+	BaggageMembers []string
+
+	// Trace, if non-nil, returns the IDs of the trace and span active in
+	// the context passed to Handle, which are added as the fields TRACE_ID
+	// and SPAN_ID, so that entries can be correlated with distributed
+	// traces, e.g. with journalctl TRACE_ID=<id>. It returns an empty trace
+	// ID if there is no active span. With OpenTelemetry, use
+	//
+	//	Trace: func(ctx context.Context) (traceID, spanID string) {
+	//		sc := trace.SpanContextFromContext(ctx)
+	//		if !sc.IsValid() {
+	//			return "", ""
+	//		}
+	//		return sc.TraceID().String(), sc.SpanID().String()
+	//	},
+	Trace func(ctx context.Context) (traceID, spanID string)
+
+	// ContextAttrs, if non-nil, returns attributes carried by the context
+	// passed to Handle, e.g. a tenant ID set by a middleware, which are
+	// added to the record. Like REQUEST_ID, they are not prefixed by the
+	// groups of the handler, and they go through ReplaceAttr as usual.
+	ContextAttrs func(ctx context.Context) []slog.Attr
+
+	// DefaultMessageID, if non-empty, is the MESSAGE_ID of entries that do
+	// not get one otherwise: from a MESSAGE_ID attribute, see [MessageID],
+	// or from an error registered with [RegisterErrorMessageID]. It must be
+	// a valid ID, see [ValidMessageID].
+	DefaultMessageID string
+}
+
+// GroupOverride overrides options for the records logged under a group.
+// Zero fields leave the options inherited from the parent handler alone.
+type GroupOverride struct {
+	// Level replaces Options.Level.
+	Level slog.Leveler
+	// ReplaceAttr replaces Options.ReplaceAttr for the attributes added
+	// under the group.
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+	// Attrs are added to the records logged under the group, without the
+	// group prefix, e.g. slog.String("SUBSYSTEM", "db").
+	Attrs []slog.Attr
+	// SyslogIdentifier replaces Options.SyslogIdentifier.
+	SyslogIdentifier string
 }
 
 // Handler sends logs to the systemd journal.
@@ -99,9 +588,40 @@ type Handler struct {
 	groups       []string
 	prefix       string
 	preformatted []byte
+	levelFields  []levelFields
+	cache        *valueCache
+	identifier   []byte
+	redact       map[string]bool
+	static       *staticBuffer
+	stats        *stats
+	deadLetters  *spool
+	limiter      *rateLimiter
+	// schemaFields are the fields closed schemas allow, see handlerFields.
+	schemaFields map[string]bool
+	dedup        *deduper
+	baggage      map[string]string
+	fallback     slog.Handler
+	also         slog.Handler
+	strictErr    error
+	// levelVar is the variable behind h.opts.Level, if it is one, so that
+	// Enabled loads the level directly rather than calling through the
+	// slog.Leveler interface.
+	levelVar *slog.LevelVar
+	// expected, if not nil, collects the fields appendKV appends, for
+	// Options.VerifyRoundTrip.
+	expected *Entry
+	// preformattedFields are the fields of preformatted, if
+	// Options.VerifyRoundTrip is set.
+	preformattedFields Entry
 }
 
-const sndBufSize = 8 * 1024 * 1024
+// levelFields are the preformatted Options.LevelFields of a single level.
+type levelFields struct {
+	level slog.Level
+	b     []byte
+	// fields are the fields of b, if Options.VerifyRoundTrip is set.
+	fields Entry
+}
 
 // NewHandler returns a new Handler that writes to the [systemd journal].
 // The journal only accepts keys of the form ^[A-Z_][A-Z0-9_]*$.
@@ -110,46 +630,287 @@ const sndBufSize = 8 * 1024 * 1024
 // slog.LevelInfo unless the environment variable DEBUG_INVOCATION is set, in
 // which case it is slog.LevelDebug.
 //
+// On Windows, entries are written to the Windows Event Log instead, with the
+// SYSLOG_IDENTIFIER as the event source. On macOS, entries are written to the
+// unified logging system if cgo is enabled, with the SYSLOG_IDENTIFIER as
+// the subsystem and the groups as the category.
+//
 // [systemd journal]: https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
 func NewHandler(opts *Options) (*Handler, error) {
-	h := &Handler{}
+	return newHandler(opts, false, nil)
+}
+
+// NewHandlerWithWriter is like [NewHandler], but writes entries to w
+// instead of the journal, each in the [native protocol format] in a single
+// call of w.Write, e.g. to capture them in tests with [ParseEntry] or to
+// send them over a transport of its own. Handle returns the errors of w.
+// Options that select the journal, such as SocketPath, Namespace, Socket,
+// SpoolPath and the fallback writers, are ignored. If w is an io.Closer,
+// [Handler.Close] closes it.
+//
+// [native protocol format]: https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
+func NewHandlerWithWriter(w io.Writer, opts *Options) (*Handler, error) {
+	if w == nil {
+		return nil, errors.New("nil writer")
+	}
+	return newHandler(opts, false, w)
+}
+
+// MustNewHandler is like [NewHandler] but panics if the handler cannot be
+// created.
+func MustNewHandler(opts *Options) *Handler {
+	h, err := NewHandler(opts)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// NewLazyHandler is like [NewHandler], but never fails: the socket to the
+// journal is only created when the first entry is written, and failures,
+// including those to read Options.Credentials and an invalid
+// Options.DefaultMessageID, are returned by Handle instead. If creating the
+// socket fails, the next Handle tries again. It lets package-level loggers
+// be initialized without error handling:
+//
+//	var logger = slog.New(slogjournal.NewLazyHandler(nil))
+func NewLazyHandler(opts *Options) *Handler {
+	h, _ := newHandler(opts, true, nil)
+	return h
+}
+
+// newHandler returns a handler writing to w, or if w is nil, to the writer
+// returned by newWriter, which is created on the first write if lazy is set.
+func newHandler(opts *Options, lazy bool, w io.Writer) (*Handler, error) {
+	h := &Handler{stats: &stats{}}
 
 	if opts != nil {
 		h.opts = *opts
 	}
+	h.fallback = h.opts.Fallback
+	h.also = h.opts.Also
+
+	// lazyErr is the failure a lazy handler returns from Handle.
+	var lazyErr error
+	if id := h.opts.DefaultMessageID; id != "" && !ValidMessageID(id) {
+		err := fmt.Errorf("invalid default MESSAGE_ID %q", id)
+		if !lazy {
+			return nil, err
+		}
+		lazyErr = err
+		h.opts.DefaultMessageID = ""
+	}
 
 	if h.opts.Level == nil {
 		h.opts.Level = &LevelVar{}
 	}
 
-	w, err := newJournalWriter()
-	if err != nil {
-		return nil, err
+	if h.opts.Credentials {
+		if err := h.loadCredentials(os.Getenv("CREDENTIALS_DIRECTORY")); err != nil {
+			if !lazy {
+				return nil, err
+			}
+			lazyErr = cmp.Or(lazyErr, err)
+		}
+	}
+
+	h.setLevel(h.opts.Level)
+
+	h.identifier = identifier
+	if h.opts.SyslogIdentifier != "" {
+		h.identifier = []byte(h.opts.SyslogIdentifier)
+	}
+
+	for _, k := range h.opts.Redact {
+		if h.redact == nil {
+			h.redact = make(map[string]bool)
+		}
+		h.redact[k] = true
 	}
 
-	h.w = w
+	if h.opts.SourceFields == 0 {
+		h.opts.SourceFields = DefaultSourceFields
+	}
+
+	if h.opts.ValueCacheSize > 0 {
+		h.cache = newValueCache(h.opts.ValueCacheSize)
+	}
+
+	if h.opts.Baggage != nil && len(h.opts.BaggageMembers) > 0 {
+		h.baggage = baggageFields(h.opts.BaggageMembers)
+	}
+
+	if h.opts.DeadLetterPath != "" {
+		h.deadLetters = newSpool(h.opts.DeadLetterPath)
+	}
+
+	if h.opts.RateLimitInterval > 0 && h.opts.RateLimitBurst > 0 {
+		h.limiter = newRateLimiter(h.opts.RateLimitInterval, h.opts.RateLimitBurst, h.opts.RateLimitKey)
+	}
+
+	if h.opts.DedupWindow > 0 {
+		h.dedup = newDeduper(h.opts.DedupWindow, h.opts.DedupKey)
+	}
+
+	if h.opts.StaticBufferSize > 0 {
+		h.static = newStaticBuffer(h.opts.StaticBufferSize)
+	}
+
+	if h.opts.VerifyRoundTrip {
+		h.expected = &h.preformattedFields
+	}
+	if id := os.Getenv("INVOCATION_ID"); h.opts.InvocationID && id != "" {
+		h.preformatted = h.appendKV(h.preformatted, invocationIDKey, []byte(id))
+	}
+
+	if h.opts.HostFields {
+		for _, f := range hostFields() {
+			h.preformatted = h.appendKV(h.preformatted, f.Name, f.Value)
+		}
+	}
+
+	for level, attrs := range h.opts.LevelFields {
+		lf := levelFields{level: level}
+		if h.opts.VerifyRoundTrip {
+			h.expected = &lf.fields
+		}
+		for _, a := range attrs {
+			lf.b = h.appendAttr(lf.b, "", a, 0)
+		}
+		h.levelFields = append(h.levelFields, lf)
+	}
+	h.expected = nil
+	slices.SortFunc(h.levelFields, func(a, b levelFields) int {
+		return cmp.Compare(a.level, b.level)
+	})
+
+	h.schemaFields = h.handlerFields()
+
+	switch {
+	case w != nil:
+		h.w = w
+	case lazy:
+		h.w = &lazyWriter{create: h.newWriter, err: lazyErr, diagnose: h.diagnose}
+	default:
+		w, err := h.newWriter()
+		if err != nil {
+			if h.fallback == nil {
+				return nil, err
+			}
+			w = errWriter{err}
+		}
+		h.w = w
+	}
+
+	// Without a writer, records go to Fallback right away.
+	if _, failed := h.w.(errWriter); h.opts.QueueSize > 0 && !failed {
+		h.w = newAsyncWriter(h.w, h.opts.QueueSize, h.opts.QueueFull, h.stats, h.diagnose)
+	}
 
 	return h, nil
 
 }
 
+// ErrNotSupported is returned by NewHandler on platforms without a system
+// log it can write to, such as plan9 and WebAssembly, unless
+// Options.ConsoleFallback or Options.Fallback is set. Entries are written to
+// the journal on Unix systems, to the Windows Event Log on Windows and to
+// the unified logging system on macOS with cgo. [Handler.WatchLevelSignals]
+// returns it on systems without SIGUSR1 and SIGUSR2.
+var ErrNotSupported = errors.New("no system log on " + runtime.GOOS)
+
+// errWriter fails every write with err.
+type errWriter struct {
+	err error
+}
+
+func (w errWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
+
+// levelToPriority maps l to a priority with Options.LevelToPriority, or
+// LevelToPriority if it is nil.
+func (h *Handler) levelToPriority(l slog.Level) Priority {
+	if f := h.opts.LevelToPriority; f != nil {
+		return f(l)
+	}
+	return LevelToPriority(l)
+}
+
+// setLevel sets the minimum level of h to l.
+func (h *Handler) setLevel(l slog.Leveler) {
+	h.opts.Level = l
+	h.levelVar = nil
+	switch l := l.(type) {
+	case *LevelVar:
+		l.Level() // apply DEBUG_INVOCATION
+		h.levelVar = &l.LevelVar
+	case *slog.LevelVar:
+		h.levelVar = l
+	}
+}
+
 // Enabled reports whether the handler handles records at the given level.
 // The handler ignores records whose level is lower.
 // It is called early, before any arguments are processed,
 // to save effort if the log event should be discarded.
 func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	if h.levelVar != nil {
+		return level >= h.levelVar.Level()
+	}
 	return level >= h.opts.Level.Level()
 }
 
-var identifier = []byte(path.Base(os.Args[0]))
+var identifier = []byte(filepath.Base(os.Args[0]))
+
+// RuntimeUsecKey is the field added by Options.RuntimeUsec.
+const RuntimeUsecKey = "RUNTIME_USEC"
+
+// The fields every handler adds, or adds depending on its options, see
+// Handler.handlerFields.
+const (
+	messageKey          = "MESSAGE"
+	priorityKey         = "PRIORITY"
+	levelKey            = "LEVEL"
+	syslogIdentifierKey = "SYSLOG_IDENTIFIER"
+	syslogTimestampKey  = "SYSLOG_TIMESTAMP"
+	invocationIDKey     = "INVOCATION_ID"
+	resolveErrorKey     = "RESOLVE_ERROR"
+	codeFileKey         = "CODE_FILE"
+	codeLineKey         = "CODE_LINE"
+	codeFuncKey         = "CODE_FUNC"
+	codeLocationKey     = "CODE_LOCATION"
+)
+
+// processStart approximates the start of the process. time.Now includes a
+// monotonic clock reading, which Time.Sub uses.
+var processStart = time.Now()
+
+var redacted = []byte("REDACTED")
+
+// priorityFields are the encoded PRIORITY fields of the eight priorities.
+var priorityFields = func() (f [8][]byte) {
+	for pri := range f {
+		f[pri] = []byte("PRIORITY=" + strconv.Itoa(pri) + "\n")
+	}
+	return f
+}()
 
 // Handle handles the Record and formats it as a [journal message].
 // The Message field maps to the [MESSAGE] field in the journal.
 // The Level field maps to the [PRIORITY] field in the journal.
-// The PC field maps to the [CODE_FILE, CODE_FUNC and CODE_LINE] fields in the journal.
-// The Time field maps to the [SYSLOG_TIMESTAMP] field in the journal.
-// The Attrs field maps to the [KEY=VALUE] fields in the journal.
-// The [SYSLOG_IDENTIFIER] field is set to the base name of the program.
+// Levels registered with [RegisterLevel], such as [LevelTrace], also get a LEVEL field.
+// The PC field maps to the [CODE_FILE, CODE_FUNC and CODE_LINE] fields in the journal,
+// or to the fields selected by Options.SourceFields.
+// The Time field maps to the [SYSLOG_TIMESTAMP] field in the journal, in
+// microseconds since the epoch unless Options.TimestampKey or
+// Options.TimestampLayout say otherwise.
+// The Attrs field maps to the [KEY=VALUE] fields in the journal. Values of
+// type []byte are written as they are, in the binary-safe form of the
+// protocol if they contain newlines.
+// The [SYSLOG_IDENTIFIER] field is set to Options.SyslogIdentifier, or the base name of the program.
+// A request ID carried by ctx (see [WithRequestID]) maps to the REQUEST_ID field,
+// and the attributes returned by Options.ContextAttrs are added to the record.
 // Journal only supports keys of the form ^[A-Z_][A-Z0-9_]*$.
 // Keys starting with an underscore are reserved for internal use and will be dropped.
 // Any other keys will be silently dropped.
@@ -166,42 +927,419 @@ var identifier = []byte(path.Base(os.Args[0]))
 // [SYSLOG_TIMESTAMP]: https://www.freedesktop.org/software/systemd/man/latest/systemd.journal-fields.html#SYSLOG_FACILITY=
 // [SYSLOG_IDENTIFIER]: https://www.freedesktop.org/software/systemd/man/latest/systemd.journal-fields.html#SYSLOG_FACILITY=
 func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
-	buf := make([]byte, 0, 1024)
-	buf = h.appendKV(buf, "MESSAGE", []byte(r.Message))
-	buf = h.appendKV(buf, "PRIORITY", []byte(strconv.Itoa(int(levelToPriority(r.Level)))))
+	err := h.handle(ctx, r)
+	// Renames are only reported now, as the buffer handle encoded r in
+	// may be the one a diagnostic entry is encoded in.
+	h.reportSanitized()
+	if h.also != nil && h.also.Enabled(ctx, r.Level) {
+		err = errors.Join(err, h.also.Handle(ctx, r))
+	}
+	return err
+}
+
+// handle writes r to the journal.
+func (h *Handler) handle(ctx context.Context, r slog.Record) error {
+	if h.dedup != nil {
+		repeat, summaries := h.dedup.check(r, time.Now())
+		h.writeRepeated(summaries)
+		if repeat {
+			return nil
+		}
+	}
+	if h.limiter != nil && !h.rateLimit(r) {
+		return nil
+	}
+
+	var buf []byte
+	if h.static != nil {
+		h.static.mu.Lock()
+		defer h.static.mu.Unlock()
+		buf = h.static.b[:0]
+	} else {
+		bp := getBuffer()
+		defer func() {
+			*bp = buf
+			putBuffer(bp)
+		}()
+		buf = *bp
+	}
+	enc := h
+	var expected *Entry
+	if h.opts.VerifyRoundTrip {
+		c := *h
+		expected = new(Entry)
+		c.expected = expected
+		enc = &c
+	}
+	buf, err := enc.encode(ctx, r, buf)
+	if err != nil {
+		h.stats.rejected.Add(1)
+		return err
+	}
+	if h.static != nil && h.static.truncated {
+		h.stats.truncated.Add(1)
+	}
+
+	if h.opts.DryRun {
+		if err := h.checkMessageID(r); err != nil {
+			return err
+		}
+		return validateEntry(buf)
+	}
+
+	if h.deadLetters != nil {
+		if err := validateEntry(buf); err != nil {
+			h.stats.rejected.Add(1)
+			return h.deadLetter(buf, err)
+		}
+	}
+
+	verr := h.checkMessageID(r)
+	if expected != nil && verr == nil {
+		verr = verifyRoundTrip(buf, *expected)
+	}
+	if err := h.write(ctx, buf); err != nil {
+		if h.fallback != nil {
+			if !h.fallback.Enabled(ctx, r.Level) {
+				return nil
+			}
+			return h.fallback.Handle(ctx, r)
+		}
+		h.stats.dropped.Add(1)
+		return err
+	}
+	return verr
+}
+
+// write writes the entry b, handled with ctx, to h.w.
+func (h *Handler) write(ctx context.Context, b []byte) error {
+	if a, ok := h.w.(*asyncWriter); ok {
+		_, err := a.enqueue(b, h.groups, h.queueDeadline(ctx))
+		return err
+	}
+	if gw, ok := h.w.(groupWriter); ok {
+		_, err := gw.WriteGroups(b, h.groups)
+		return err
+	}
+	_, err := h.w.Write(b)
+	return err
+}
+
+// groupWriter is implemented by writers that make use of the groups a record
+// was logged under, such as the unified logging writer on macOS.
+type groupWriter interface {
+	io.Writer
+	WriteGroups(p []byte, groups []string) (int, error)
+}
+
+// encode appends r as a journal message in the native protocol format to buf.
+// It fails only if the entry is rejected by its schema. With a static buffer,
+// buf must be the static buffer and the caller must hold its lock until it is
+// done with the entry.
+func (h *Handler) encode(ctx context.Context, r slog.Record, buf []byte) ([]byte, error) {
+	if h.static != nil {
+		h.static.truncated = false
+	}
+	var num [20]byte
+	buf = h.appendKVString(buf, messageKey, r.Message)
+	if pri := h.levelToPriority(r.Level); pri >= PriorityEmergency && pri <= PriorityDebug && !h.redact[priorityKey] {
+		n := len(buf)
+		buf = h.appendRaw(buf, priorityFields[pri])
+		if h.expected != nil && len(buf) > n {
+			*h.expected = append(*h.expected, Field{priorityKey, priorityFields[pri][len(priorityKey)+1 : len(priorityKey)+2]})
+		}
+	} else {
+		buf = h.appendKV(buf, priorityKey, strconv.AppendInt(num[:0], int64(pri), 10))
+	}
+	if rl, ok := registeredLevel(r.Level); ok {
+		buf = h.appendKVString(buf, levelKey, rl.name)
+	}
 	// If r.PC is zero, ignore it.
-	if r.PC != 0 {
-		fs := runtime.CallersFrames([]uintptr{r.PC})
-		f, _ := fs.Next()
-		buf = h.appendKV(buf, "CODE_FILE", []byte(f.File))
-		buf = h.appendKV(buf, "CODE_FUNC", []byte(f.Function))
-		buf = h.appendKV(buf, "CODE_LINE", []byte(strconv.Itoa(f.Line)))
+	if pc := recordPC(r); pc != 0 {
+		buf = h.appendSource(buf, pc)
 	}
 
 	// If r.Time is the zero time, ignore the time.
 	// NOTE: journald does its own timestamping. Lets just ignore
 	// NOTE: slogtest requires this. grrr
-	if !r.Time.IsZero() {
-		timestampStr := strconv.FormatInt(r.Time.UnixMicro(), 10)
-		buf = h.appendKV(buf, "SYSLOG_TIMESTAMP", []byte(timestampStr))
+	if !r.Time.IsZero() && !h.opts.OmitTimestamp {
+		buf = h.appendTimestamp(buf, r.Time)
+	}
+
+	if h.opts.RuntimeUsec {
+		t := r.Time
+		if t.IsZero() {
+			t = time.Now()
+		}
+		buf = h.appendKV(buf, RuntimeUsecKey, strconv.AppendInt(num[:0], t.Sub(processStart).Microseconds(), 10))
+	}
+
+	buf = h.appendKV(buf, syslogIdentifierKey, h.identifier)
+
+	if h.opts.ThreadID {
+		if tid, ok := gettid(); ok {
+			buf = h.appendKV(buf, ThreadIDKey, strconv.AppendInt(num[:0], int64(tid), 10))
+		}
 	}
 
-	buf = h.appendKV(buf, "SYSLOG_IDENTIFIER", identifier)
+	if h.opts.GoroutineID {
+		buf = h.appendKV(buf, GoroutineIDKey, appendGoroutineID(num[:0]))
+	}
 
-	buf = append(buf, h.preformatted...)
+	for _, lf := range h.levelFields {
+		if r.Level < lf.level {
+			break
+		}
+		n := len(buf)
+		buf = h.appendRaw(buf, lf.b)
+		if h.expected != nil && len(buf) > n {
+			*h.expected = append(*h.expected, lf.fields...)
+		}
+	}
 
+	if id, ok := RequestIDFromContext(ctx); ok {
+		buf = h.appendKVString(buf, RequestIDKey, id)
+	}
+
+	if h.opts.Trace != nil {
+		buf = h.appendTrace(buf, ctx)
+	}
+
+	if h.baggage != nil {
+		buf = h.appendBaggage(buf, ctx)
+	}
+
+	if h.opts.ContextAttrs != nil {
+		for _, a := range h.opts.ContextAttrs(ctx) {
+			buf = h.appendAttr(buf, "", a, 0)
+		}
+	}
+
+	n := len(buf)
+	buf = h.appendRaw(buf, h.preformatted)
+	if h.expected != nil && len(buf) > n {
+		*h.expected = append(*h.expected, h.preformattedFields...)
+	}
+
+	schema, hasSchema := h.recordSchema(r)
 	r.Attrs(func(a slog.Attr) bool {
-		buf = h.appendAttr(buf, h.prefix, a)
+		if hasSchema && schema.declares(a.Key) {
+			buf = h.appendAttr(buf, "", a, 0)
+		} else {
+			buf = h.appendAttr(buf, h.prefix, a, 0)
+		}
 		return true
 	})
 
-	_, err := h.w.Write(buf)
-	return err
+	if id, ok := h.recordMessageID(r); ok {
+		buf = h.appendKVString(buf, MessageIDKey, id)
+	}
+
+	if h.opts.FieldNames == FieldNamesReject {
+		if err := checkFieldNames(buf); err != nil {
+			return nil, err
+		}
+	}
+
+	if h.opts.SchemaMode != SchemaIgnore {
+		var err error
+		if buf, err = h.checkSchema(buf); err != nil {
+			return nil, err
+		}
+	}
+
+	if h.static != nil && h.static.truncated {
+		buf = append(buf, truncatedField...)
+		if h.expected != nil {
+			*h.expected = append(*h.expected, Field{TruncatedKey, []byte("1")})
+		}
+	}
+
+	if len(h.opts.SigningKey) > 0 {
+		buf = h.appendSignature(buf)
+	}
+
+	return buf, nil
+}
+
+// CallerKey is the key of the Attr returned by [Caller] and [SourcePC].
+const CallerKey = "CALLER"
+
+// callerPC is the value type of the Attr returned by Caller and SourcePC.
+type callerPC uintptr
+
+// Caller returns an Attr that overrides the source location of the record it
+// is added to. The argument skip is the number of stack frames to ascend, with
+// 0 identifying the caller of Caller.
+//
+// Logging wrappers use it to report their callers instead of themselves:
+//
+//	func Infof(format string, args ...any) {
+//		logger.Info(fmt.Sprintf(format, args...), slogjournal.Caller(1))
+//	}
+func Caller(skip int) slog.Attr {
+	var pcs [1]uintptr
+	runtime.Callers(skip+2, pcs[:])
+	return SourcePC(pcs[0])
+}
 
+// SourcePC returns an Attr that overrides the source location of the record it
+// is added to with pc.
+func SourcePC(pc uintptr) slog.Attr {
+	return slog.Any(CallerKey, callerPC(pc))
+}
+
+// recordPC returns the program counter of the record's call site, which is
+// r.PC unless overridden by an Attr returned by Caller or SourcePC.
+func recordPC(r slog.Record) uintptr {
+	pc := r.PC
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Value.Kind() != slog.KindAny {
+			return true
+		}
+		if c, ok := a.Value.Any().(callerPC); ok {
+			pc = uintptr(c)
+			return false
+		}
+		return true
+	})
+	return pc
+}
+
+// appendTimestamp appends the time of the record in the field and layout
+// selected by Options.TimestampKey and Options.TimestampLayout.
+func (h *Handler) appendTimestamp(b []byte, t time.Time) []byte {
+	key := h.opts.TimestampKey
+	if key == "" {
+		key = syslogTimestampKey
+	}
+	var num [64]byte
+	if h.opts.TimestampLayout == "" {
+		return h.appendKV(b, key, strconv.AppendInt(num[:0], t.UnixMicro(), 10))
+	}
+	return h.appendKV(b, key, t.AppendFormat(num[:0], h.opts.TimestampLayout))
+}
+
+// appendSource appends the source location fields selected by
+// Options.SourceFields.
+func (h *Handler) appendSource(b []byte, pc uintptr) []byte {
+	sf := h.opts.SourceFields
+	if sf&NoSource != 0 {
+		return b
+	}
+	f := frameOf(pc)
+	if sf&CodeFile != 0 {
+		b = h.appendKVString(b, codeFileKey, h.codeFile(f))
+	}
+	if sf&CodeFunc != 0 {
+		b = h.appendKVString(b, codeFuncKey, f.Function)
+	}
+	if sf&CodeLine != 0 {
+		var num [20]byte
+		b = h.appendKV(b, codeLineKey, strconv.AppendInt(num[:0], int64(f.Line), 10))
+	}
+	if sf&CodeLocation != 0 {
+		var buf [256]byte
+		loc := append(buf[:0], h.codeFile(f)...)
+		loc = append(loc, ':')
+		loc = strconv.AppendInt(loc, int64(f.Line), 10)
+		loc = append(loc, " ("...)
+		loc = append(loc, f.Function...)
+		loc = append(loc, ')')
+		b = h.appendKV(b, codeLocationKey, loc)
+	}
+	return b
+}
+
+// frames caches the frames of program counters, since runtime.CallersFrames
+// allocates. The number of call sites that log is bounded, and so is the
+// cache.
+var frames = struct {
+	sync.RWMutex
+	m map[uintptr]runtime.Frame
+}{m: make(map[uintptr]runtime.Frame)}
+
+// frameOf returns the frame of pc.
+func frameOf(pc uintptr) runtime.Frame {
+	frames.RLock()
+	f, ok := frames.m[pc]
+	frames.RUnlock()
+	if ok {
+		return f
+	}
+	f, _ = runtime.CallersFrames([]uintptr{pc}).Next()
+	frames.Lock()
+	frames.m[pc] = f
+	frames.Unlock()
+	return f
+}
+
+// codeFile returns the CODE_FILE value for f, trimmed according to the options.
+func (h *Handler) codeFile(f runtime.Frame) string {
+	if h.opts.CodeFileRelative && f.Function != "" {
+		pkg := packagePath(f.Function)
+		file := path.Base(f.File)
+		if mod := mainModule(); mod != "" {
+			if pkg == mod {
+				return file
+			}
+			if rel, ok := strings.CutPrefix(pkg, mod+"/"); ok {
+				return rel + "/" + file
+			}
+		}
+		return pkg + "/" + file
+	}
+	if h.opts.CodeFilePrefix != "" {
+		return strings.TrimPrefix(f.File, h.opts.CodeFilePrefix)
+	}
+	return f.File
+}
+
+// mainModule returns the module path of the main module, if known.
+var mainModule = sync.OnceValue(func() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return bi.Main.Path
+})
+
+// packagePath returns the import path of the package a fully qualified
+// function name such as "example.com/pkg.(*T).Method" belongs to.
+func packagePath(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot != -1 {
+		return function[:slash+1+dot]
+	}
+	return function
 }
 
 func (h *Handler) appendKV(b []byte, k string, v []byte) []byte {
-	if bytes.IndexByte(v, '\n') != -1 {
+	if h.redact[k] {
+		v = redacted
+	}
+	if h.opts.FieldNames == FieldNamesSanitize || h.opts.FieldNames == FieldNamesDrop {
+		if checkFieldName(k) != "" {
+			if h.opts.FieldNames == FieldNamesDrop {
+				return b
+			}
+			name := sanitizeFieldName(k)
+			h.stats.sanitized.add(k, name)
+			k = name
+		}
+	}
+	if size := h.opts.FieldPartSize; size > 0 && len(v) > size {
+		return h.appendParts(b, k, v)
+	}
+	bin := bytes.IndexByte(v, '\n') != -1
+	n := len(k) + len(v) + 2
+	if bin {
+		n += 8
+	}
+	if !h.fits(b, n) {
+		return b
+	}
+	if bin {
 		b = append(b, k...)
 		b = append(b, '\n')
 		b = binary.LittleEndian.AppendUint64(b, uint64(len(v)))
@@ -213,9 +1351,18 @@ func (h *Handler) appendKV(b []byte, k string, v []byte) []byte {
 		b = append(b, v...)
 		b = append(b, '\n')
 	}
+	if h.expected != nil {
+		h.expect(k, v)
+	}
 	return b
 }
 
+// appendKVString is appendKV for string values. It does not copy v, which
+// appendKV neither modifies nor retains.
+func (h *Handler) appendKVString(b []byte, k, v string) []byte {
+	return h.appendKV(b, k, unsafe.Slice(unsafe.StringData(v), len(v)))
+}
+
 // appendAttr has the following rules:
 //   - Attr's values should be resolved.
 //   - If an Attr's key and value are both the zero value, ignore the Attr.
@@ -223,21 +1370,45 @@ func (h *Handler) appendKV(b []byte, k string, v []byte) []byte {
 //   - If a group's key is empty, inline the group's Attrs.
 //   - If a group has no Attrs (even if it has a non-empty key),
 //     ignore it.
-func (h *Handler) appendAttr(b []byte, prefix string, a slog.Attr) []byte {
+//
+// depth is the nesting depth of a within groups. Values that cannot be
+// resolved and groups nested deeper than maxResolveDepth are reported in a
+// RESOLVE_ERROR field instead.
+func (h *Handler) appendAttr(b []byte, prefix string, a slog.Attr, depth int) []byte {
 	// Attr's values should be resolved.
-	a.Value = a.Value.Resolve()
+	var err error
+	if a.Value, err = resolve(a.Value); err != nil {
+		return h.appendResolveError(b, prefix+a.Key, err)
+	}
+
+	// Source overrides are consumed by Handle.
+	if a.Value.Kind() == slog.KindAny {
+		if _, ok := a.Value.Any().(callerPC); ok {
+			return b
+		}
+	}
 
 	if rep := h.opts.ReplaceAttr; rep != nil && a.Value.Kind() != slog.KindGroup {
 		// a.Value is resolved before calling ReplaceAttr, so the user doesn't have to.
 		a = rep(h.groups, a)
 		// The ReplaceAttr function may return an unresolved Attr.
-		a.Value = a.Value.Resolve()
+		if a.Value, err = resolve(a.Value); err != nil {
+			return h.appendResolveError(b, prefix+a.Key, err)
+		}
 	}
 
 	// If an Attr's key and value are both the zero value, ignore the Attr.
 	if a.Equal(slog.Attr{}) {
 		return b
 	}
+	// MESSAGE_ID identifies the entry, so it is never prefixed by the
+	// groups of the handler, and invalid IDs are left out.
+	if a.Key == MessageIDKey && depth == 0 {
+		if id := a.Value.String(); ValidMessageID(id) {
+			b = h.appendKVString(b, MessageIDKey, id)
+		}
+		return b
+	}
 	switch a.Value.Kind() {
 	case slog.KindGroup:
 		attrs := a.Value.Group()
@@ -245,23 +1416,85 @@ func (h *Handler) appendAttr(b []byte, prefix string, a slog.Attr) []byte {
 		if len(attrs) == 0 {
 			return b
 		}
+		if depth == maxResolveDepth {
+			return h.appendResolveError(b, prefix+a.Key, errGroupTooDeep)
+		}
 		// If a group's key is not empty, append the group's key as a prefix.
 		// Otherwise, if a group's key is empty, inline the group's Attrs.
 		if a.Key != "" {
 			if rep := h.opts.ReplaceGroup; rep != nil {
 				a.Key = rep(a.Key)
 			}
-			prefix += a.Key + "_"
+			if h.opts.GroupEncoding == GroupsJSON {
+				return h.appendJSONField(b, prefix+a.Key, a.Value, depth)
+			}
+			// A single concatenation, unlike +=, does not allocate for
+			// short prefixes.
+			prefix = prefix + a.Key + "_"
 		}
 		for _, a := range attrs {
-			b = h.appendAttr(b, prefix, a)
+			b = h.appendAttr(b, prefix, a, depth+1)
+		}
+	case slog.KindString:
+		if v := a.Value.String(); h.cache != nil && len(v) >= minCachedValueSize {
+			b = h.cache.appendField(h, b, prefix+a.Key, v)
+			break
 		}
+		b = h.appendKVString(b, prefix+a.Key, a.Value.String())
+	case slog.KindInt64:
+		var num [20]byte
+		b = h.appendKV(b, prefix+a.Key, strconv.AppendInt(num[:0], a.Value.Int64(), 10))
+	case slog.KindUint64:
+		var num [20]byte
+		b = h.appendKV(b, prefix+a.Key, strconv.AppendUint(num[:0], a.Value.Uint64(), 10))
+	case slog.KindBool:
+		var num [5]byte
+		b = h.appendKV(b, prefix+a.Key, strconv.AppendBool(num[:0], a.Value.Bool()))
+	case slog.KindFloat64:
+		// The same format as slog.Value.String, without its allocation.
+		var num [32]byte
+		b = h.appendKV(b, prefix+a.Key, strconv.AppendFloat(num[:0], a.Value.Float64(), 'g', -1, 64))
 	case slog.KindDuration:
-		b = h.appendKV(b, prefix+a.Key, []byte(strconv.FormatInt(a.Value.Duration().Microseconds(), 10)))
+		var num [32]byte
+		b = h.appendKV(b, prefix+a.Key, h.appendDuration(num[:0], a.Value.Duration(), false))
 	case slog.KindTime:
-		b = h.appendKV(b, prefix+a.Key, []byte(strconv.FormatInt(a.Value.Time().UnixMicro(), 10)))
+		var num [64]byte
+		b = h.appendKV(b, prefix+a.Key, h.appendTime(num[:0], a.Value.Time(), false))
+	case slog.KindAny:
+		if v, ok := encodeValue(a.Value.Any()); ok {
+			b = h.appendKV(b, prefix+a.Key, v)
+			break
+		}
+		// Binary values, e.g. hashes or protobufs, are written untouched.
+		if v, ok := a.Value.Any().([]byte); ok {
+			b = h.appendKV(b, prefix+a.Key, v)
+			break
+		}
+		if m, ok := a.Value.Any().(FieldMarshaler); ok {
+			attrs, err := marshalFields(m)
+			if err != nil {
+				return h.appendResolveError(b, prefix+a.Key, err)
+			}
+			b = h.appendAttr(b, prefix, slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}, depth)
+			break
+		}
+		if err, ok := a.Value.Any().(error); ok && h.opts.ExpandErrors {
+			b = h.appendError(b, prefix, err)
+			break
+		}
+		if h.opts.ExpandStructs {
+			if attrs, ok := expandStruct(a.Value.Any()); ok {
+				b = h.appendAttr(b, prefix, slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}, depth)
+				break
+			}
+		}
+		if h.opts.GroupEncoding == GroupsJSON && !formatsItself(a.Value.Any()) {
+			b = h.appendJSONField(b, prefix+a.Key, a.Value, depth)
+			break
+		}
+		b = h.appendKVString(b, prefix+a.Key, a.Value.String())
 	default:
-		b = h.appendKV(b, prefix+a.Key, []byte(a.Value.String()))
+		b = h.appendKVString(b, prefix+a.Key, a.Value.String())
 	}
 
 	return b
@@ -269,13 +1502,39 @@ func (h *Handler) appendAttr(b []byte, prefix string, a slog.Attr) []byte {
 
 // WithAttrs returns a new Handler whose attributes consist of
 // both the receiver's attributes and the arguments.
+//
+// The attributes of a chain of WithAttrs and WithGroup calls are kept in a
+// single preformatted block that is never modified once built, so Handle
+// copies it in one go regardless of the depth of the chain.
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
 	h2 := *h
-	pre := slices.Clone(h2.preformatted)
+	var fields Entry
+	if h.opts.VerifyRoundTrip {
+		h2.expected = &fields
+	}
+	var add []byte
 	for _, a := range attrs {
-		pre = h2.appendAttr(pre, h2.prefix, a)
+		add = h2.appendAttr(add, h.prefix, a, 0)
+	}
+	h2.expected = nil
+	if fields != nil {
+		h2.preformattedFields = append(slices.Clip(h.preformattedFields), fields...)
+	}
+	if h.opts.Strict != StrictOff {
+		h2.strictErr = h.checkStrictFields(add)
+	}
+	h2.preformatted = make([]byte, 0, len(h.preformatted)+len(add))
+	h2.preformatted = append(h2.preformatted, h.preformatted...)
+	h2.preformatted = append(h2.preformatted, add...)
+	if h.fallback != nil {
+		h2.fallback = h.fallback.WithAttrs(attrs)
+	}
+	if h.also != nil {
+		h2.also = h.also.WithAttrs(attrs)
 	}
-	h2.preformatted = pre
 	return &h2
 }
 
@@ -288,12 +1547,41 @@ func (h *Handler) WithGroup(name string) slog.Handler {
 	if rep := h.opts.ReplaceGroup; rep != nil {
 		name = rep(name)
 	}
-	return &Handler{
-		opts:         h.opts,
-		w:            h.w,
-		groups:       append(slices.Clip(h.groups), name),
-		prefix:       h.prefix + name + "_",
-		preformatted: h.preformatted,
+	h2 := *h
+	if h.opts.Strict != StrictOff {
+		h2.strictErr = h.checkStrictGroup(h.prefix + name)
+	}
+	h2.groups = append(slices.Clip(h.groups), name)
+	h2.prefix = h.prefix + name + "_"
+	if h.fallback != nil {
+		h2.fallback = h.fallback.WithGroup(name)
+	}
+	if h.also != nil {
+		h2.also = h.also.WithGroup(name)
+	}
+	if o, ok := h.opts.GroupOverrides[strings.Join(h2.groups, ".")]; ok {
+		h2.applyOverride(o)
+	}
+	return &h2
+}
+
+// applyOverride applies the group override o to h.
+func (h *Handler) applyOverride(o GroupOverride) {
+	if o.Level != nil {
+		h.setLevel(o.Level)
+	}
+	if o.ReplaceAttr != nil {
+		h.opts.ReplaceAttr = o.ReplaceAttr
+	}
+	if o.SyslogIdentifier != "" {
+		h.identifier = []byte(o.SyslogIdentifier)
+	}
+	if len(o.Attrs) > 0 {
+		var add []byte
+		for _, a := range o.Attrs {
+			add = h.appendAttr(add, "", a, 0)
+		}
+		h.preformatted = append(slices.Clip(h.preformatted), add...)
 	}
 }
 
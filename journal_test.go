@@ -6,10 +6,12 @@ import (
 	"encoding/binary"
 	"io"
 	"log/slog"
-	"net"
 	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
-	"syscall"
 	"testing"
 	"testing/slogtest"
 	"time"
@@ -118,6 +120,63 @@ func TestBasicFunctionality(t *testing.T) {
 
 }
 
+func TestNewHandlerWithWriter(t *testing.T) {
+	if _, err := NewHandlerWithWriter(nil, nil); err == nil {
+		t.Error("expected a nil writer to be rejected")
+	}
+
+	w := &failingWriter{}
+	handler, err := NewHandlerWithWriter(w, &Options{SocketPath: "/nonexistent/socket"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.New(handler).Info("Hello, World!", "KEY", "value")
+	e, err := ParseEntry(w.buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := e.Get("KEY"); string(v) != "value" {
+		t.Errorf("expected KEY=value, got %q", v)
+	}
+	if err := handler.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !w.closed {
+		t.Error("expected Close to close the writer")
+	}
+}
+
+func TestAlso(t *testing.T) {
+	var also bytes.Buffer
+	w := &failingWriter{}
+	handler, err := NewHandlerWithWriter(w, &Options{
+		Level: slog.LevelInfo,
+		Also: slog.NewTextHandler(&also, &slog.HandlerOptions{
+			Level: slog.LevelWarn,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey && len(groups) == 0 {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(handler).With("KEY", "value").WithGroup("G")
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn", "N", 1)
+
+	if want := "level=WARN msg=warn KEY=value G.N=1\n"; also.String() != want {
+		t.Errorf("expected %q, got %q", want, also.String())
+	}
+	if n := bytes.Count(w.buf.Bytes(), []byte("MESSAGE=")); n != 2 {
+		t.Errorf("expected 2 entries in the journal, got %d", n)
+	}
+}
+
 func TestWithAttrs(t *testing.T) {
 	h, err := NewHandler(nil)
 	if err != nil {
@@ -241,10 +300,18 @@ func createNestedMap(m map[string]any, keys []string, value any) {
 }
 
 func TestSlogtest(t *testing.T) {
+	for _, opts := range []*Options{nil, {OmitTimestamp: true}} {
+		t.Run("OmitTimestamp="+strconv.FormatBool(opts != nil), func(t *testing.T) {
+			testSlogtest(t, opts)
+		})
+	}
+}
+
+func testSlogtest(t *testing.T, opts *Options) {
 	var buf bytes.Buffer
 
 	slogtest.Run(t, func(t *testing.T) slog.Handler {
-		handler, err := NewHandler(nil)
+		handler, err := NewHandler(opts)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -273,143 +340,492 @@ func TestSlogtest(t *testing.T) {
 			}
 			m[k] = v
 		}
+		// Without SYSLOG_TIMESTAMP, the time of an entry is the time
+		// journald received it, which it adds to all entries.
+		if opts != nil && opts.OmitTimestamp && !strings.HasSuffix(t.Name(), "/zero-time") {
+			m[slog.TimeKey] = "__REALTIME_TIMESTAMP"
+		}
 		buf.Reset()
 		return m
 	})
 }
 
-func TestCanWriteMessageToSocket(t *testing.T) {
-	tempDir, err := os.MkdirTemp(os.TempDir(), "journal")
+func TestLevel(t *testing.T) {
+	l := LevelVar{}
+	if l.Level() != slog.LevelInfo {
+		t.Error("expected LevelInfo")
+	}
+
+	h, err := NewHandler(nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	addr := tempDir + "/socket"
-	raddr, err := net.ResolveUnixAddr("unixgram", addr)
+	if h.opts.Level.Level() != slog.LevelInfo {
+		t.Error("expected LevelInfo")
+	}
+
+	l = LevelVar{}
+	os.Setenv("DEBUG_INVOCATION", "1")
+	if l.Level() != slog.LevelDebug {
+		t.Error("expected LevelDebug")
+	}
+
+	h, err = NewHandler(nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	conn, err := net.ListenUnixgram("unixgram", raddr)
+	if h.opts.Level.Level() != slog.LevelDebug {
+		t.Error("expected LevelDebug")
+	}
+
+	h, err = NewHandler(&Options{Level: slog.LevelError})
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer conn.Close()
+	if h.opts.Level.Level() != slog.LevelError {
+		t.Error("expected LevelError")
+	}
 
+	if !h.Enabled(context.TODO(), slog.LevelError) {
+		t.Error("expected true")
+	}
+
+}
+
+func TestCodeFile(t *testing.T) {
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	_, file, _, _ := runtime.Caller(0)
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"Default", Options{}, file},
+		{"Prefix", Options{CodeFilePrefix: path.Dir(file) + "/"}, "journal_test.go"},
+		{"Relative", Options{CodeFileRelative: true}, "journal_test.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			handler, err := NewHandler(&tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler.w = buf
+			_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", pcs[0]))
+			kv, err := deserializeKeyValue(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kv["CODE_FILE"] != tt.want {
+				t.Errorf("expected CODE_FILE=%s, got %q", tt.want, kv["CODE_FILE"])
+			}
+		})
+	}
+}
+
+func TestPackagePath(t *testing.T) {
+	for fn, want := range map[string]string{
+		"main.main":                         "main",
+		"net/http.(*Server).Serve":          "net/http",
+		"example.com/mod/pkg.Func.func1":    "example.com/mod/pkg",
+		"github.com/systemd/slog-journal.F": "github.com/systemd/slog-journal",
+	} {
+		if got := packagePath(fn); got != want {
+			t.Errorf("packagePath(%q) = %q, want %q", fn, got, want)
+		}
+	}
+}
+
+func TestSourceFields(t *testing.T) {
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	fs := runtime.CallersFrames(pcs[:])
+	f, _ := fs.Next()
+
+	tests := []struct {
+		name    string
+		fields  SourceFields
+		present []string
+		absent  []string
+	}{
+		{"Default", 0, []string{"CODE_FILE", "CODE_FUNC", "CODE_LINE"}, []string{"CODE_LOCATION"}},
+		{"Location", CodeLocation, []string{"CODE_LOCATION"}, []string{"CODE_FILE", "CODE_FUNC", "CODE_LINE"}},
+		{"FileAndLine", CodeFile | CodeLine, []string{"CODE_FILE", "CODE_LINE"}, []string{"CODE_FUNC", "CODE_LOCATION"}},
+		{"None", NoSource, nil, []string{"CODE_FILE", "CODE_FUNC", "CODE_LINE", "CODE_LOCATION"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			handler, err := NewHandler(&Options{SourceFields: tt.fields, CodeFileRelative: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler.w = buf
+			_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", pcs[0]))
+			kv, err := deserializeKeyValue(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, k := range tt.present {
+				if _, ok := kv[k]; !ok {
+					t.Errorf("expected %s", k)
+				}
+			}
+			for _, k := range tt.absent {
+				if v, ok := kv[k]; ok {
+					t.Errorf("unexpected %s=%s", k, v)
+				}
+			}
+			if tt.fields == CodeLocation {
+				want := "journal_test.go:" + strconv.Itoa(f.Line) + " (" + f.Function + ")"
+				if kv["CODE_LOCATION"] != want {
+					t.Errorf("expected CODE_LOCATION=%s, got %q", want, kv["CODE_LOCATION"])
+				}
+			}
+		})
+	}
+}
+
+func TestCaller(t *testing.T) {
+	buf := new(bytes.Buffer)
 	handler, err := NewHandler(nil)
 	if err != nil {
 		t.Fatal(err)
 	}
+	handler.w = buf
 
-	handler.w.(*journalWriter).addr = raddr
+	logf := func(msg string) {
+		slog.New(handler).Info(msg, Caller(1))
+	}
+	_, _, line, _ := runtime.Caller(0)
+	logf("Hello, World!")
 
-	t.Run("NormalSize", func(t *testing.T) {
-		if err := handler.Handle(context.TODO(), slog.Record{Level: slog.LevelInfo, Message: "Hello, World!"}); err != nil {
-			t.Fatal(err)
-		}
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["CODE_LINE"] != strconv.Itoa(line+1) {
+		t.Errorf("expected CODE_LINE=%d, got %q", line+1, kv["CODE_LINE"])
+	}
+	if _, ok := kv[CallerKey]; ok {
+		t.Error("unexpected", CallerKey, kv)
+	}
+}
 
-		buf := make([]byte, 1024)
-		oob := make([]byte, 1024)
+func TestLevelFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{LevelFields: map[slog.Level][]slog.Attr{
+		slog.LevelWarn:  {slog.String("ALERT_CHANNEL", "ops")},
+		slog.LevelError: {slog.Bool("PAGES", true)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
 
-		n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	tests := []struct {
+		level   slog.Level
+		channel string
+		pages   string
+	}{
+		{slog.LevelInfo, "", ""},
+		{slog.LevelWarn, "ops", ""},
+		{slog.LevelError, "ops", "true"},
+		{LevelEmergency, "ops", "true"},
+	}
+	for _, tt := range tests {
+		_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), tt.level, "Hello, World!", 0))
+		kv, err := deserializeKeyValue(buf)
 		if err != nil {
 			t.Fatal(err)
 		}
-		if n == 0 {
-			t.Error("no data read")
+		if kv["ALERT_CHANNEL"] != tt.channel || kv["PAGES"] != tt.pages {
+			t.Error("unexpected level fields for", tt.level, kv)
 		}
-		if oobn != 0 {
-			t.Error("did not expect oob data")
-		}
-	})
-
-	t.Run("TooLarge", func(t *testing.T) {
+	}
+}
 
-		_ = handler.w.(*journalWriter).conn.SetWriteBuffer(1024)
+func TestLevelTrace(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{Level: LevelTrace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
 
-		largeLog := "Hello, World!"
-		for range 1024 {
-			largeLog += "a"
-		}
+	if !handler.Enabled(context.TODO(), LevelTrace) {
+		t.Error("expected LevelTrace to be enabled")
+	}
+	_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), LevelTrace, "Hello, World!", 0))
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["PRIORITY"] != "7" || kv["LEVEL"] != "TRACE" {
+		t.Error("expected PRIORITY=7 and LEVEL=TRACE", kv)
+	}
 
-		if err := handler.Handle(context.TODO(), slog.Record{Level: slog.LevelInfo, Message: largeLog}); err != nil {
-			t.Fatal(err)
-		}
+	_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelDebug, "Hello, World!", 0))
+	kv, err = deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := kv["LEVEL"]; ok {
+		t.Error("unexpected LEVEL", v)
+	}
 
-		buf := make([]byte, 1024)
-		oob := make([]byte, 1024)
+	t.Setenv("DEBUG_INVOCATION", "1")
+	l := LevelVar{Trace: true}
+	if l.Level() != LevelTrace {
+		t.Error("expected LevelTrace")
+	}
+}
 
-		_, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
-		if err != nil {
-			t.Error(err)
+func BenchmarkWithAttrsChain(b *testing.B) {
+	handler, err := NewHandler(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	handler.w = io.Discard
+	var h slog.Handler = handler
+	for i := range 16 {
+		h = h.WithAttrs([]slog.Attr{slog.Int("ATTR_"+strconv.Itoa(i), i)})
+		if i%4 == 0 {
+			h = h.WithGroup("GROUP" + strconv.Itoa(i))
 		}
+	}
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = h.Handle(context.TODO(), record)
+	}
+}
 
-		ctrl, err := syscall.ParseSocketControlMessage(oob[:oobn])
-		if err != nil {
-			t.Error(err)
-		}
+func TestEnabledLevelVar(t *testing.T) {
+	var v slog.LevelVar
+	h, err := NewHandler(&Options{Level: &v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Enabled(context.TODO(), slog.LevelDebug) {
+		t.Error("expected LevelDebug to be disabled")
+	}
+	v.Set(slog.LevelDebug)
+	if !h.Enabled(context.TODO(), slog.LevelDebug) {
+		t.Error("expected LevelDebug to be enabled after Set")
+	}
 
-		for _, m := range ctrl {
-			rights, err := syscall.ParseUnixRights(&m)
-			if err != nil {
-				t.Error(err)
-			}
-			for _, fd := range rights {
-				_ = syscall.SetNonblock(int(fd), true)
-				f := os.NewFile(uintptr(fd), "journal")
-				defer f.Close()
-				_, _ = f.Seek(0, 0)
-				buf := make([]byte, 4096)
-				n, err := f.Read(buf)
-				if err != nil {
-					t.Fatal(err)
-				}
-				if n == 0 {
-					t.Error("no data read")
-				}
-			}
+	t.Setenv("DEBUG_INVOCATION", "1")
+	lv := &LevelVar{}
+	h, err = NewHandler(&Options{Level: lv})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.Enabled(context.TODO(), slog.LevelDebug) {
+		t.Error("expected DEBUG_INVOCATION to enable LevelDebug")
+	}
+	lv.Set(slog.LevelWarn)
+	if lv.Level() != slog.LevelWarn || h.Enabled(context.TODO(), slog.LevelInfo) {
+		t.Error("expected Set to override DEBUG_INVOCATION")
+	}
+}
+
+func BenchmarkEnabledDisabled(b *testing.B) {
+	handler, err := NewHandler(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.TODO()
+	b.ReportAllocs()
+	for b.Loop() {
+		if handler.Enabled(ctx, LevelTrace) {
+			b.Fatal("expected LevelTrace to be disabled")
 		}
+	}
+}
 
-	})
+func BenchmarkHandleScalars(b *testing.B) {
+	handler, err := NewHandler(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	handler.w = io.Discard
+	record := slog.NewRecord(time.Now(), slog.LevelWarn, "Hello, World!", 0)
+	record.AddAttrs(
+		slog.Int("INT", -42),
+		slog.Uint64("UINT", 42),
+		slog.Bool("BOOL", true),
+		slog.Duration("DURATION", time.Second),
+		slog.Time("TIME", time.Now()),
+	)
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = handler.Handle(context.TODO(), record)
+	}
+}
 
+// newAllocRecord returns a record with a source location, long strings and
+// groups, the parts of an entry that used to allocate.
+func newAllocRecord() slog.Record {
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, "Hello, World! This message is longer than 32 bytes.", pcs[0])
+	r.AddAttrs(
+		slog.String("STRING", "a value that is longer than 32 bytes as well"),
+		slog.Float64("FLOAT", 3.5),
+		slog.Duration("DURATION", time.Second),
+		slog.Time("TIME", time.Unix(0, 0)),
+		slog.Group("GROUP", slog.String("KEY", "value"), slog.Int("INT", 1)),
+	)
+	return r
 }
 
-func TestLevel(t *testing.T) {
-	l := LevelVar{}
-	if l.Level() != slog.LevelInfo {
-		t.Error("expected LevelInfo")
+func BenchmarkHandle(b *testing.B) {
+	handler, err := NewHandler(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	handler.w = io.Discard
+	h := handler.WithGroup("REQUEST").WithAttrs([]slog.Attr{slog.String("ID", "42")})
+	record := newAllocRecord()
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = h.Handle(context.TODO(), record)
 	}
+}
 
-	h, err := NewHandler(nil)
+func TestHandleAllocs(t *testing.T) {
+	handler, err := NewHandler(nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if h.opts.Level.Level() != slog.LevelInfo {
-		t.Error("expected LevelInfo")
+	handler.w = io.Discard
+	h := handler.WithGroup("REQUEST").WithAttrs([]slog.Attr{slog.String("ID", "42")})
+	record := newAllocRecord()
+	if n := testing.AllocsPerRun(100, func() { _ = h.Handle(context.TODO(), record) }); n != 0 {
+		t.Errorf("expected no allocations, got %v per record", n)
 	}
+}
 
-	l = LevelVar{}
-	os.Setenv("DEBUG_INVOCATION", "1")
-	if l.Level() != slog.LevelDebug {
-		t.Error("expected LevelDebug")
+func TestInvocationID(t *testing.T) {
+	t.Setenv("INVOCATION_ID", "0123456789abcdef0123456789abcdef")
+
+	for _, enabled := range []bool{false, true} {
+		buf := new(bytes.Buffer)
+		handler, err := NewHandler(&Options{InvocationID: enabled})
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.w = buf
+
+		_ = handler.WithGroup("GROUP").Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+		kv, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := kv["INVOCATION_ID"], map[bool]string{true: "0123456789abcdef0123456789abcdef"}[enabled]; got != want {
+			t.Errorf("expected INVOCATION_ID=%s, got %q", want, got)
+		}
 	}
+}
 
-	h, err = NewHandler(nil)
+func TestRuntimeUsec(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{RuntimeUsec: true})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if h.opts.Level.Level() != slog.LevelDebug {
-		t.Error("expected LevelDebug")
-	}
+	handler.w = buf
 
-	h, err = NewHandler(&Options{Level: slog.LevelError})
+	_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+	now := time.Now()
+	kv, err := deserializeKeyValue(buf)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if h.opts.Level.Level() != slog.LevelError {
-		t.Error("expected LevelError")
+	usec, err := strconv.ParseInt(kv[RuntimeUsecKey], 10, 64)
+	if err != nil {
+		t.Fatal(err)
 	}
+	if max := now.Sub(processStart).Microseconds(); usec < 0 || usec > max {
+		t.Errorf("expected RUNTIME_USEC between 0 and %d, got %d", max, usec)
+	}
+}
 
-	if !h.Enabled(context.TODO(), slog.LevelError) {
-		t.Error("expected true")
+func TestTimestamp(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.UTC)
+	for _, tt := range []struct {
+		opts      *Options
+		key, want string
+		// parsed is the time Entry.Record recovers, if the key is SYSLOG_TIMESTAMP.
+		parsed time.Time
+	}{
+		{nil, "SYSLOG_TIMESTAMP", "1709296245123456", now.Truncate(time.Microsecond)},
+		{&Options{TimestampLayout: time.RFC3339Nano}, "SYSLOG_TIMESTAMP", "2024-03-01T12:30:45.123456789Z", now},
+		{&Options{TimestampKey: "REALTIME_USEC"}, "REALTIME_USEC", "1709296245123456", time.Time{}},
+	} {
+		buf := new(bytes.Buffer)
+		handler, err := NewHandler(tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.w = buf
+		_ = handler.Handle(context.TODO(), slog.NewRecord(now, slog.LevelInfo, "Hello, World!", 0))
+		e, err := ParseEntry(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := e.Get(tt.key); string(got) != tt.want {
+			t.Errorf("expected %s=%s, got %q", tt.key, tt.want, got)
+		}
+		if !tt.parsed.IsZero() && !e.Record().Time.Equal(tt.parsed) {
+			t.Errorf("expected time %v, got %v", tt.parsed, e.Record().Time)
+		}
 	}
+}
 
+func TestSyslogIdentifier(t *testing.T) {
+	for _, tt := range []struct {
+		opts *Options
+		want string
+	}{
+		{nil, filepath.Base(os.Args[0])},
+		{&Options{SyslogIdentifier: "wrapped"}, "wrapped"},
+	} {
+		buf := new(bytes.Buffer)
+		handler, err := NewHandler(tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.w = buf
+		_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+		kv, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kv["SYSLOG_IDENTIFIER"] != tt.want {
+			t.Errorf("expected SYSLOG_IDENTIFIER=%s, got %q", tt.want, kv["SYSLOG_IDENTIFIER"])
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	payload := []byte{0, '\n', 0xff, 'a', '\n'}
+	for _, opts := range []Options{{}, {GroupEncoding: GroupsJSON}} {
+		w := &queueWriter{}
+		handler, err := NewHandlerWithWriter(w, &opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slog.New(handler).Info("Hello, World!", "PAYLOAD", payload, "HASH", []byte("d41d8cd9"))
+		if v, _ := w.entries[0].Get("PAYLOAD"); !bytes.Equal(v, payload) {
+			t.Errorf("expected PAYLOAD=%q, got %q", payload, v)
+		}
+		if v, _ := w.entries[0].Get("HASH"); string(v) != "d41d8cd9" {
+			t.Errorf("expected HASH=d41d8cd9, got %q", v)
+		}
+	}
 }
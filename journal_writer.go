@@ -1,23 +1,167 @@
+//go:build unix && !(darwin && cgo)
+
 package slogjournal
 
 import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 )
 
 // journalWriter encapsulates the behaviour of writing unixgrams to the journal socket.
 // It will try to write the message with a single write call, but if the message is too large
 // it will write the message to a temporary file and send the file descriptor as OOB data.
+//
+// If a spool is configured, entries are spooled while the journal is
+// unavailable and replayed once it is reachable again.
+//
+// Writes on a socket are serialized by the runtime, so with more than one
+// socket in conns, concurrent writes are spread over them at random.
+//
+// addr is nil if the socket is connected already.
 type journalWriter struct {
-	addr *net.UnixAddr
-	conn *net.UnixConn
+	addr  *net.UnixAddr
+	conns []*net.UnixConn
+	spool *spool
+	// diagnose reports the conditions of the writer.
+	diagnose func(DiagnosticKind, string, error)
+
+	// onConnect and onDisconnect are Options.OnConnect and
+	// Options.OnDisconnect, fired when down changes.
+	onConnect    func(path string)
+	onDisconnect func(path string, err error)
+	down         atomic.Bool
+
+	// backoff is Options.ReconnectBackoff. retry is the state of the
+	// backoff while journald is unreachable: entries are not sent before
+	// at, and fail with err, the error of the last attempt.
+	backoff time.Duration
+	retry   struct {
+		sync.Mutex
+		at    time.Time
+		delay time.Duration
+		err   error
+	}
+
+	// fallback reports whether the handler has Options.Fallback, which
+	// handles the entries that cannot be sent.
+	fallback bool
 }
 
-func newJournalWriter() (io.Writer, error) {
+const sndBufSize = 8 * 1024 * 1024
+
+// maxReconnectBackoff bounds the delay between attempts to reach journald
+// with Options.ReconnectBackoff.
+const maxReconnectBackoff = 30 * time.Second
+
+// journalSocket is the socket journald listens on for the native protocol.
+const journalSocket = "/run/systemd/journal/socket"
+
+// newWriter returns the writer for the journal.
+func (h *Handler) newWriter() (io.Writer, error) {
+	path, err := h.socketPath()
+	if err != nil {
+		return nil, err
+	}
+	// A socket passed in Options.Socket is used even if there is no socket
+	// at path, e.g. in sandboxes without access to /run.
+	if h.opts.Socket == nil && h.opts.JSONFallback && inContainer() {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return &jsonWriter{w: os.Stdout}, nil
+		}
+	}
+	if h.opts.Socket == nil && h.opts.ConsoleFallback {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return newConsoleWriter(os.Stderr), nil
+		}
+	}
+	var w *journalWriter
+	if h.opts.Socket != nil {
+		w, err = newSocketJournalWriter(h.opts.Socket)
+	} else {
+		w, err = newJournalWriter(path, max(h.opts.WriterShards, 1))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if h.opts.SpoolPath != "" {
+		w.spool = newSpool(h.opts.SpoolPath)
+	}
+	w.diagnose = h.diagnose
+	w.fallback = h.fallback != nil
+	w.onConnect = h.opts.OnConnect
+	w.onDisconnect = h.opts.OnDisconnect
+	w.backoff = h.opts.ReconnectBackoff
+	if w.onConnect != nil {
+		w.onConnect(w.path())
+	}
+	return w, nil
+}
+
+// socketPath returns the path of the socket of the journal selected by
+// Options.SocketPath and Options.Namespace.
+func (h *Handler) socketPath() (string, error) {
+	switch {
+	case h.opts.SocketPath != "":
+		return h.opts.SocketPath, nil
+	case h.opts.Namespace != "":
+		if strings.ContainsAny(h.opts.Namespace, "/\x00") || h.opts.Namespace == "." || h.opts.Namespace == ".." {
+			return "", fmt.Errorf("invalid journal namespace %q", h.opts.Namespace)
+		}
+		return "/run/systemd/journal." + h.opts.Namespace + "/socket", nil
+	default:
+		return journalSocket, nil
+	}
+}
+
+// newJournalWriter returns a journalWriter sending entries to the socket at
+// path on n sockets.
+func newJournalWriter(path string, n int) (*journalWriter, error) {
+	w := &journalWriter{
+		addr: &net.UnixAddr{
+			Name: path,
+			Net:  "unixgram",
+		},
+		diagnose: noDiagnose,
+	}
+	for range n {
+		conn, err := newJournalConn()
+		if err != nil {
+			for _, c := range w.conns {
+				c.Close()
+			}
+			return nil, err
+		}
+		w.conns = append(w.conns, conn)
+	}
+	return w, nil
+}
+
+// newSocketJournalWriter returns a journalWriter sending entries on the
+// connected socket f.
+func newSocketJournalWriter(f *os.File) (*journalWriter, error) {
+	fconn, err := net.FileConn(f)
+	if err != nil {
+		return nil, err
+	}
+	conn, ok := fconn.(*net.UnixConn)
+	if !ok {
+		fconn.Close()
+		return nil, fmt.Errorf("expected a unix socket, got %T", fconn)
+	}
+	return &journalWriter{conns: []*net.UnixConn{conn}, diagnose: noDiagnose}, nil
+}
+
+func newJournalConn() (*net.UnixConn, error) {
 	// The "net" library in Go really wants me to either Dial or Listen a UnixConn,
 	// which would respectively bind() an address or connect() to a remote address,
 	// but we want neither. We want to create a datagram socket and write to it directly
@@ -41,50 +185,206 @@ func newJournalWriter() (io.Writer, error) {
 	}
 
 	if err := conn.SetWriteBuffer(sndBufSize); err != nil {
+		conn.Close()
 		return nil, err
 	}
+	return conn, nil
+}
 
-	addr := &net.UnixAddr{
-		Name: "/run/systemd/journal/socket",
-		Net:  "unixgram",
+// conn returns the socket to send the next entry on.
+func (j *journalWriter) conn() *net.UnixConn {
+	if len(j.conns) == 1 {
+		return j.conns[0]
 	}
-
-	return &journalWriter{
-		addr: addr,
-		conn: conn,
-	}, nil
+	return j.conns[rand.IntN(len(j.conns))]
 }
 
 // If the message is too large, it will write the message to a temporary file and send the file descriptor as OOB data.
 func (j *journalWriter) Write(p []byte) (n int, err error) {
+	if j.spool != nil && j.spool.pending.Load() {
+		// Notifications may write entries themselves, which must wait until
+		// the spool is released.
+		var deferred []func()
+		err := j.spool.replay(func(p []byte) error {
+			return j.send(p, func(f func()) { deferred = append(deferred, f) })
+		})
+		for _, f := range deferred {
+			f()
+		}
+		if err != nil {
+			if !isUnavailable(err) {
+				return 0, err
+			}
+			return len(p), j.spool.append(p)
+		}
+		j.diagnose(DiagnosticReplayed, "replayed the spooled entries", nil)
+	}
+
+	err = j.send(p, notifyNow)
+	if isUnavailable(err) && j.spool != nil {
+		if !j.spool.pending.Load() {
+			j.diagnose(DiagnosticSpooling, "journal unavailable, spooling entries", err)
+		}
+		return len(p), j.spool.append(p)
+	}
+	// fail silently if the journal is not available, unless there is a
+	// fallback for the entry
+	if err == nil || errors.Is(err, syscall.ENOENT) && !j.fallback {
+		return len(p), nil
+	}
+	return 0, err
+}
+
+// isUnavailable reports whether err indicates that journald is not running.
+func isUnavailable(err error) bool {
+	return errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// path returns the path of the journal socket, or the empty string if the
+// socket was passed by the caller.
+func (j *journalWriter) path() string {
+	if j.addr == nil {
+		return ""
+	}
+	return j.addr.Name
+}
+
+// notifyNow runs notifications right away.
+func notifyNow(f func()) { f() }
+
+// send sends a single entry to the journal and fires the connection
+// callbacks when journald becomes unreachable or reachable again. While
+// journald is unreachable, it only tries again after the backoff. The
+// callbacks and diagnostics are passed to notify to be run.
+func (j *journalWriter) send(p []byte, notify func(func())) error {
+	if j.backoff > 0 && j.down.Load() {
+		if err := j.backingOff(); err != nil {
+			return err
+		}
+	}
+	err := j.transmit(p, notify)
+	switch {
+	case err == nil:
+		if j.down.CompareAndSwap(true, false) {
+			j.retry.Lock()
+			j.retry.delay = 0
+			j.retry.Unlock()
+			notify(func() {
+				if j.onConnect != nil {
+					j.onConnect(j.path())
+				}
+				// With a spool, Write reports DiagnosticReplayed instead.
+				if j.spool == nil {
+					j.diagnose(DiagnosticReconnected, "journal reachable again", nil)
+				}
+			})
+		}
+	case isUnavailable(err):
+		if j.down.CompareAndSwap(false, true) {
+			notify(func() {
+				if j.onDisconnect != nil {
+					j.onDisconnect(j.path(), err)
+				}
+				// With a spool, Write reports DiagnosticSpooling instead.
+				if j.spool == nil {
+					j.diagnose(DiagnosticWriterFailed, "journal unreachable", err)
+				}
+			})
+		}
+		if j.backoff > 0 {
+			j.scheduleRetry(err)
+		}
+	}
+	return err
+}
+
+// backingOff returns the error of the last attempt to reach journald if it
+// is too early to try again.
+func (j *journalWriter) backingOff() error {
+	j.retry.Lock()
+	defer j.retry.Unlock()
+	if time.Now().Before(j.retry.at) {
+		return j.retry.err
+	}
+	return nil
+}
+
+// scheduleRetry schedules the next attempt to reach journald after the
+// attempt that failed with err, doubling the delay every time.
+func (j *journalWriter) scheduleRetry(err error) {
+	j.retry.Lock()
+	defer j.retry.Unlock()
+	now := time.Now()
+	if now.Before(j.retry.at) {
+		// Another attempt failed concurrently and scheduled the retry.
+		return
+	}
+	if j.retry.delay == 0 {
+		j.retry.delay = j.backoff
+	} else {
+		j.retry.delay = min(2*j.retry.delay, maxReconnectBackoff)
+	}
+	j.retry.at = now.Add(j.retry.delay)
+	j.retry.err = err
+}
+
+// transmit sends a single entry to the journal. Its diagnostics are passed
+// to notify to be run.
+func (j *journalWriter) transmit(p []byte, notify func(func())) error {
 	// NOTE: No mutex needed. datagram socket writes are atomic
-	n, err = j.conn.WriteToUnix(p, j.addr)
-	// fail silently if the journal is not available
-	if err == nil || errors.Is(err, syscall.ENOENT) {
-		return n, nil
+	conn := j.conn()
+	var err error
+	if j.addr != nil {
+		_, err = conn.WriteToUnix(p, j.addr)
+	} else {
+		_, err = conn.Write(p)
+	}
+	if err == nil {
+		return nil
 	}
 
 	if !errors.Is(err, syscall.ENOBUFS) && !errors.Is(err, syscall.EMSGSIZE) {
-		return n, err
+		return err
 	}
 
 	// Message does not fit in a single datagram, write to a temp file and send the file descriptor
-	file, err := tempFd()
+	file, sealable, err := tempFd()
 	if err != nil {
-		return n, err
+		return err
 	}
 	defer file.Close()
-	if n, err := file.Write(p); err != nil {
-		return n, err
+	if _, err := file.Write(p); err != nil {
+		return err
 	}
-	if err := trySeal(file); err != nil {
-		return n, err
+	if sealable {
+		if err := seal(file); err != nil {
+			return err
+		}
 	}
 	fd := int(file.Fd())
-	if _, _, err := j.conn.WriteMsgUnix([]byte{}, syscall.UnixRights(fd), j.addr); err != nil {
-		return 0, err
+	if _, _, err = conn.WriteMsgUnix([]byte{}, syscall.UnixRights(fd), j.addr); err != nil {
+		return err
+	}
+	notify(func() {
+		j.diagnose(DiagnosticLargeEntry, "entry of "+strconv.Itoa(len(p))+" bytes passed in a file descriptor", nil)
+	})
+	return nil
+}
+
+func (j *journalWriter) sink() string {
+	if j.addr == nil {
+		return "journal socket passed by the caller"
+	}
+	return "journal " + j.path()
+}
+
+// Close closes the sockets of j.
+func (j *journalWriter) Close() error {
+	var errs []error
+	for _, c := range j.conns {
+		errs = append(errs, c.Close())
 	}
-	return n, err
+	return errors.Join(errs...)
 }
 
-var _ io.Writer = &journalWriter{}
+var _ io.WriteCloser = &journalWriter{}
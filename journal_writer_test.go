@@ -1,12 +1,430 @@
+//go:build unix && !(darwin && cgo)
+
 package slogjournal
 
 import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestJournalWriter(t *testing.T) {
-	_, err := newJournalWriter()
+	_, err := newJournalWriter(journalSocket, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCanWriteMessageToSocket(t *testing.T) {
+	tempDir, err := os.MkdirTemp(os.TempDir(), "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := tempDir + "/socket"
+	raddr, err := net.ResolveUnixAddr("unixgram", addr)
 	if err != nil {
 		t.Fatal(err)
 	}
+	conn, err := net.ListenUnixgram("unixgram", raddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	handler, err := NewHandler(&Options{SocketPath: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("NormalSize", func(t *testing.T) {
+		if err := handler.Handle(context.TODO(), slog.Record{Level: slog.LevelInfo, Message: "Hello, World!"}); err != nil {
+			t.Fatal(err)
+		}
+
+		buf := make([]byte, 1024)
+		oob := make([]byte, 1024)
+
+		n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			t.Error("no data read")
+		}
+		if oobn != 0 {
+			t.Error("did not expect oob data")
+		}
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+
+		_ = handler.w.(*journalWriter).conns[0].SetWriteBuffer(1024)
+
+		largeLog := "Hello, World!"
+		for range 1024 {
+			largeLog += "a"
+		}
+
+		if err := handler.Handle(context.TODO(), slog.Record{Level: slog.LevelInfo, Message: largeLog}); err != nil {
+			t.Fatal(err)
+		}
+
+		buf := make([]byte, 1024)
+		oob := make([]byte, 1024)
+
+		_, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+		if err != nil {
+			t.Error(err)
+		}
+
+		ctrl, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			t.Error(err)
+		}
+
+		for _, m := range ctrl {
+			rights, err := syscall.ParseUnixRights(&m)
+			if err != nil {
+				t.Error(err)
+			}
+			for _, fd := range rights {
+				_ = syscall.SetNonblock(int(fd), true)
+				f := os.NewFile(uintptr(fd), "journal")
+				defer f.Close()
+				_, _ = f.Seek(0, 0)
+				buf := make([]byte, 4096)
+				n, err := f.Read(buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n == 0 {
+					t.Error("no data read")
+				}
+			}
+		}
+
+	})
+
+}
+
+func TestWriterShards(t *testing.T) {
+	dir := t.TempDir()
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: dir + "/socket", Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	handler, err := NewHandler(&Options{SocketPath: dir + "/socket", WriterShards: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := handler.w.(*journalWriter).conns
+	if len(conns) != 4 {
+		t.Fatalf("expected 4 sockets, got %d", len(conns))
+	}
+	// Bind the shards so that the entries tell which one they were sent on.
+	for i, c := range conns {
+		raw, err := c.SyscallConn()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = raw.Control(func(fd uintptr) {
+			err = syscall.Bind(int(fd), &syscall.SockaddrUnix{Name: dir + "/shard" + strconv.Itoa(i)})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Read concurrently, since the queue of the socket may be shorter than n.
+	const n = 64
+	type datagram struct {
+		n    string
+		addr string
+	}
+	datagrams := make(chan datagram, n)
+	errs := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 4096)
+		for range n {
+			m, addr, err := conn.ReadFromUnix(buf)
+			if err != nil {
+				errs <- err
+				return
+			}
+			e, err := ParseEntry(buf[:m])
+			if err != nil {
+				errs <- err
+				return
+			}
+			v, _ := e.Get("N")
+			datagrams <- datagram{string(v), addr.Name}
+		}
+	}()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for i := range n {
+		record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+		record.AddAttrs(slog.Int("N", i))
+		if err := handler.Handle(context.TODO(), record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	received := map[string]bool{}
+	shards := map[string]bool{}
+	for range n {
+		select {
+		case d := <-datagrams:
+			received[d.n] = true
+			shards[d.addr] = true
+		case err := <-errs:
+			t.Fatal(err)
+		}
+	}
+	if len(received) != n {
+		t.Errorf("expected %d distinct entries, got %d", n, len(received))
+	}
+	if len(shards) < 2 {
+		t.Errorf("expected entries on more than one shard, got %v", shards)
+	}
+}
+
+// BenchmarkHandleParallel measures the throughput of concurrent logging to a
+// socket that is drained as fast as possible. Run it with e.g. -cpu 64 to
+// compare the shard counts on many cores.
+func BenchmarkHandleParallel(b *testing.B) {
+	addr := &net.UnixAddr{Name: b.TempDir() + "/socket", Net: "unixgram"}
+	conn, err := net.ListenUnixgram("unixgram", addr)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadBuffer(sndBufSize)
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, shards := range []int{1, runtime.GOMAXPROCS(0)} {
+		b.Run("shards="+strconv.Itoa(shards), func(b *testing.B) {
+			handler, err := NewHandler(&Options{SocketPath: addr.Name, WriterShards: shards})
+			if err != nil {
+				b.Fatal(err)
+			}
+			record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+			record.AddAttrs(slog.Int("N", 42), slog.String("KEY", "value"))
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = handler.Handle(context.TODO(), record)
+				}
+			})
+		})
+	}
+}
+
+func TestSocket(t *testing.T) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sock := os.NewFile(uintptr(fds[0]), "journal")
+	peer := os.NewFile(uintptr(fds[1]), "peer")
+	defer peer.Close()
+
+	handler, err := NewHandler(&Options{Socket: sock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The handler uses a duplicate of the socket.
+	sock.Close()
+	if handler.w.(*journalWriter).addr != nil {
+		t.Error("expected no address for a connected socket")
+	}
+
+	if err := handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4096)
+	n, err := peer.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := ParseEntry(buf[:n])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, _ := e.Get("MESSAGE"); string(msg) != "Hello, World!" {
+		t.Errorf("expected MESSAGE=Hello, World!, got %q", msg)
+	}
+}
+
+func TestSocketWithFallback(t *testing.T) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sock := os.NewFile(uintptr(fds[0]), "journal")
+	defer sock.Close()
+	peer := os.NewFile(uintptr(fds[1]), "peer")
+	defer peer.Close()
+
+	// The socket at SocketPath does not exist, as without access to /run.
+	handler, err := NewHandler(&Options{Socket: sock, SocketPath: t.TempDir() + "/socket", ConsoleFallback: true, JSONFallback: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := handler.w.(*journalWriter); !ok {
+		t.Fatalf("expected entries to be sent on Socket, got %T", handler.w)
+	}
+	if err := handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4096)
+	n, err := peer.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf[:n], []byte("MESSAGE=Hello, World!\n")) {
+		t.Errorf("expected the entry on Socket, got %q", buf[:n])
+	}
+}
+
+func TestListenFDsSocket(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "journal")
+	if _, err := ListenFDsSocket("journal"); err == nil {
+		t.Error("expected error for descriptors passed to another process")
+	}
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	if _, err := ListenFDsSocket("other"); err == nil {
+		t.Error("expected error for an unknown name")
+	}
+}
+
+func TestConnectionCallbacks(t *testing.T) {
+	raddr := &net.UnixAddr{Name: t.TempDir() + "/socket", Net: "unixgram"}
+	var events []string
+	handler, err := NewHandler(&Options{
+		SocketPath:   raddr.Name,
+		OnConnect:    func(path string) { events = append(events, "connect "+path) },
+		OnDisconnect: func(path string, err error) { events = append(events, "disconnect "+path) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	for range 2 {
+		_ = handler.Handle(context.TODO(), record)
+	}
+	conn, err := net.ListenUnixgram("unixgram", raddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for range 2 {
+		_ = handler.Handle(context.TODO(), record)
+	}
+
+	want := []string{"connect " + raddr.Name, "disconnect " + raddr.Name, "connect " + raddr.Name}
+	if !slices.Equal(events, want) {
+		t.Errorf("expected %q, got %q", want, events)
+	}
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	raddr := &net.UnixAddr{Name: t.TempDir() + "/socket", Net: "unixgram"}
+	var diagnostics []DiagnosticKind
+	handler, err := NewHandler(&Options{SocketPath: raddr.Name, ReconnectBackoff: time.Hour, OnDiagnostic: func(d Diagnostic) {
+		diagnostics = append(diagnostics, d.Kind)
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := handler.w.(*journalWriter)
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+
+	_ = handler.Handle(context.TODO(), record)
+	if !w.down.Load() || w.retry.delay != time.Hour {
+		t.Fatalf("expected a backoff of an hour, got %v", w.retry.delay)
+	}
+	if want := []DiagnosticKind{DiagnosticWriterFailed}; !slices.Equal(diagnostics, want) {
+		t.Errorf("expected diagnostics %v, got %v", want, diagnostics)
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", raddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The entry is not sent before the backoff expires.
+	_ = handler.Handle(context.TODO(), record)
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, _, err := conn.ReadFromUnix(make([]byte, 1024)); err == nil {
+		t.Fatal("expected no entry to be sent during the backoff")
+	}
+
+	w.retry.at = time.Time{}
+	_ = handler.Handle(context.TODO(), record)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadFromUnix(make([]byte, 1024)); err != nil {
+		t.Fatal("expected the entry to be sent after the backoff", err)
+	}
+	if w.down.Load() || w.retry.delay != 0 {
+		t.Error("expected the backoff to be reset")
+	}
+}
+
+func TestFallback(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := NewHandler(&Options{SocketPath: t.TempDir() + "/socket", Fallback: slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(handler).With("KEY", "value").WithGroup("G")
+	logger.Info("Hello, World!", "N", 1)
+	if want := "level=INFO msg=\"Hello, World!\" KEY=value G.N=1\n"; buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+	if handler.stats.dropped.Load() != 0 {
+		t.Error("expected the entry not to be counted as dropped")
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	handler, err := NewHandler(&Options{Namespace: "tenant1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := handler.w.(*journalWriter).addr.Name, "/run/systemd/journal.tenant1/socket"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if s := handler.State(); s.Namespace != "tenant1" {
+		t.Errorf("expected namespace tenant1, got %q", s.Namespace)
+	}
+	if _, err := NewHandler(&Options{Namespace: "../x"}); err == nil {
+		t.Error("expected error for invalid namespace")
+	}
 }
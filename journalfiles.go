@@ -0,0 +1,146 @@
+package slogjournal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// journalDirs are the directories journald stores journal files in, for
+// the volatile and the persistent journal.
+var journalDirs = []string{"/run/log/journal", "/var/log/journal"}
+
+// JournalFile describes a journal file written by journald, as read from
+// its [header].
+//
+// [header]: https://systemd.io/JOURNAL_FILE_FORMAT/#header
+type JournalFile struct {
+	// Path is the path of the file.
+	Path string
+	// Size is the size of the file in bytes.
+	Size int64
+	// MachineID is the machine ID of the host that wrote the file, 32
+	// hexadecimal characters.
+	MachineID string
+	// Entries is the number of entries in the file.
+	Entries uint64
+	// Head and Tail are the times of the first and the last entry in the
+	// file, or the zero time if it has none.
+	Head, Tail time.Time
+	// Archived reports whether journald is done writing the file.
+	Archived bool
+	// Err is the error reading the header of the file, e.g. because it is
+	// not readable by the user or truncated. Only Path and Size are set
+	// then.
+	Err error
+}
+
+// journalHeaderSize is the size of the part of the header of a journal file
+// that ListJournalFiles reads, which every version of journald writes.
+const journalHeaderSize = 208
+
+var journalSignature = []byte("LPKSHHRH")
+
+// ListJournalFiles returns the journal files in dirs and their
+// subdirectories, ordered by path, like journalctl --directory lists them
+// for a copied /var/log/journal. If dirs is empty, it lists the files of
+// the journal of the local system. Directories that do not exist and
+// subdirectories that cannot be read are ignored, and files whose header
+// cannot be read are listed with their Err set.
+func ListJournalFiles(dirs ...string) ([]JournalFile, error) {
+	if len(dirs) == 0 {
+		dirs = journalDirs
+	}
+	var files []JournalFile
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				switch {
+				case path == dir && errors.Is(err, fs.ErrNotExist):
+					return fs.SkipDir
+				case path == dir:
+					return err
+				case d != nil && d.IsDir():
+					return fs.SkipDir
+				}
+			}
+			// Files ending in ~ are those journald renamed as corrupted.
+			if d.IsDir() || !strings.HasSuffix(strings.TrimSuffix(path, "~"), ".journal") {
+				return nil
+			}
+			files = append(files, readJournalFile(path, d))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	slices.SortFunc(files, func(a, b JournalFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return files, nil
+}
+
+// JournalDiskUsage returns the total size of the journal files in dirs, see
+// [ListJournalFiles], like journalctl --disk-usage, including those whose
+// header cannot be read.
+func JournalDiskUsage(dirs ...string) (int64, error) {
+	files, err := ListJournalFiles(dirs...)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, f := range files {
+		size += f.Size
+	}
+	return size, nil
+}
+
+// readJournalFile reads the header of the journal file at path, found as
+// d.
+func readJournalFile(path string, d fs.DirEntry) JournalFile {
+	jf := JournalFile{Path: path}
+	fi, err := d.Info()
+	if err != nil {
+		jf.Err = err
+		return jf
+	}
+	jf.Size = fi.Size()
+	f, err := os.Open(path)
+	if err != nil {
+		jf.Err = err
+		return jf
+	}
+	defer f.Close()
+	var h [journalHeaderSize]byte
+	if _, err := io.ReadFull(f, h[:]); err != nil || !bytes.Equal(h[:8], journalSignature) {
+		jf.Err = fmt.Errorf("%s: not a journal file", path)
+		return jf
+	}
+	le := binary.LittleEndian
+	jf.MachineID = hex.EncodeToString(h[40:56])
+	jf.Entries = le.Uint64(h[152:])
+	jf.Head = fromRealtime(le.Uint64(h[184:]))
+	jf.Tail = fromRealtime(le.Uint64(h[192:]))
+	// STATE_ARCHIVED
+	jf.Archived = h[16] == 2
+	return jf
+}
+
+// fromRealtime returns the time of a realtime timestamp in microseconds, or
+// the zero time for 0.
+func fromRealtime(usec uint64) time.Time {
+	if usec == 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(int64(usec))
+}
@@ -0,0 +1,84 @@
+package slogjournal
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeJournalHeader writes a journal file at path with the header fields
+// ListJournalFiles reads, padded to size bytes.
+func writeJournalHeader(t *testing.T, path string, entries uint64, head, tail time.Time, size int) {
+	t.Helper()
+	b := make([]byte, size)
+	copy(b, journalSignature)
+	b[16] = 2
+	copy(b[40:], []byte{0x0d, 0x3a, 0x0b, 0xc3, 0xa4, 0xb0, 0x4b, 0xdc, 0xb8, 0xa6, 0xb1, 0xf1, 0xc0, 0xb2, 0x4e, 0x8d})
+	binary.LittleEndian.PutUint64(b[152:], entries)
+	binary.LittleEndian.PutUint64(b[184:], uint64(head.UnixMicro()))
+	binary.LittleEndian.PutUint64(b[192:], uint64(tail.UnixMicro()))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b, 0o640); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListJournalFiles(t *testing.T) {
+	dir := t.TempDir()
+	head := time.UnixMicro(1700000000000000)
+	tail := head.Add(time.Hour)
+	machine := filepath.Join(dir, "0d3a0bc3a4b04bdcb8a6b1f1c0b24e8d")
+	writeJournalHeader(t, filepath.Join(machine, "system.journal"), 42, head, tail, 4096)
+	writeJournalHeader(t, filepath.Join(machine, "user-1000.journal~"), 0, time.UnixMicro(0), time.UnixMicro(0), 1024)
+	if err := os.WriteFile(filepath.Join(machine, "README"), []byte("not a journal"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ListJournalFiles(dir, filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 journal files, got %v", files)
+	}
+	f := files[0]
+	if f.Path != filepath.Join(machine, "system.journal") || f.Size != 4096 || f.Entries != 42 || !f.Archived {
+		t.Errorf("unexpected journal file %+v", f)
+	}
+	if f.MachineID != "0d3a0bc3a4b04bdcb8a6b1f1c0b24e8d" {
+		t.Errorf("expected the machine ID of the header, got %s", f.MachineID)
+	}
+	if !f.Head.Equal(head) || !f.Tail.Equal(tail) {
+		t.Errorf("expected entries from %v to %v, got %v to %v", head, tail, f.Head, f.Tail)
+	}
+	if !files[1].Head.IsZero() {
+		t.Errorf("expected no head time for a file without entries, got %v", files[1].Head)
+	}
+
+	size, err := JournalDiskUsage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 4096+1024 {
+		t.Errorf("expected a disk usage of %d bytes, got %d", 4096+1024, size)
+	}
+
+	// Files that cannot be read are listed with their error and counted.
+	if err := os.WriteFile(filepath.Join(machine, "bad.journal"), []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files, err = ListJournalFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 || files[0].Err == nil || files[0].Size != 7 || files[1].Err != nil {
+		t.Errorf("expected the error of bad.journal, got %+v", files)
+	}
+	if size, err := JournalDiskUsage(dir); err != nil || size != 4096+1024+7 {
+		t.Errorf("expected a disk usage of %d bytes, got %d, %v", 4096+1024+7, size, err)
+	}
+}
@@ -0,0 +1,160 @@
+// Package journaltest helps testing code that logs to the journal. A
+// [Recorder] captures the entries of a handler instead of sending them to
+// journald, and the assertion helpers check their fields:
+//
+//	func TestLogin(t *testing.T) {
+//		h, rec := journaltest.NewHandler(t, nil)
+//		login(slog.New(h), "alice")
+//		e := rec.Last(t)
+//		journaltest.FieldEquals(t, e, "USER", "alice")
+//		journaltest.HasField(t, e, "MESSAGE_ID")
+//	}
+package journaltest
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// Entry is a journal entry, as parsed by [slogjournal.ParseEntry].
+type Entry = slogjournal.Entry
+
+// Field is a single field of an Entry.
+type Field = slogjournal.Field
+
+// Recorder is an io.Writer that keeps the entries written to it, each in
+// the native protocol format in a single call of Write, as by handlers
+// created with [slogjournal.NewHandlerWithWriter]. It is safe for
+// concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Write parses p as an entry and keeps it. It fails if p is malformed.
+func (r *Recorder) Write(p []byte) (int, error) {
+	// Handlers reuse p once Write returns.
+	e, err := slogjournal.ParseEntry(bytes.Clone(p))
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+	return len(p), nil
+}
+
+// Entries returns the entries written so far, oldest first.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// Last returns the entry written last. It fails t if there is none.
+func (r *Recorder) Last(t testing.TB) Entry {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) == 0 {
+		t.Fatal("no journal entries written")
+	}
+	return r.entries[len(r.entries)-1]
+}
+
+// Reset forgets the entries written so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// NewHandler returns a handler with opts that writes its entries to the
+// returned Recorder. It fails t if the handler cannot be created, and
+// closes the handler when the test ends.
+func NewHandler(t testing.TB, opts *slogjournal.Options) (*slogjournal.Handler, *Recorder) {
+	t.Helper()
+	rec := &Recorder{}
+	h, err := slogjournal.NewHandlerWithWriter(rec, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = h.Close() })
+	return h, rec
+}
+
+// ReadEntry reads a single entry in the native protocol format from r, up
+// to the end of r, e.g. a datagram received on a socket standing in for
+// journald.
+func ReadEntry(r io.Reader) (Entry, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return slogjournal.ParseEntry(b)
+}
+
+// ReadExport reads entries in the journal export format from r, e.g. the
+// output of journalctl -o export.
+func ReadExport(r io.Reader) ([]Entry, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return slogjournal.ParseExport(b)
+}
+
+// HasField reports whether e has a field named name, and fails t if not.
+func HasField(t testing.TB, e Entry, name string) bool {
+	t.Helper()
+	if _, ok := e.Get(name); !ok {
+		t.Errorf("expected a %s field in %s", name, fieldNames(e))
+		return false
+	}
+	return true
+}
+
+// NoField reports whether e has no field named name, and fails t if it
+// has.
+func NoField(t testing.TB, e Entry, name string) bool {
+	t.Helper()
+	if v, ok := e.Get(name); ok {
+		t.Errorf("expected no %s field, got %s=%q", name, name, v)
+		return false
+	}
+	return true
+}
+
+// FieldEquals reports whether the first field of e named name has the
+// value want, and fails t if not.
+func FieldEquals(t testing.TB, e Entry, name, want string) bool {
+	t.Helper()
+	v, ok := e.Get(name)
+	if !ok {
+		t.Errorf("expected %s=%q, got no %s field in %s", name, want, name, fieldNames(e))
+		return false
+	}
+	if string(v) != want {
+		t.Errorf("expected %s=%q, got %q", name, want, v)
+		return false
+	}
+	return true
+}
+
+// fieldNames returns the names of the fields of e, for failure messages.
+func fieldNames(e Entry) string {
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for i, f := range e {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(f.Name)
+	}
+	b.WriteByte(']')
+	return b.String()
+}
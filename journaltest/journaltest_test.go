@@ -0,0 +1,77 @@
+package journaltest
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// failureRecorder records the failures of assertions instead of failing
+// the test.
+type failureRecorder struct {
+	testing.TB
+	failures []string
+}
+
+func (f *failureRecorder) Helper() {}
+
+func (f *failureRecorder) Errorf(format string, args ...any) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	h, rec := NewHandler(t, nil)
+	logger := slog.New(h)
+	logger.Info("first", "USER", "alice")
+	logger.Warn("second", "PAYLOAD", []byte("a\nb"))
+	if n := len(rec.Entries()); n != 2 {
+		t.Fatalf("expected 2 entries, got %d", n)
+	}
+	FieldEquals(t, rec.Entries()[0], "USER", "alice")
+	e := rec.Last(t)
+	FieldEquals(t, e, "MESSAGE", "second")
+	FieldEquals(t, e, "PAYLOAD", "a\nb")
+	HasField(t, e, "PRIORITY")
+	NoField(t, e, "USER")
+
+	rec.Reset()
+	if n := len(rec.Entries()); n != 0 {
+		t.Errorf("expected no entries after Reset, got %d", n)
+	}
+}
+
+func TestAssertionFailures(t *testing.T) {
+	e := Entry{{Name: "MESSAGE", Value: []byte("Hello")}}
+	f := &failureRecorder{TB: t}
+	if HasField(f, e, "USER") || NoField(f, e, "MESSAGE") || FieldEquals(f, e, "MESSAGE", "Bye") || FieldEquals(f, e, "USER", "alice") {
+		t.Error("expected the assertions to fail")
+	}
+	if len(f.failures) != 4 {
+		t.Fatalf("expected 4 failures, got %q", f.failures)
+	}
+	if want := "expected a USER field in [MESSAGE]"; f.failures[0] != want {
+		t.Errorf("expected %q, got %q", want, f.failures[0])
+	}
+}
+
+func TestRead(t *testing.T) {
+	native := slogjournal.Entry{{Name: "MESSAGE", Value: []byte("a\nb")}, {Name: "PRIORITY", Value: []byte("6")}}.AppendNative(nil)
+	e, err := ReadEntry(bytes.NewReader(native))
+	if err != nil {
+		t.Fatal(err)
+	}
+	FieldEquals(t, e, "MESSAGE", "a\nb")
+
+	entries, err := ReadExport(strings.NewReader("MESSAGE=first\n\nMESSAGE=second\nPRIORITY=4\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	FieldEquals(t, entries[1], "PRIORITY", "4")
+}
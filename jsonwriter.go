@@ -0,0 +1,108 @@
+package slogjournal
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// jsonWriter writes entries as JSON objects, one per line, in the shape of
+// journalctl -o json: field values are strings, binary values are arrays of
+// numbers and fields occurring more than once are arrays of their values.
+// Each object also gets a __REALTIME_TIMESTAMP field with the time it was
+// written, as journald would have added it.
+type jsonWriter struct {
+	w io.Writer
+}
+
+// Write writes a single entry in the native protocol format as a JSON object.
+func (w *jsonWriter) Write(p []byte) (int, error) {
+	fields, err := ParseEntry(p)
+	if err != nil {
+		return 0, err
+	}
+	now := strconv.AppendInt(nil, time.Now().UnixMicro(), 10)
+	b, err := appendJSONEntry(nil, append(Entry{{"__REALTIME_TIMESTAMP", now}}, fields...))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.w.Write(append(b, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// appendJSONEntry appends fields as a JSON object in the shape of
+// journalctl -o json.
+func appendJSONEntry(b []byte, fields Entry) ([]byte, error) {
+	var names []string
+	values := make(map[string][]any, len(fields))
+	for _, f := range fields {
+		if _, ok := values[f.Name]; !ok {
+			names = append(names, f.Name)
+		}
+		values[f.Name] = append(values[f.Name], jsonValue(f.Value))
+	}
+	b = append(b, '{')
+	for i, name := range names {
+		var v any = values[name]
+		if len(values[name]) == 1 {
+			v = values[name][0]
+		}
+		nb, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		vb, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, nb...)
+		b = append(b, ':')
+		b = append(b, vb...)
+	}
+	return append(b, '}'), nil
+}
+
+// jsonValue returns v as a string, or as an array of numbers if it is not
+// printable UTF-8, like journalctl does.
+func jsonValue(v []byte) any {
+	if !utf8.Valid(v) {
+		return jsonBytes(v)
+	}
+	for _, c := range v {
+		if c < ' ' && c != '\n' && c != '\t' {
+			return jsonBytes(v)
+		}
+	}
+	return string(v)
+}
+
+func jsonBytes(v []byte) []int {
+	a := make([]int, len(v))
+	for i, c := range v {
+		a[i] = int(c)
+	}
+	return a
+}
+
+// inContainer reports whether the process runs in a container.
+func inContainer() bool {
+	if os.Getenv("container") != "" || os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return true
+	}
+	for _, name := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+var _ io.Writer = &jsonWriter{}
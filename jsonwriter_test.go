@@ -0,0 +1,54 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestJSONWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = &jsonWriter{w: buf}
+
+	record := slog.NewRecord(time.Now(), slog.LevelWarn, "Hello, World!", 0)
+	record.AddAttrs(
+		slog.String("KEY", "line 1\nline 2"),
+		slog.String("BINARY", "\x00\x01"),
+		slog.String("REPEATED", "a"),
+		slog.String("REPEATED", "b"),
+	)
+	if err := handler.Handle(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Bytes()[buf.Len()-1] != '\n' {
+		t.Error("expected trailing newline")
+	}
+	for k, want := range map[string]any{
+		"MESSAGE":  "Hello, World!",
+		"PRIORITY": "4",
+		"KEY":      "line 1\nline 2",
+		"BINARY":   []any{0.0, 1.0},
+		"REPEATED": []any{"a", "b"},
+	} {
+		got, _ := json.Marshal(m[k])
+		wantb, _ := json.Marshal(want)
+		if !bytes.Equal(got, wantb) {
+			t.Errorf("expected %s=%s, got %s", k, wantb, got)
+		}
+	}
+	if _, ok := m["__REALTIME_TIMESTAMP"].(string); !ok {
+		t.Error("expected __REALTIME_TIMESTAMP", m)
+	}
+}
@@ -0,0 +1,40 @@
+package slogjournal
+
+import "log/slog"
+
+// Key is the name of a journal field whose values have type T. Declaring
+// the fields of a program as Keys catches misspelled field names and values
+// of the wrong type at compile time:
+//
+//	const KeyUser slogjournal.Key[string] = "USER"
+//
+//	logger.Info("login", KeyUser.Attr(name), slogjournal.KeyErrno.Attr(int(errno)))
+//
+// Attrs passed to the Logger methods as Attr values, rather than as
+// alternating keys and values, are also checked by go vet.
+type Key[T any] string
+
+// Attr returns an Attr for the field k with value v.
+func (k Key[T]) Attr(v T) slog.Attr {
+	return slog.Attr{Key: string(k), Value: slog.AnyValue(v)}
+}
+
+// Well-known fields of systemd.journal-fields(7) that programs may set.
+const (
+	// KeyErrno is the low-level Unix error number that caused the entry.
+	KeyErrno Key[int] = "ERRNO"
+	// KeyDocumentation is a URL of documentation about the entry.
+	KeyDocumentation Key[string] = "DOCUMENTATION"
+	// KeyMessageID identifies the kind of the entry, see [MessageIDKey].
+	KeyMessageID Key[string] = MessageIDKey
+	// KeySyslogFacility is the syslog facility of the entry.
+	KeySyslogFacility Key[int] = "SYSLOG_FACILITY"
+	// KeyTID is the ID of the thread that logged the entry.
+	KeyTID Key[int] = "TID"
+	// KeyUnit is the system unit the entry is about.
+	KeyUnit Key[string] = "UNIT"
+	// KeyUserUnit is the user unit the entry is about.
+	KeyUserUnit Key[string] = "USER_UNIT"
+	// KeyRequestID is the request ID of the entry, see [WithRequestID].
+	KeyRequestID Key[string] = RequestIDKey
+)
@@ -0,0 +1,32 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestKey(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	const keyAttempts Key[uint64] = "ATTEMPTS"
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	record.AddAttrs(KeyErrno.Attr(2), KeyUnit.Attr("app.service"), keyAttempts.Attr(3))
+	_ = handler.Handle(context.TODO(), record)
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{"ERRNO": "2", "UNIT": "app.service", "ATTEMPTS": "3"} {
+		if kv[k] != v {
+			t.Errorf("expected %s=%s, got %q", k, v, kv[k])
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package slogjournal
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// lazyWriter creates the writer of a handler returned by NewLazyHandler on
+// the first write.
+type lazyWriter struct {
+	create func() (io.Writer, error)
+	// err is a permanent failure of NewLazyHandler.
+	err error
+	// diagnose reports failures to create the writer.
+	diagnose func(DiagnosticKind, string, error)
+
+	mu sync.Mutex
+	w  atomic.Pointer[io.Writer]
+}
+
+// writer returns the underlying writer, creating it if needed.
+func (l *lazyWriter) writer() (io.Writer, error) {
+	if w := l.w.Load(); w != nil {
+		return *w, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if w := l.w.Load(); w != nil {
+		return *w, nil
+	}
+	if l.err != nil {
+		return nil, l.err
+	}
+	w, err := l.create()
+	if err != nil {
+		l.diagnose(DiagnosticWriterFailed, "connecting to the journal failed", err)
+		return nil, err
+	}
+	l.w.Store(&w)
+	return w, nil
+}
+
+func (l *lazyWriter) Write(p []byte) (int, error) {
+	w, err := l.writer()
+	if err != nil {
+		return 0, err
+	}
+	return w.Write(p)
+}
+
+// WriteGroups implements groupWriter if the underlying writer does.
+func (l *lazyWriter) WriteGroups(p []byte, groups []string) (int, error) {
+	w, err := l.writer()
+	if err != nil {
+		return 0, err
+	}
+	if gw, ok := w.(groupWriter); ok {
+		return gw.WriteGroups(p, groups)
+	}
+	return w.Write(p)
+}
+
+// Close closes the underlying writer if it was created.
+func (l *lazyWriter) Close() error {
+	if w := l.w.Load(); w != nil {
+		if c, ok := (*w).(io.Closer); ok {
+			return c.Close()
+		}
+	}
+	return nil
+}
+
+var _ groupWriter = &lazyWriter{}
@@ -0,0 +1,80 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLazyHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	created := 0
+	fail := true
+	handler := NewLazyHandler(nil)
+	handler.w.(*lazyWriter).create = func() (io.Writer, error) {
+		created++
+		if fail {
+			return nil, errors.New("no socket")
+		}
+		return buf, nil
+	}
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	if err := handler.Handle(context.TODO(), r); err == nil {
+		t.Error("expected error from failed writer creation")
+	}
+	fail = false
+	for range 2 {
+		if err := handler.Handle(context.TODO(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if created != 2 {
+		t.Errorf("expected writer to be created after one retry, got %d attempts", created)
+	}
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["MESSAGE"] != "Hello, World!" {
+		t.Errorf("unexpected entry %v", kv)
+	}
+}
+
+func TestLazyHandlerCredentials(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, CredentialLevel), []byte("verbose"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+	if _, err := NewHandler(&Options{Credentials: true}); err == nil {
+		t.Fatal("expected NewHandler to fail")
+	}
+	handler := NewLazyHandler(&Options{Credentials: true})
+	if err := handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)); err == nil {
+		t.Error("expected Handle to report the credential error")
+	}
+}
+
+func TestLazyHandlerDefaultMessageID(t *testing.T) {
+	handler := NewLazyHandler(&Options{DefaultMessageID: "startup"})
+	if handler == nil {
+		t.Fatal("expected a handler")
+	}
+	err := handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+	if err == nil || !strings.Contains(err.Error(), "startup") {
+		t.Errorf("expected Handle to report the invalid default MESSAGE_ID, got %v", err)
+	}
+}
+
+func TestMustNewHandler(t *testing.T) {
+	if MustNewHandler(nil) == nil {
+		t.Error("expected handler")
+	}
+}
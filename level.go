@@ -0,0 +1,152 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Priority is a syslog priority as carried by the PRIORITY field.
+type Priority int
+
+// Priorities in decreasing order of severity.
+const (
+	PriorityEmergency Priority = iota
+	PriorityAlert
+	PriorityCritical
+	PriorityError
+	PriorityWarning
+	PriorityNotice
+	PriorityInfo
+	PriorityDebug
+)
+
+type levelInfo struct {
+	name string
+	pri  Priority
+}
+
+var levels = struct {
+	sync.RWMutex
+	m map[slog.Level]levelInfo
+}{
+	m: map[slog.Level]levelInfo{
+		LevelTrace: {"TRACE", PriorityDebug},
+	},
+}
+
+// RegisterLevel registers a custom level with a name and a priority.
+// Records at a registered level get a PRIORITY field of pri and a LEVEL field of name,
+// and [LevelName] and [ReplaceLevel] report name for the level.
+// [LevelTrace] is registered as "TRACE" with PriorityDebug.
+//
+// RegisterLevel is meant to be called during initialization. Registering a level again replaces
+// the previous registration.
+func RegisterLevel(level slog.Level, name string, pri Priority) {
+	levels.Lock()
+	defer levels.Unlock()
+	levels.m[level] = levelInfo{name, pri}
+}
+
+// unregisterLevel undoes [RegisterLevel] for level.
+func unregisterLevel(level slog.Level) {
+	levels.Lock()
+	defer levels.Unlock()
+	delete(levels.m, level)
+}
+
+func registeredLevel(l slog.Level) (levelInfo, bool) {
+	levels.RLock()
+	defer levels.RUnlock()
+	rl, ok := levels.m[l]
+	return rl, ok
+}
+
+// priorityToLevel returns the level corresponding to pri.
+func priorityToLevel(pri Priority) slog.Level {
+	switch pri {
+	case PriorityEmergency:
+		return LevelEmergency
+	case PriorityAlert:
+		return LevelAlert
+	case PriorityCritical:
+		return LevelCritical
+	case PriorityError:
+		return slog.LevelError
+	case PriorityWarning:
+		return slog.LevelWarn
+	case PriorityNotice:
+		return LevelNotice
+	case PriorityDebug:
+		return slog.LevelDebug
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// LevelName returns the name of l. Registered levels use their registered name,
+// the levels defined by this package use their syslog names, e.g. "NOTICE", and
+// all other levels are named by [slog.Level.String].
+func LevelName(l slog.Level) string {
+	if rl, ok := registeredLevel(l); ok {
+		return rl.name
+	}
+	switch l {
+	case LevelNotice:
+		return "NOTICE"
+	case LevelCritical:
+		return "CRITICAL"
+	case LevelAlert:
+		return "ALERT"
+	case LevelEmergency:
+		return "EMERGENCY"
+	default:
+		return l.String()
+	}
+}
+
+// ReplaceLevel can be used as [slog.HandlerOptions.ReplaceAttr] so that other
+// handlers, such as a console handler next to the journal, name levels the
+// same way as this package.
+func ReplaceLevel(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.LevelKey {
+		if l, ok := a.Value.Any().(slog.Level); ok {
+			a.Value = slog.StringValue(LevelName(l))
+		}
+	}
+	return a
+}
+
+// ParseLevel parses a level by its name as reported by [LevelName], its syslog
+// priority name or number, or its slog representation such as "INFO+2",
+// ignoring case.
+func ParseLevel(s string) (slog.Level, error) {
+	if len(s) == 1 && s[0] >= '0' && s[0] <= '7' {
+		return priorityToLevel(Priority(s[0] - '0')), nil
+	}
+	switch strings.ToUpper(s) {
+	case "EMERG", "EMERGENCY":
+		return LevelEmergency, nil
+	case "ALERT":
+		return LevelAlert, nil
+	case "CRIT", "CRITICAL":
+		return LevelCritical, nil
+	case "ERR":
+		return slog.LevelError, nil
+	case "WARNING":
+		return slog.LevelWarn, nil
+	case "NOTICE":
+		return LevelNotice, nil
+	}
+	levels.RLock()
+	for l, rl := range levels.m {
+		if strings.EqualFold(rl.name, s) {
+			levels.RUnlock()
+			return l, nil
+		}
+	}
+	levels.RUnlock()
+	var l slog.Level
+	err := l.UnmarshalText([]byte(s))
+	return l, err
+}
@@ -0,0 +1,143 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterLevel(t *testing.T) {
+	const levelAudit = slog.LevelInfo + 2
+	RegisterLevel(levelAudit, "AUDIT", PriorityNotice)
+	t.Cleanup(func() { unregisterLevel(levelAudit) })
+
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), levelAudit, "Hello, World!", 0))
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["PRIORITY"] != "5" || kv["LEVEL"] != "AUDIT" {
+		t.Error("expected PRIORITY=5 and LEVEL=AUDIT", kv)
+	}
+
+	if name := LevelName(levelAudit); name != "AUDIT" {
+		t.Error("expected AUDIT, got", name)
+	}
+	if name := LevelName(LevelCritical); name != "CRITICAL" {
+		t.Error("expected CRITICAL, got", name)
+	}
+
+	var out strings.Builder
+	slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{ReplaceAttr: ReplaceLevel})).Log(context.TODO(), levelAudit, "Hello, World!")
+	if !strings.Contains(out.String(), "level=AUDIT") {
+		t.Error("expected level=AUDIT, got", out.String())
+	}
+}
+
+func TestLevelToPriorityOption(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{
+		Level: slog.Level(-10),
+		LevelToPriority: func(l slog.Level) Priority {
+			if l < slog.LevelInfo {
+				return PriorityDebug
+			}
+			return LevelToPriority(l)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	for level, want := range map[slog.Level]string{slog.Level(-8): "7", slog.LevelWarn: "4"} {
+		buf.Reset()
+		_ = handler.Handle(context.TODO(), slog.NewRecord(time.Now(), level, "Hello, World!", 0))
+		kv, err := deserializeKeyValue(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kv["PRIORITY"] != want {
+			t.Errorf("level %v: expected PRIORITY=%s, got %q", level, want, kv["PRIORITY"])
+		}
+	}
+}
+
+func TestLevelToPriority(t *testing.T) {
+	for level, want := range map[slog.Level]Priority{
+		slog.LevelDebug - 4:  PriorityDebug,
+		slog.LevelDebug:      PriorityDebug,
+		slog.LevelInfo - 1:   PriorityDebug,
+		slog.LevelInfo:       PriorityInfo,
+		LevelNotice:          PriorityNotice,
+		slog.LevelInfo + 2:   PriorityNotice,
+		slog.LevelWarn:       PriorityWarning,
+		slog.LevelWarn + 1:   PriorityWarning,
+		slog.LevelError:      PriorityError,
+		LevelCritical:        PriorityCritical,
+		LevelAlert:           PriorityAlert,
+		LevelEmergency:       PriorityEmergency,
+		LevelEmergency + 100: PriorityEmergency,
+	} {
+		if got := LevelToPriority(level); got != want {
+			t.Errorf("LevelToPriority(%v) = %d, want %d", level, got, want)
+		}
+	}
+}
+
+// testSignal is an os.Signal for tests.
+type testSignal string
+
+func (s testSignal) String() string { return string(s) }
+func (testSignal) Signal()          {}
+
+func TestWatchLevelSignals(t *testing.T) {
+	handler, err := NewHandler(&Options{Level: slog.LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.WatchLevelSignals(context.Background()); err == nil {
+		t.Error("expected a constant level to be rejected")
+	}
+
+	var v slog.LevelVar
+	v.Set(slog.LevelWarn)
+	c := make(chan os.Signal)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watchLevelSignals(ctx, c, testSignal("raise"), &v)
+		close(done)
+	}()
+	for _, tt := range []struct {
+		sig  testSignal
+		want slog.Level
+	}{
+		{"restore", slog.LevelWarn},
+		{"raise", slog.LevelDebug},
+		{"raise", slog.LevelDebug},
+		{"restore", slog.LevelWarn},
+	} {
+		c <- tt.sig
+		deadline := time.Now().Add(time.Second)
+		for v.Level() != tt.want && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := v.Level(); got != tt.want {
+			t.Errorf("after %s: expected level %v, got %v", tt.sig, tt.want, got)
+		}
+	}
+	cancel()
+	<-done
+}
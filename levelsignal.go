@@ -0,0 +1,60 @@
+package slogjournal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+)
+
+// WatchLevelSignals lets operators debug a running service without
+// restarting it, like many systemd daemons: on SIGUSR1, the level of h is
+// lowered to slog.LevelDebug, and on SIGUSR2, it is restored to the level
+// it had before, e.g. with systemctl kill -s SIGUSR1 foo.service. It
+// watches the signals until ctx is done.
+//
+// The level of h must be a variable, a [LevelVar] or a slog.LevelVar, which
+// is the default. The handlers derived from h with WithAttrs and WithGroup
+// share it, except those with a level of their own in
+// Options.GroupOverrides. WatchLevelSignals returns ErrNotSupported on
+// systems without these signals, such as Windows.
+func (h *Handler) WatchLevelSignals(ctx context.Context) error {
+	if h.levelVar == nil {
+		return errors.New("level of the handler is not a variable")
+	}
+	raise, restore := levelSignals()
+	if raise == nil {
+		return ErrNotSupported
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, raise, restore)
+	go func() {
+		defer signal.Stop(c)
+		watchLevelSignals(ctx, c, raise, h.levelVar)
+	}()
+	return nil
+}
+
+// watchLevelSignals lowers v to slog.LevelDebug on the signal raise and
+// restores it on any other signal received on c, until ctx is done.
+func watchLevelSignals(ctx context.Context, c <-chan os.Signal, raise os.Signal, v *slog.LevelVar) {
+	var saved slog.Level
+	raised := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-c:
+			switch {
+			case sig == raise && !raised:
+				saved = v.Level()
+				raised = true
+				v.Set(min(saved, slog.LevelDebug))
+			case sig != raise && raised:
+				v.Set(saved)
+				raised = false
+			}
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package slogjournal
+
+import "os"
+
+// levelSignals returns nil, as there are no signals to raise and restore
+// the level with outside of Unix.
+func levelSignals() (raise, restore os.Signal) {
+	return nil, nil
+}
@@ -0,0 +1,13 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"os"
+	"syscall"
+)
+
+// levelSignals returns the signals that raise and restore the level.
+func levelSignals() (raise, restore os.Signal) {
+	return syscall.SIGUSR1, syscall.SIGUSR2
+}
@@ -0,0 +1,35 @@
+package slogjournal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation,
+// SD_LISTEN_FDS_START.
+const listenFDsStart = 3
+
+// ListenFDsSocket returns the file descriptor named name that was passed to
+// the process by systemd socket activation or a compatible parent, as
+// described in sd_listen_fds(3): $LISTEN_PID must be the process ID and
+// $LISTEN_FDNAMES must name the descriptors counted by $LISTEN_FDS. Pass the
+// result as Options.Socket.
+func ListenFDsSocket(name string) (*os.File, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, errors.New("no file descriptors passed to this process")
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, errors.New("no file descriptors passed to this process")
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < n && i < len(names); i++ {
+		if names[i] == name {
+			return os.NewFile(uintptr(listenFDsStart+i), name), nil
+		}
+	}
+	return nil, fmt.Errorf("no file descriptor named %q passed to this process", name)
+}
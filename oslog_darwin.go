@@ -0,0 +1,115 @@
+//go:build cgo
+
+package slogjournal
+
+/*
+#include <os/log.h>
+#include <stdlib.h>
+
+static os_log_t slogjournal_os_log_create(const char *subsystem, const char *category) {
+	return os_log_create(subsystem, category);
+}
+
+static void slogjournal_os_log(os_log_t log, os_log_type_t type, const char *msg) {
+	os_log_with_type(log, type, "%{public}s", msg);
+}
+*/
+import "C"
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// osLogWriter writes entries to the unified logging system. The MESSAGE
+// field is followed by all other fields as NAME=VALUE pairs, as unified
+// logging has no notion of structured fields.
+type osLogWriter struct {
+	subsystem *C.char
+	// logs maps categories to their os_log_t. They are never released, as
+	// there are only as many as there are distinct group paths.
+	logs sync.Map
+}
+
+// newWriter returns the writer for the unified logging system.
+func (h *Handler) newWriter() (io.Writer, error) {
+	return &osLogWriter{subsystem: C.CString(string(h.identifier))}, nil
+}
+
+func (w *osLogWriter) sink() string {
+	return "unified logging"
+}
+
+// Write writes a single entry in the native protocol format.
+func (w *osLogWriter) Write(p []byte) (int, error) {
+	return w.WriteGroups(p, nil)
+}
+
+// WriteGroups writes a single entry in the native protocol format with the
+// groups as its category.
+func (w *osLogWriter) WriteGroups(p []byte, groups []string) (int, error) {
+	fields, err := ParseEntry(p)
+	if err != nil {
+		return 0, err
+	}
+	var msg, rest strings.Builder
+	typ := C.os_log_type_t(C.OS_LOG_TYPE_DEFAULT)
+	for _, f := range fields {
+		switch f.Name {
+		case "MESSAGE":
+			msg.Write(f.Value)
+			continue
+		case "PRIORITY":
+			typ = priorityToOSLogType(f.Value)
+		}
+		rest.WriteByte(' ')
+		rest.WriteString(f.Name)
+		rest.WriteByte('=')
+		if v := string(f.Value); strings.ContainsAny(v, " \t\n\"") {
+			rest.WriteString(strconv.Quote(v))
+		} else {
+			rest.WriteString(v)
+		}
+	}
+	msg.WriteString(rest.String())
+
+	cmsg := C.CString(msg.String())
+	defer C.free(unsafe.Pointer(cmsg))
+	C.slogjournal_os_log(w.log(strings.Join(groups, ".")), typ, cmsg)
+	return len(p), nil
+}
+
+// log returns the os_log_t of category.
+func (w *osLogWriter) log(category string) C.os_log_t {
+	if l, ok := w.logs.Load(category); ok {
+		return l.(C.os_log_t)
+	}
+	ccategory := C.CString(category)
+	defer C.free(unsafe.Pointer(ccategory))
+	l, _ := w.logs.LoadOrStore(category, C.slogjournal_os_log_create(w.subsystem, ccategory))
+	return l.(C.os_log_t)
+}
+
+// priorityToOSLogType maps a PRIORITY value to an os_log_type_t.
+func priorityToOSLogType(v []byte) C.os_log_type_t {
+	pri, err := strconv.Atoi(string(v))
+	switch {
+	case err != nil:
+		return C.os_log_type_t(C.OS_LOG_TYPE_DEFAULT)
+	case Priority(pri) <= PriorityCritical:
+		return C.os_log_type_t(C.OS_LOG_TYPE_FAULT)
+	case Priority(pri) == PriorityError:
+		return C.os_log_type_t(C.OS_LOG_TYPE_ERROR)
+	case Priority(pri) == PriorityInfo:
+		return C.os_log_type_t(C.OS_LOG_TYPE_INFO)
+	case Priority(pri) == PriorityDebug:
+		return C.os_log_type_t(C.OS_LOG_TYPE_DEBUG)
+	default:
+		return C.os_log_type_t(C.OS_LOG_TYPE_DEFAULT)
+	}
+}
+
+var _ groupWriter = &osLogWriter{}
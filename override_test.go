@@ -0,0 +1,70 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestGroupOverrides(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{
+		Level:            slog.LevelInfo,
+		SyslogIdentifier: "app",
+		GroupOverrides: map[string]GroupOverride{
+			"db": {
+				Level:            slog.LevelWarn,
+				Attrs:            []slog.Attr{slog.String("SUBSYSTEM", "db")},
+				SyslogIdentifier: "app-db",
+			},
+			"http.client": {
+				Level: slog.LevelDebug,
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == "TOKEN" {
+						a.Value = slog.StringValue("REDACTED")
+					}
+					return a
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+	logger := slog.New(handler)
+
+	db := logger.WithGroup("db")
+	if db.Enabled(context.TODO(), slog.LevelInfo) {
+		t.Error("expected LevelInfo to be disabled for db")
+	}
+	db.Warn("slow query", "MS", 500)
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{"SUBSYSTEM": "db", "SYSLOG_IDENTIFIER": "app-db", "db_MS": "500"} {
+		if kv[k] != v {
+			t.Errorf("expected %s=%s, got %q", k, v, kv[k])
+		}
+	}
+
+	buf.Reset()
+	client := logger.WithGroup("http").WithGroup("client")
+	if !client.Enabled(context.TODO(), slog.LevelDebug) {
+		t.Error("expected LevelDebug to be enabled for http.client")
+	}
+	client.Debug("request", "TOKEN", "secret")
+	kv, err = deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["http_client_TOKEN"] != "REDACTED" || kv["SYSLOG_IDENTIFIER"] != "app" {
+		t.Errorf("unexpected entry %v", kv)
+	}
+
+	if logger.WithGroup("http").Enabled(context.TODO(), slog.LevelDebug) {
+		t.Error("expected the override not to apply to the parent group")
+	}
+}
@@ -0,0 +1,58 @@
+package slogjournal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// appendParts appends the value v of the field k, which is longer than
+// Options.FieldPartSize, as the fields k_PART_0 to k_PART_<n-1> followed by
+// k_PARTS=n, with k shortened by partsBase.
+func (h *Handler) appendParts(b []byte, k string, v []byte) []byte {
+	size := h.opts.FieldPartSize
+	k = partsBase(k, (len(v)+size-1)/size)
+	n := 0
+	for ; len(v) > 0; n++ {
+		part := v[:min(size, len(v))]
+		v = v[len(part):]
+		b = h.appendKV(b, k+"_PART_"+strconv.Itoa(n), part)
+	}
+	var num [20]byte
+	return h.appendKV(b, k+"_PARTS", strconv.AppendInt(num[:0], int64(n), 10))
+}
+
+// partsBase returns k, shortened if needed so that the names of its n parts
+// are no longer than 64 characters, which journald requires.
+func partsBase(k string, n int) string {
+	size := maxFieldNameLen - len("_PART_") - len(strconv.Itoa(max(n-1, 0)))
+	return k[:min(len(k), size)]
+}
+
+// GetJoined returns the value of the first field named name like Get, or if
+// there is none, the value of a field split by Options.FieldPartSize, joined
+// from its parts.
+func (e Entry) GetJoined(name string) ([]byte, bool) {
+	if v, ok := e.Get(name); ok {
+		return v, true
+	}
+	for _, f := range e {
+		base, ok := strings.CutSuffix(f.Name, "_PARTS")
+		if !ok || !strings.HasPrefix(name, base) {
+			continue
+		}
+		n, err := strconv.Atoi(string(f.Value))
+		if err != nil || n < 0 || partsBase(name, n) != base {
+			continue
+		}
+		var v []byte
+		for i := range n {
+			part, ok := e.Get(base + "_PART_" + strconv.Itoa(i))
+			if !ok {
+				return nil, false
+			}
+			v = append(v, part...)
+		}
+		return v, true
+	}
+	return nil, false
+}
@@ -0,0 +1,70 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFieldParts(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{FieldPartSize: 4, SourceFields: NoSource, OmitTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "hi", 0)
+	record.AddAttrs(slog.String("BLOB", "0123456789"), slog.String("SHORT", "abcd"))
+	_ = handler.Handle(context.TODO(), record)
+	e, err := ParseEntry(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{"BLOB_PART_0": "0123", "BLOB_PART_1": "4567", "BLOB_PART_2": "89", "BLOB_PARTS": "3", "SHORT": "abcd"} {
+		if got, _ := e.Get(k); string(got) != v {
+			t.Errorf("expected %s=%s, got %q", k, v, got)
+		}
+	}
+	if _, ok := e.Get("BLOB"); ok {
+		t.Error("expected no BLOB field")
+	}
+	if v, ok := e.GetJoined("BLOB"); !ok || string(v) != "0123456789" {
+		t.Errorf("expected joined BLOB, got %q", v)
+	}
+}
+
+func TestFieldPartsLongName(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{FieldPartSize: 2, FieldNames: FieldNamesReject, SourceFields: NoSource, OmitTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	key := strings.Repeat("K", 60)
+	value := strings.Repeat("v", 21)
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "hi", 0)
+	record.AddAttrs(slog.String(key, value))
+	if err := handler.Handle(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+	e, err := ParseEntry(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range e {
+		if len(f.Name) > maxFieldNameLen {
+			t.Errorf("expected field names of at most %d characters, got %s", maxFieldNameLen, f.Name)
+		}
+	}
+	if _, ok := e.Get(key[:56] + "_PART_10"); !ok {
+		t.Errorf("expected the name to be shortened, got %q", e)
+	}
+	if v, ok := e.GetJoined(key); !ok || string(v) != value {
+		t.Errorf("expected joined %s, got %q", key, v)
+	}
+}
@@ -0,0 +1,31 @@
+package slogjournal
+
+import "sync"
+
+// maxPooledBufferSize is the capacity above which entry buffers are not
+// returned to bufferPool, so that an occasional huge entry does not pin a
+// large buffer in the pool.
+const maxPooledBufferSize = 64 * 1024
+
+// bufferPool holds the buffers entries are encoded into.
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+// getBuffer returns an empty buffer from bufferPool.
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+// putBuffer returns b to bufferPool, unless it has grown larger than
+// maxPooledBufferSize.
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBufferSize {
+		return
+	}
+	*b = (*b)[:0]
+	bufferPool.Put(b)
+}
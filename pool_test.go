@@ -0,0 +1,24 @@
+package slogjournal
+
+import (
+	"testing"
+)
+
+func TestPutBufferCap(t *testing.T) {
+	small := make([]byte, 10, 1024)
+	b := &small
+	putBuffer(b)
+	if len(*b) != 0 {
+		t.Errorf("expected pooled buffer to be reset, got length %d", len(*b))
+	}
+
+	large := make([]byte, 0, maxPooledBufferSize+1)
+	for range 10 {
+		putBuffer(&large)
+	}
+	for range 100 {
+		if b := getBuffer(); cap(*b) > maxPooledBufferSize {
+			t.Fatalf("got pooled buffer of capacity %d, more than %d", cap(*b), maxPooledBufferSize)
+		}
+	}
+}
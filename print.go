@@ -0,0 +1,87 @@
+package slogjournal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHandler is the handler of the package-level Print functions.
+var defaultHandler = sync.OnceValues(func() (*Handler, error) {
+	return NewHandler(nil)
+})
+
+// Print logs a message formatted as by fmt.Sprintf with priority pri, like
+// sd_journal_print(3). It is meant for quick scripts and code ported from C;
+// the message gets the same fields as those logged through slog, such as
+// SYSLOG_IDENTIFIER and the source location of the caller of Print.
+func Print(pri Priority, format string, args ...any) error {
+	h, err := defaultHandler()
+	if err != nil {
+		return err
+	}
+	return h.print(pri, fmt.Sprintf(format, args...))
+}
+
+// Send logs an entry with the given fields of the form NAME=VALUE, like
+// sd_journal_send(3):
+//
+//	slogjournal.Send("MESSAGE=disk full", "PRIORITY=3", "MOUNT=/var")
+//
+// The MESSAGE and PRIORITY fields set the message and level of the entry.
+func Send(fields ...string) error {
+	h, err := defaultHandler()
+	if err != nil {
+		return err
+	}
+	return h.send(fields)
+}
+
+// Print logs a message formatted as by fmt.Sprintf with priority pri with h,
+// like the package-level [Print].
+func (h *Handler) Print(pri Priority, format string, args ...any) error {
+	return h.print(pri, fmt.Sprintf(format, args...))
+}
+
+// Send logs an entry with the given fields with h, like the package-level
+// [Send].
+func (h *Handler) Send(fields ...string) error {
+	return h.send(fields)
+}
+
+func (h *Handler) print(pri Priority, msg string) error {
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // skip [Callers, print, Print]
+	r := slog.NewRecord(time.Now(), priorityToLevel(pri), msg, pcs[0])
+	return h.Handle(context.Background(), r)
+}
+
+func (h *Handler) send(fields []string) error {
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // skip [Callers, send, Send]
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "", pcs[0])
+	for _, f := range fields {
+		name, value, ok := strings.Cut(f, "=")
+		if !ok {
+			return fmt.Errorf("field %q is not of the form NAME=VALUE", f)
+		}
+		switch name {
+		case "MESSAGE":
+			r.Message = value
+		case "PRIORITY":
+			pri, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid PRIORITY %q", value)
+			}
+			r.Level = priorityToLevel(Priority(pri))
+		default:
+			r.AddAttrs(slog.String(name, value))
+		}
+	}
+	return h.Handle(context.Background(), r)
+}
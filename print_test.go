@@ -0,0 +1,56 @@
+package slogjournal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrint(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{SyslogIdentifier: "printer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	if err := handler.Print(PriorityError, "disk %s is %d%% full", "/var", 95); err != nil {
+		t.Fatal(err)
+	}
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{
+		"MESSAGE":           "disk /var is 95% full",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "printer",
+	} {
+		if kv[k] != v {
+			t.Errorf("expected %s=%s, got %q", k, v, kv[k])
+		}
+	}
+	if !strings.HasSuffix(kv["CODE_FILE"], "print_test.go") {
+		t.Errorf("expected CODE_FILE of the caller, got %q", kv["CODE_FILE"])
+	}
+
+	buf.Reset()
+	if err := handler.Send("MESSAGE=disk full", "PRIORITY=2", "MOUNT=/var"); err != nil {
+		t.Fatal(err)
+	}
+	kv, err = deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{"MESSAGE": "disk full", "PRIORITY": "2", "MOUNT": "/var"} {
+		if kv[k] != v {
+			t.Errorf("expected %s=%s, got %q", k, v, kv[k])
+		}
+	}
+
+	for _, fields := range [][]string{{"MESSAGE"}, {"PRIORITY=high"}} {
+		if err := handler.Send(fields...); err == nil {
+			t.Errorf("expected error for %q", fields)
+		}
+	}
+}
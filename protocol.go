@@ -0,0 +1,291 @@
+package slogjournal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field is a single field of a journal entry.
+type Field struct {
+	Name  string
+	Value []byte
+}
+
+// Entry is a journal entry. Fields may occur more than once.
+type Entry []Field
+
+// Get returns the value of the first field named name.
+func (e Entry) Get(name string) ([]byte, bool) {
+	for _, f := range e {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Record converts e to a slog.Record. MESSAGE, PRIORITY and SYSLOG_TIMESTAMP
+// map to the message, level and time of the record, all other fields become
+// string Attrs. SYSLOG_TIMESTAMP may be in microseconds since the epoch or
+// in time.RFC3339Nano. If e has no SYSLOG_TIMESTAMP, the time is the
+// current time.
+func (e Entry) Record() slog.Record {
+	var r slog.Record
+	for _, f := range e {
+		switch f.Name {
+		case "MESSAGE":
+			r.Message = string(f.Value)
+		case "PRIORITY":
+			if pri, err := strconv.Atoi(string(f.Value)); err == nil {
+				r.Level = priorityToLevel(Priority(pri))
+			}
+		case "SYSLOG_TIMESTAMP":
+			if usec, err := strconv.ParseInt(string(f.Value), 10, 64); err == nil {
+				r.Time = time.UnixMicro(usec)
+			} else if t, err := time.Parse(time.RFC3339Nano, string(f.Value)); err == nil {
+				r.Time = t
+			}
+		default:
+			r.AddAttrs(slog.String(f.Name, string(f.Value)))
+		}
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	return r
+}
+
+// OriginalPrefix is the prefix of the fields Entry.Original renames trusted
+// fields to.
+const OriginalPrefix = "ORIGINAL_"
+
+// Original returns a copy of e in which the trusted fields, whose names
+// start with an underscore, are renamed to ORIGINAL_ followed by the name
+// without the leading underscores, e.g. _PID to ORIGINAL_PID and
+// __REALTIME_TIMESTAMP to ORIGINAL_REALTIME_TIMESTAMP. journald drops
+// trusted fields sent by clients and adds its own, those of the forwarding
+// process, so entries forwarded from one journal to another keep their
+// provenance this way.
+func (e Entry) Original() Entry {
+	e2 := make(Entry, len(e))
+	for i, f := range e {
+		if name := strings.TrimLeft(f.Name, "_"); name != f.Name && name != "" {
+			f.Name = OriginalPrefix + name
+		}
+		e2[i] = f
+	}
+	return e2
+}
+
+var errMalformedEntry = errors.New("malformed journal entry")
+
+// ParseEntry parses an entry in the [native protocol] format, in which each
+// field is either NAME=VALUE followed by a newline, or NAME followed by a
+// newline, the little-endian 64-bit length of VALUE and VALUE itself, followed
+// by a newline.
+//
+// [native protocol]: https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
+func ParseEntry(b []byte) (Entry, error) {
+	var fields Entry
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+		if i == -1 {
+			return nil, errMalformedEntry
+		}
+		name := string(b[:i])
+		if b[i] == '=' {
+			b = b[i+1:]
+			j := bytes.IndexByte(b, '\n')
+			if j == -1 {
+				return nil, errMalformedEntry
+			}
+			fields = append(fields, Field{name, b[:j]})
+			b = b[j+1:]
+			continue
+		}
+		b = b[i+1:]
+		if len(b) < 8 {
+			return nil, errMalformedEntry
+		}
+		n := binary.LittleEndian.Uint64(b)
+		b = b[8:]
+		// n+1 would overflow for lengths close to 1<<64.
+		if n >= uint64(len(b)) || b[n] != '\n' {
+			return nil, errMalformedEntry
+		}
+		fields = append(fields, Field{name, b[:n]})
+		b = b[n+1:]
+	}
+	return fields, nil
+}
+
+// ParseExport parses entries in the [journal export format], as written by
+// journalctl -o export. It is the native protocol format with entries
+// separated by an empty line.
+//
+// [journal export format]: https://systemd.io/JOURNAL_EXPORT_FORMATS/
+func ParseExport(b []byte) ([]Entry, error) {
+	var entries []Entry
+	var e Entry
+	for len(b) > 0 {
+		if b[0] == '\n' {
+			if len(e) > 0 {
+				entries = append(entries, e)
+			}
+			e = nil
+			b = b[1:]
+			continue
+		}
+		i := bytes.IndexAny(b, "=\n")
+		if i == -1 {
+			return nil, errMalformedEntry
+		}
+		name := string(b[:i])
+		if b[i] == '=' {
+			b = b[i+1:]
+			j := bytes.IndexByte(b, '\n')
+			if j == -1 {
+				j = len(b)
+			}
+			e = append(e, Field{name, b[:j]})
+			b = b[min(j+1, len(b)):]
+			continue
+		}
+		b = b[i+1:]
+		if len(b) < 8 {
+			return nil, errMalformedEntry
+		}
+		n := binary.LittleEndian.Uint64(b)
+		b = b[8:]
+		if uint64(len(b)) < n {
+			return nil, errMalformedEntry
+		}
+		e = append(e, Field{name, b[:n]})
+		b = b[n:]
+		if len(b) > 0 {
+			if b[0] != '\n' {
+				return nil, errMalformedEntry
+			}
+			b = b[1:]
+		}
+	}
+	if len(e) > 0 {
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// AppendNative appends e in the native protocol format to b. Values
+// containing a newline use the binary form.
+func (e Entry) AppendNative(b []byte) []byte {
+	for _, f := range e {
+		if bytes.IndexByte(f.Value, '\n') != -1 {
+			b = append(b, f.Name...)
+			b = append(b, '\n')
+			b = binary.LittleEndian.AppendUint64(b, uint64(len(f.Value)))
+			b = append(b, f.Value...)
+		} else {
+			b = append(b, f.Name...)
+			b = append(b, '=')
+			b = append(b, f.Value...)
+		}
+		b = append(b, '\n')
+	}
+	return b
+}
+
+// MarshalJSON implements [json.Marshaler]. e is encoded in the shape of
+// journalctl -o json: values are strings, binary values are arrays of
+// numbers and fields occurring more than once are arrays of their values.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	return appendJSONEntry(nil, e)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. It accepts the output of
+// journalctl -o json, skipping null values, which journalctl uses for values
+// that are too large. The fields are sorted by name.
+func (e *Entry) UnmarshalJSON(b []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	*e = (*e)[:0]
+	for _, name := range names {
+		values, err := jsonFieldValues(m[name])
+		if err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+		for _, v := range values {
+			*e = append(*e, Field{name, v})
+		}
+	}
+	return nil
+}
+
+// jsonFieldValues returns the values of a field encoded as by journalctl -o
+// json: null, a string, an array of numbers, or an array of those.
+func jsonFieldValues(raw json.RawMessage) ([][]byte, error) {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	if s, ok := v.(string); ok {
+		return [][]byte{[]byte(s)}, nil
+	}
+	a, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value %s", raw)
+	}
+	if b, ok := jsonByteArray(a); ok {
+		return [][]byte{b}, nil
+	}
+	var values [][]byte
+	for _, v := range a {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			values = append(values, []byte(v))
+		case []any:
+			b, ok := jsonByteArray(v)
+			if !ok {
+				return nil, fmt.Errorf("unexpected value %s", raw)
+			}
+			values = append(values, b)
+		default:
+			return nil, fmt.Errorf("unexpected value %s", raw)
+		}
+	}
+	return values, nil
+}
+
+// jsonByteArray returns a as bytes if it is a non-empty array of numbers.
+func jsonByteArray(a []any) ([]byte, bool) {
+	if len(a) == 0 {
+		return nil, false
+	}
+	b := make([]byte, len(a))
+	for i, v := range a {
+		n, ok := v.(float64)
+		if !ok || n < 0 || n > 255 || n != float64(byte(n)) {
+			return nil, false
+		}
+		b[i] = byte(n)
+	}
+	return b, true
+}
@@ -0,0 +1,139 @@
+package slogjournal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseEntry(t *testing.T) {
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b []byte
+	b = handler.appendKV(b, "MESSAGE", []byte("Hello, World!"))
+	b = handler.appendKV(b, "MULTILINE", []byte("line 1\nline 2"))
+	b = handler.appendKV(b, "EMPTY", nil)
+
+	fields, err := ParseEntry(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Entry{{"MESSAGE", []byte("Hello, World!")}, {"MULTILINE", []byte("line 1\nline 2")}, {"EMPTY", nil}}
+	if len(fields) != len(want) {
+		t.Fatalf("expected %d fields, got %d", len(want), len(fields))
+	}
+	for i, f := range fields {
+		if f.Name != want[i].Name || string(f.Value) != string(want[i].Value) {
+			t.Errorf("expected %s=%q, got %s=%q", want[i].Name, want[i].Value, f.Name, f.Value)
+		}
+	}
+
+	for _, malformed := range []string{"MESSAGE", "MESSAGE=foo", "MESSAGE\n\x05\x00\x00\x00\x00\x00\x00\x00foo\n",
+		"MESSAGE\n\xff\xff\xff\xff\xff\xff\xff\xff\n\n"} {
+		if _, err := ParseEntry([]byte(malformed)); err == nil {
+			t.Errorf("expected error for %q", malformed)
+		}
+	}
+}
+
+func FuzzParseEntry(f *testing.F) {
+	f.Add([]byte("MESSAGE=Hello, World!\nPRIORITY=6\n"))
+	f.Add([]byte("MESSAGE\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n"))
+	f.Add([]byte("MESSAGE\n\xff\xff\xff\xff\xff\xff\xff\xff\n\n"))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		e, err := ParseEntry(b)
+		if err != nil {
+			return
+		}
+		got, err := ParseEntry(e.AppendNative(nil))
+		if err != nil {
+			t.Fatalf("parsing the re-encoded entry: %v", err)
+		}
+		if !equalEntries(got, e) {
+			t.Errorf("expected %q, got %q", e, got)
+		}
+	})
+}
+
+func equalEntries(a, b Entry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || string(a[i].Value) != string(b[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEntryAppendNative(t *testing.T) {
+	e := Entry{{"MESSAGE", []byte("Hello, World!")}, {"MULTILINE", []byte("line 1\nline 2")}, {"EMPTY", []byte{}}}
+	got, err := ParseEntry(e.AppendNative(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalEntries(got, e) {
+		t.Errorf("expected %q, got %q", e, got)
+	}
+}
+
+func TestEntryJSON(t *testing.T) {
+	e := Entry{{"BINARY", []byte{0, 1, 2}}, {"MESSAGE", []byte("Hello, World!")}, {"TAG", []byte("a")}, {"TAG", []byte("b")}}
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"BINARY":[0,1,2],"MESSAGE":"Hello, World!","TAG":["a","b"]}`; string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+	var got Entry
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !equalEntries(got, e) {
+		t.Errorf("expected %q, got %q", e, got)
+	}
+
+	if err := json.Unmarshal([]byte(`{"MESSAGE":"hi","HUGE":null}`), &got); err != nil || len(got) != 1 {
+		t.Errorf("expected null values to be skipped, got %q, %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(`{"MESSAGE":3}`), &got); err == nil {
+		t.Error("expected error for number value")
+	}
+}
+
+func TestParseExport(t *testing.T) {
+	b := []byte("__CURSOR=s=1\nMESSAGE=first\n\nMESSAGE\n\x0e\x00\x00\x00\x00\x00\x00\x00line 1\n\nline 2\nPRIORITY=6\n\n")
+	entries, err := ParseExport(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{{"__CURSOR", []byte("s=1")}, {"MESSAGE", []byte("first")}},
+		{{"MESSAGE", []byte("line 1\n\nline 2")}, {"PRIORITY", []byte("6")}},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %q", len(want), entries)
+	}
+	for i := range want {
+		if !equalEntries(entries[i], want[i]) {
+			t.Errorf("expected %q, got %q", want[i], entries[i])
+		}
+	}
+	if _, err := ParseExport([]byte("MESSAGE\n\x05\x00")); err == nil {
+		t.Error("expected error for truncated entry")
+	}
+}
+
+func TestEntryOriginal(t *testing.T) {
+	e := Entry{{"MESSAGE", []byte("hi")}, {"_PID", []byte("42")}, {"_SYSTEMD_UNIT", []byte("app.service")}, {"__REALTIME_TIMESTAMP", []byte("1")}, {"_", []byte("x")}}
+	want := Entry{{"MESSAGE", []byte("hi")}, {"ORIGINAL_PID", []byte("42")}, {"ORIGINAL_SYSTEMD_UNIT", []byte("app.service")}, {"ORIGINAL_REALTIME_TIMESTAMP", []byte("1")}, {"_", []byte("x")}}
+	if got := e.Original(); !equalEntries(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if e[1].Name != "_PID" {
+		t.Error("expected Original not to modify e")
+	}
+}
@@ -0,0 +1,142 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MessageIDSuppressed is the MESSAGE_ID of the entries that count the
+// entries suppressed by Options.RateLimitInterval and Options.RateLimitBurst.
+const MessageIDSuppressed = "8be4319be1244ddfab8e130bf77fe690"
+
+// Fields of the entries written for suppressed entries.
+const (
+	SuppressedKey   = "SUPPRESSED"
+	RateLimitKeyKey = "RATE_LIMIT_KEY"
+)
+
+// maxRateLimitKeys is the number of rate limit windows above which expired
+// windows are forgotten.
+const maxRateLimitKeys = 1024
+
+// rateLimiter lets through burst entries per key in every interval, like
+// RateLimitIntervalSec= and RateLimitBurst= of journald.conf(5).
+type rateLimiter struct {
+	interval time.Duration
+	burst    int
+	key      func(r slog.Record) string
+
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+}
+
+// rateWindow counts the entries of a key in the interval starting at start.
+type rateWindow struct {
+	start      time.Time
+	n          int
+	suppressed uint64
+}
+
+func newRateLimiter(interval time.Duration, burst int, key func(r slog.Record) string) *rateLimiter {
+	return &rateLimiter{
+		interval: interval,
+		burst:    burst,
+		key:      key,
+		windows:  make(map[string]*rateWindow),
+	}
+}
+
+// suppressedEntries counts the entries suppressed for a key.
+type suppressedEntries struct {
+	key string
+	n   uint64
+}
+
+// allow reports whether an entry with key is let through at now. If it is
+// the first entry let through after some were suppressed, it also returns
+// how many were; if it is suppressed, how many were so far, including it.
+// It also returns the counts of the windows it forgot to make room for key.
+func (l *rateLimiter) allow(key string, now time.Time) (ok bool, suppressed uint64, expired []suppressedEntries) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w := l.windows[key]
+	if w == nil {
+		if len(l.windows) >= maxRateLimitKeys {
+			expired = l.prune(now)
+		}
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	} else if now.Sub(w.start) >= l.interval {
+		w.start, w.n = now, 0
+	}
+	if w.n >= l.burst {
+		w.suppressed++
+		return false, w.suppressed, expired
+	}
+	w.n++
+	suppressed, w.suppressed = w.suppressed, 0
+	return true, suppressed, expired
+}
+
+// prune forgets the windows that ended before now, and returns the counts of
+// their suppressed entries not yet reported.
+func (l *rateLimiter) prune(now time.Time) []suppressedEntries {
+	var expired []suppressedEntries
+	for k, w := range l.windows {
+		if now.Sub(w.start) < l.interval {
+			continue
+		}
+		if w.suppressed > 0 {
+			expired = append(expired, suppressedEntries{k, w.suppressed})
+		}
+		delete(l.windows, k)
+	}
+	return expired
+}
+
+// rateLimit reports whether r is let through by the rate limiter of h. If
+// entries were suppressed before it, it first writes an entry counting them.
+// The first entry it suppresses is reported as a DiagnosticRateLimited.
+func (h *Handler) rateLimit(r slog.Record) bool {
+	var key string
+	if h.limiter.key != nil {
+		key = h.limiter.key(r)
+	}
+	ok, suppressed, expired := h.limiter.allow(key, time.Now())
+	for _, e := range expired {
+		h.writeSuppressed(e.key, e.n)
+	}
+	if !ok {
+		h.stats.suppressed.Add(1)
+		if suppressed == 1 {
+			msg := "rate limit exceeded, suppressing entries"
+			if key != "" {
+				msg += " of " + key
+			}
+			h.diagnose(DiagnosticRateLimited, msg, nil)
+		}
+		return false
+	}
+	if suppressed > 0 {
+		h.writeSuppressed(key, suppressed)
+	}
+	return true
+}
+
+// writeSuppressed writes an entry counting the n entries with key suppressed
+// by the rate limiter.
+func (h *Handler) writeSuppressed(key string, n uint64) {
+	msg := "slog-journal: suppressed " + strconv.FormatUint(n, 10) + " entries"
+	if key != "" {
+		msg += " of " + key
+	}
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, msg, 0)
+	r.AddAttrs(slog.String(MessageIDKey, MessageIDSuppressed), slog.Uint64(SuppressedKey, n))
+	if key != "" {
+		r.AddAttrs(slog.String(RateLimitKeyKey, key))
+	}
+	_ = h.root().Handle(context.Background(), r)
+}
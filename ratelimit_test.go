@@ -0,0 +1,122 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	w := &queueWriter{}
+	handler, err := NewHandlerWithWriter(w, &Options{RateLimitInterval: time.Hour, RateLimitBurst: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(handler).With("SERVICE", "test")
+	for range 5 {
+		logger.Info("Hello, World!")
+	}
+	if len(w.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(w.entries))
+	}
+
+	// End the window.
+	handler.limiter.windows[""].start = time.Now().Add(-time.Hour)
+	logger.Info("again")
+	if len(w.entries) != 4 {
+		t.Fatalf("expected a summary and an entry, got %d entries", len(w.entries)-2)
+	}
+	summary := w.entries[2]
+	for k, want := range map[string]string{
+		MessageIDKey:  MessageIDSuppressed,
+		"PRIORITY":    "4",
+		SuppressedKey: "3",
+	} {
+		if v, _ := summary.Get(k); string(v) != want {
+			t.Errorf("expected %s=%s, got %q", k, want, v)
+		}
+	}
+	if _, ok := summary.Get("SERVICE"); ok {
+		t.Error("expected the summary without the attributes of the logger")
+	}
+	if got := w.message(3); got != "again" {
+		t.Errorf("expected MESSAGE=again, got %q", got)
+	}
+
+	if err := handler.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := w.entries[len(w.entries)-1].Get(SuppressedKey); string(v) != "3" {
+		t.Errorf("expected the Close summary to have %s=3, got %q", SuppressedKey, v)
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	l := newRateLimiter(time.Second, 1, nil)
+	now := time.Now()
+	for _, tt := range []struct {
+		key        string
+		at         time.Duration
+		ok         bool
+		suppressed uint64
+	}{
+		{"a", 0, true, 0},
+		{"a", 0, false, 1},
+		{"b", 0, true, 0},
+		{"a", 500 * time.Millisecond, false, 2},
+		{"a", time.Second, true, 2},
+		{"b", time.Second, true, 0},
+	} {
+		ok, suppressed, _ := l.allow(tt.key, now.Add(tt.at))
+		if ok != tt.ok || suppressed != tt.suppressed {
+			t.Errorf("allow(%q, +%v) = %v, %d, expected %v, %d", tt.key, tt.at, ok, suppressed, tt.ok, tt.suppressed)
+		}
+	}
+}
+
+func TestRateLimitPrune(t *testing.T) {
+	w := &queueWriter{}
+	handler, err := NewHandlerWithWriter(w, &Options{
+		RateLimitInterval: time.Hour,
+		RateLimitBurst:    1,
+		RateLimitKey:      func(r slog.Record) string { return r.Message },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(handler)
+	logger.Info("noisy")
+	logger.Info("noisy")
+	for i := range maxRateLimitKeys - 1 {
+		logger.Info(strconv.Itoa(i))
+	}
+	if len(w.entries) != maxRateLimitKeys {
+		t.Fatalf("expected %d entries, got %d", maxRateLimitKeys, len(w.entries))
+	}
+
+	// End all the windows, so that they are forgotten for a new key.
+	for _, rw := range handler.limiter.windows {
+		rw.start = time.Now().Add(-time.Hour)
+	}
+	logger.Info("new")
+	if n := len(handler.limiter.windows); n != 1 {
+		t.Errorf("expected the expired windows to be forgotten, got %d windows", n)
+	}
+	if len(w.entries) != maxRateLimitKeys+2 {
+		t.Fatalf("expected a summary and an entry, got %d entries", len(w.entries)-maxRateLimitKeys)
+	}
+	summary := w.entries[maxRateLimitKeys]
+	for k, want := range map[string]string{
+		MessageIDKey:    MessageIDSuppressed,
+		SuppressedKey:   "1",
+		RateLimitKeyKey: "noisy",
+	} {
+		if v, _ := summary.Get(k); string(v) != want {
+			t.Errorf("expected %s=%s, got %q", k, want, v)
+		}
+	}
+	if got := w.message(maxRateLimitKeys + 1); got != "new" {
+		t.Errorf("expected MESSAGE=new, got %q", got)
+	}
+}
@@ -0,0 +1,37 @@
+package slogjournal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// RequestIDKey is the journal field that carries the request ID of a record.
+const RequestIDKey = "REQUEST_ID"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx that carries the request ID id.
+// Records handled with the returned context get a [REQUEST_ID] field.
+//
+// [REQUEST_ID]: RequestIDKey
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// NewRequestID returns a random 128-bit request ID formatted as 32 lowercase
+// hexadecimal characters, the same format systemd uses for its IDs.
+func NewRequestID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
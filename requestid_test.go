@@ -0,0 +1,83 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestRequestID(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	id := NewRequestID()
+	if len(id) != 32 {
+		t.Fatalf("expected 32 characters, got %q", id)
+	}
+	if id == NewRequestID() {
+		t.Error("expected unique request IDs")
+	}
+
+	ctx := WithRequestID(context.Background(), id)
+	if got, ok := RequestIDFromContext(ctx); !ok || got != id {
+		t.Errorf("expected %s, got %q", id, got)
+	}
+
+	_ = handler.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv[RequestIDKey] != id {
+		t.Errorf("expected %s=%s, got %q", RequestIDKey, id, kv[RequestIDKey])
+	}
+
+	_ = handler.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+	kv, err = deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := kv[RequestIDKey]; ok {
+		t.Error("unexpected", RequestIDKey, v)
+	}
+}
+
+func TestContextAttrs(t *testing.T) {
+	type tenantKey struct{}
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{ContextAttrs: func(ctx context.Context) []slog.Attr {
+		if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+			return []slog.Attr{slog.String("TENANT_ID", tenant)}
+		}
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	_ = handler.WithGroup("DB").Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+	kv, err := deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv["TENANT_ID"] != "acme" {
+		t.Errorf("expected TENANT_ID=acme, got %q", kv["TENANT_ID"])
+	}
+
+	_ = handler.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0))
+	kv, err = deserializeKeyValue(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := kv["TENANT_ID"]; ok {
+		t.Error("unexpected TENANT_ID", v)
+	}
+}
@@ -0,0 +1,49 @@
+package slogjournal
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+)
+
+// maxResolveDepth bounds both the number of LogValue calls made to resolve a
+// single value and the nesting depth of groups.
+const maxResolveDepth = 32
+
+var errGroupTooDeep = fmt.Errorf("groups nested deeper than %d levels", maxResolveDepth)
+
+// resolve is like [slog.Value.Resolve], but gives up after maxResolveDepth
+// LogValue calls or when a LogValuer resolves to itself, and reports panics in
+// LogValue methods as errors.
+func resolve(v slog.Value) (rv slog.Value, err error) {
+	if v.Kind() != slog.KindLogValuer {
+		return v, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("LogValue panicked: %v", r)
+		}
+	}()
+	var seen []slog.LogValuer
+	for range maxResolveDepth {
+		lv := v.LogValuer()
+		if reflect.TypeOf(lv).Comparable() {
+			if slices.Contains(seen, lv) {
+				return slog.Value{}, fmt.Errorf("LogValue of type %T resolves to itself", lv)
+			}
+			seen = append(seen, lv)
+		}
+		v = lv.LogValue()
+		if v.Kind() != slog.KindLogValuer {
+			return v, nil
+		}
+	}
+	return slog.Value{}, fmt.Errorf("LogValue called more than %d times on value of type %T", maxResolveDepth, v.LogValuer())
+}
+
+// appendResolveError appends a RESOLVE_ERROR field describing why the value
+// of key could not be resolved.
+func (h *Handler) appendResolveError(b []byte, key string, err error) []byte {
+	return h.appendKV(b, resolveErrorKey, []byte(key+": "+err.Error()))
+}
@@ -0,0 +1,73 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type selfValuer struct{ n int }
+
+func (v selfValuer) LogValue() slog.Value { return slog.AnyValue(v) }
+
+type recursiveGroup struct{}
+
+func (v recursiveGroup) LogValue() slog.Value {
+	return slog.GroupValue(slog.Any("NESTED", v))
+}
+
+type chainValuer int
+
+func (v chainValuer) LogValue() slog.Value {
+	if v == 0 {
+		return slog.StringValue("done")
+	}
+	return slog.AnyValue(v - 1)
+}
+
+type panicValuer struct{}
+
+func (panicValuer) LogValue() slog.Value { panic("boom") }
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+		err   string
+	}{
+		{"Chain", chainValuer(3), "done", ""},
+		{"Cycle", selfValuer{}, "", "resolves to itself"},
+		{"TooLong", chainValuer(100), "", "LogValue called more than"},
+		{"Panic", panicValuer{}, "", "LogValue panicked: boom"},
+		{"Groups", recursiveGroup{}, "", "nested deeper"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			handler, err := NewHandler(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler.w = buf
+			record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+			record.AddAttrs(slog.Any("VALUE", tt.value))
+			if err := handler.Handle(context.TODO(), record); err != nil {
+				t.Fatal(err)
+			}
+			kv, err := deserializeKeyValue(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kv["VALUE"] != tt.want {
+				t.Errorf("expected VALUE=%s, got %q", tt.want, kv["VALUE"])
+			}
+			if !strings.Contains(kv["RESOLVE_ERROR"], tt.err) || (tt.err == "") != (kv["RESOLVE_ERROR"] == "") {
+				t.Errorf("expected RESOLVE_ERROR containing %q, got %q", tt.err, kv["RESOLVE_ERROR"])
+			}
+		})
+	}
+}
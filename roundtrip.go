@@ -0,0 +1,56 @@
+package slogjournal
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RoundTripError is returned by Handle with Options.VerifyRoundTrip set for
+// entries that do not decode to the fields they were encoded from.
+type RoundTripError struct {
+	// Entry is the entry as encoded by the handler.
+	Entry []byte
+	// Decoded are the fields ParseEntry decoded from Entry, up to the first
+	// field that failed to decode.
+	Decoded Entry
+	// Expected are the fields Entry was encoded from.
+	Expected Entry
+	// Err is the error of ParseEntry, if it failed.
+	Err error
+}
+
+func (e *RoundTripError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("journal entry does not decode: %v: %q", e.Err, e.Entry)
+	}
+	return fmt.Sprintf("journal entry decodes as %q instead of %q: %q", e.Decoded, e.Expected, e.Entry)
+}
+
+func (e *RoundTripError) Unwrap() error {
+	return e.Err
+}
+
+// verifyRoundTrip decodes the entry b with ParseEntry and checks that it
+// decodes to exactly the fields in expected, the names and values b was
+// encoded from.
+func verifyRoundTrip(b []byte, expected Entry) error {
+	e, err := ParseEntry(b)
+	if err != nil {
+		return &RoundTripError{Entry: bytes.Clone(b), Expected: expected, Err: err}
+	}
+	if !slices.EqualFunc(e, expected, func(a, b Field) bool {
+		return a.Name == b.Name && bytes.Equal(a.Value, b.Value)
+	}) {
+		return &RoundTripError{Entry: bytes.Clone(b), Decoded: e, Expected: expected}
+	}
+	return nil
+}
+
+// expect adds the field k=v appended by appendKV to h.expected.
+func (h *Handler) expect(k string, v []byte) {
+	name := strings.Clone(k)
+	value := bytes.Clone(v)
+	*h.expected = append(*h.expected, Field{name, value})
+}
@@ -0,0 +1,166 @@
+// Package runtimemetrics periodically logs readings of the Go runtime, such
+// as the heap size, the number of goroutines and GC pauses, as structured
+// entries with a fixed MESSAGE_ID. On hosts without a metrics stack, this
+// makes them available next to the logs during incidents:
+//
+//	journalctl MESSAGE_ID=8f77f68f879a49ffbc582c98faf6d798 -o json
+//
+// Every entry has a field per metric, named after the metric: the leading
+// slash is dropped, the name is upper-cased and all other characters that
+// are not allowed in field names become underscores, with a GO_ prefix, so
+// /sched/goroutines:goroutines becomes GO_SCHED_GOROUTINES_GOROUTINES.
+// Histograms, such as /sched/pauses/total/gc:seconds, are logged as their
+// median, 99th percentile and maximum, in fields with the suffixes _P50,
+// _P99 and _MAX.
+package runtimemetrics
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"strings"
+	"time"
+
+	slogjournal "github.com/systemd/slog-journal"
+)
+
+// MessageID is the MESSAGE_ID of the entries.
+const MessageID = "8f77f68f879a49ffbc582c98faf6d798"
+
+// DefaultMetrics are the metrics logged if Options.Metrics is empty.
+var DefaultMetrics = []string{
+	"/memory/classes/total:bytes",
+	"/memory/classes/heap/objects:bytes",
+	"/gc/heap/goal:bytes",
+	"/gc/cycles/total:gc-cycles",
+	"/sched/goroutines:goroutines",
+	"/sched/pauses/total/gc:seconds",
+}
+
+// Options configure Run.
+type Options struct {
+	// Interval is the time between entries. The default is one minute.
+	Interval time.Duration
+	// Level is the level the entries are logged at. The default is
+	// slog.LevelInfo.
+	Level slog.Level
+	// Metrics are the names of the runtime/metrics to log. Metrics the
+	// runtime does not support are skipped. The default is DefaultMetrics.
+	Metrics []string
+}
+
+// Run logs the metrics with l every opts.Interval until ctx is done. It
+// logs the first entry right away. opts may be nil.
+func Run(ctx context.Context, l *slog.Logger, opts *Options) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Interval <= 0 {
+		o.Interval = time.Minute
+	}
+	samples := newSamples(o.Metrics)
+	t := time.NewTicker(o.Interval)
+	defer t.Stop()
+	for {
+		logSamples(ctx, l, o.Level, samples)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Log logs the metrics named by names, or DefaultMetrics if names is empty,
+// once with l at level.
+func Log(ctx context.Context, l *slog.Logger, level slog.Level, names ...string) {
+	logSamples(ctx, l, level, newSamples(names))
+}
+
+// newSamples returns the samples of the supported metrics among names.
+func newSamples(names []string) []metrics.Sample {
+	if len(names) == 0 {
+		names = DefaultMetrics
+	}
+	supported := map[string]bool{}
+	for _, d := range metrics.All() {
+		supported[d.Name] = true
+	}
+	var samples []metrics.Sample
+	for _, name := range names {
+		if supported[name] {
+			samples = append(samples, metrics.Sample{Name: name})
+		}
+	}
+	return samples
+}
+
+func logSamples(ctx context.Context, l *slog.Logger, level slog.Level, samples []metrics.Sample) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	metrics.Read(samples)
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // skip [Callers, logSamples, Log]
+	r := slog.NewRecord(time.Now(), level, "runtime metrics", pcs[0])
+	r.AddAttrs(slog.String(slogjournal.MessageIDKey, MessageID))
+	for _, s := range samples {
+		key := fieldName(s.Name)
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			r.AddAttrs(slog.Uint64(key, s.Value.Uint64()))
+		case metrics.KindFloat64:
+			r.AddAttrs(slog.Float64(key, s.Value.Float64()))
+		case metrics.KindFloat64Histogram:
+			h := s.Value.Float64Histogram()
+			r.AddAttrs(
+				slog.Float64(key+"_P50", quantile(h, 0.5)),
+				slog.Float64(key+"_P99", quantile(h, 0.99)),
+				slog.Float64(key+"_MAX", quantile(h, 1)),
+			)
+		}
+	}
+	_ = l.Handler().Handle(ctx, r)
+}
+
+// fieldName returns the field name of the metric name.
+func fieldName(name string) string {
+	b := []byte("GO_" + strings.ToUpper(strings.TrimPrefix(name, "/")))
+	for i, c := range b {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// quantile returns the upper bound of the bucket of h that contains the
+// quantile q, or 0 if h is empty. Infinite bounds are replaced by the lower
+// bound of their bucket.
+func quantile(h *metrics.Float64Histogram, q float64) float64 {
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	var n uint64
+	for i, c := range h.Counts {
+		n += c
+		if n >= rank && c > 0 {
+			if hi := h.Buckets[i+1]; !math.IsInf(hi, 1) {
+				return hi
+			}
+			return h.Buckets[i]
+		}
+	}
+	return 0
+}
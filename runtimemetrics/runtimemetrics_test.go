@@ -0,0 +1,94 @@
+package runtimemetrics
+
+import (
+	"context"
+	"log/slog"
+	"runtime/metrics"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestLog(t *testing.T) {
+	h := &recordHandler{}
+	Log(context.TODO(), slog.New(h), slog.LevelInfo, "/sched/goroutines:goroutines", "/sched/pauses/total/gc:seconds", "/no/such:metric")
+	if len(h.records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(h.records))
+	}
+	fields := map[string]slog.Value{}
+	h.records[0].Attrs(func(a slog.Attr) bool {
+		fields[a.Key] = a.Value
+		return true
+	})
+	if fields["MESSAGE_ID"].String() != MessageID {
+		t.Errorf("expected MESSAGE_ID=%s, got %v", MessageID, fields["MESSAGE_ID"])
+	}
+	if v, ok := fields["GO_SCHED_GOROUTINES_GOROUTINES"]; !ok || v.Uint64() == 0 {
+		t.Errorf("expected number of goroutines, got %v", v)
+	}
+	for _, suffix := range []string{"_P50", "_P99", "_MAX"} {
+		if _, ok := fields["GO_SCHED_PAUSES_TOTAL_GC_SECONDS"+suffix]; !ok {
+			t.Errorf("expected GC pause %s, got %v", suffix, fields)
+		}
+	}
+	if len(fields) != 5 {
+		t.Errorf("expected 5 fields, got %v", fields)
+	}
+}
+
+func TestRun(t *testing.T) {
+	h := &recordHandler{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, slog.New(h), &Options{Interval: time.Millisecond})
+		close(done)
+	}()
+	for {
+		h.mu.Lock()
+		n := len(h.records)
+		h.mu.Unlock()
+		if n >= 3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
+
+func TestQuantile(t *testing.T) {
+	h := &metrics.Float64Histogram{
+		Counts:  []uint64{5, 4, 0, 1},
+		Buckets: []float64{0, 1, 2, 3, 4},
+	}
+	for q, want := range map[float64]float64{0.5: 1, 0.9: 2, 0.99: 4, 1: 4} {
+		if got := quantile(h, q); got != want {
+			t.Errorf("quantile(%v) = %v, want %v", q, got, want)
+		}
+	}
+	if got := quantile(&metrics.Float64Histogram{Counts: []uint64{0}, Buckets: []float64{0, 1}}, 0.5); got != 0 {
+		t.Errorf("expected 0 for empty histogram, got %v", got)
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	if got := fieldName("/gc/cycles/total:gc-cycles"); got != "GO_GC_CYCLES_TOTAL_GC_CYCLES" {
+		t.Errorf("unexpected field name %s", got)
+	}
+}
@@ -0,0 +1,307 @@
+package slogjournal
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FieldType is the type of the values of a field declared in a Schema.
+type FieldType int
+
+const (
+	// TypeString accepts any value.
+	TypeString FieldType = iota
+	// TypeInt accepts signed decimal integers.
+	TypeInt
+	// TypeUint accepts unsigned decimal integers.
+	TypeUint
+	// TypeFloat accepts floating-point numbers.
+	TypeFloat
+	// TypeBool accepts "true" and "false".
+	TypeBool
+	// TypeIP accepts IPv4 and IPv6 addresses, as formatted by netip.Addr.
+	TypeIP
+)
+
+var fieldTypeNames = []string{"string", "int", "uint", "float", "bool", "ip"}
+
+func (t FieldType) String() string {
+	if t < 0 || int(t) >= len(fieldTypeNames) {
+		return "FieldType(" + strconv.Itoa(int(t)) + ")"
+	}
+	return fieldTypeNames[t]
+}
+
+// MarshalText implements [encoding.TextMarshaler].
+func (t FieldType) MarshalText() ([]byte, error) {
+	if t < 0 || int(t) >= len(fieldTypeNames) {
+		return nil, fmt.Errorf("invalid field type %d", t)
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (t *FieldType) UnmarshalText(b []byte) error {
+	for i, name := range fieldTypeNames {
+		if string(b) == name {
+			*t = FieldType(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown field type %q", b)
+}
+
+// check reports whether v is a valid value of type t.
+func (t FieldType) check(v []byte) bool {
+	var err error
+	switch t {
+	case TypeInt:
+		_, err = strconv.ParseInt(string(v), 10, 64)
+	case TypeUint:
+		_, err = strconv.ParseUint(string(v), 10, 64)
+	case TypeFloat:
+		_, err = strconv.ParseFloat(string(v), 64)
+	case TypeBool:
+		_, err = strconv.ParseBool(string(v))
+	case TypeIP:
+		_, err = netip.ParseAddr(string(v))
+	}
+	return err == nil
+}
+
+// FieldSpec declares a field of a Schema.
+type FieldSpec struct {
+	Name     string    `json:"name"`
+	Type     FieldType `json:"type"`
+	Required bool      `json:"required,omitempty"`
+	// Doc describes the field.
+	Doc string `json:"doc,omitempty"`
+}
+
+// Schema declares the fields of the entries with a MESSAGE_ID.
+// Schemas can be read from JSON, e.g.
+//
+//	{
+//		"message_id": "8d45620c1a4348dbb17410da57c60c66",
+//		"name": "UserLoginFailed",
+//		"message": "login failed",
+//		"level": "WARN",
+//		"fields": [
+//			{"name": "USER", "type": "string", "required": true},
+//			{"name": "ATTEMPTS", "type": "uint"}
+//		]
+//	}
+type Schema struct {
+	MessageID string `json:"message_id"`
+	// Name names the event, e.g. "UserLoginFailed".
+	Name string `json:"name,omitempty"`
+	// Message is the default message of the event.
+	Message string `json:"message,omitempty"`
+	// Level is the default level of the event, e.g. "WARN".
+	Level  slog.Level  `json:"level,omitempty"`
+	Fields []FieldSpec `json:"fields"`
+	// Closed, if true, makes fields that are not declared violations.
+	// The fields added by the handler itself, such as CODE_FILE, are
+	// always allowed.
+	Closed bool `json:"closed,omitempty"`
+}
+
+// handlerFields returns the names of the fields h adds to entries on its
+// own with its options, which schemas always allow.
+func (h *Handler) handlerFields() map[string]bool {
+	fields := map[string]bool{}
+	for _, name := range []string{
+		messageKey, MessageIDKey, priorityKey, levelKey, syslogIdentifierKey,
+		RequestIDKey, resolveErrorKey, SchemaViolationKey, TruncatedKey,
+	} {
+		fields[name] = true
+	}
+	add := func(ok bool, names ...string) {
+		for _, name := range names {
+			fields[name] = fields[name] || ok
+		}
+	}
+	sf := h.opts.SourceFields
+	if sf == 0 {
+		sf = DefaultSourceFields
+	}
+	add(sf&CodeFile != 0, codeFileKey)
+	add(sf&CodeLine != 0, codeLineKey)
+	add(sf&CodeFunc != 0, codeFuncKey)
+	add(sf&CodeLocation != 0, codeLocationKey)
+	add(!h.opts.OmitTimestamp, cmp.Or(h.opts.TimestampKey, syslogTimestampKey))
+	add(h.opts.RuntimeUsec, RuntimeUsecKey)
+	add(h.opts.InvocationID, invocationIDKey)
+	add(h.opts.HostFields, HostnameKey, MachineOSKey, KernelVersionKey)
+	add(h.opts.ThreadID, ThreadIDKey)
+	add(h.opts.GoroutineID, GoroutineIDKey)
+	add(h.opts.Trace != nil, TraceIDKey, SpanIDKey)
+	add(len(h.opts.SigningKey) > 0, SignatureKey)
+	for _, name := range h.baggage {
+		fields[name] = true
+	}
+	for _, lf := range h.levelFields {
+		e, _ := ParseEntry(lf.b)
+		for _, f := range e {
+			fields[f.Name] = true
+		}
+	}
+	return fields
+}
+
+// anyHandlerFields are the fields handlers add on their own with any
+// options, which [Schema.Validate] allows.
+var anyHandlerFields = sync.OnceValue(func() map[string]bool {
+	h := &Handler{opts: Options{
+		SourceFields: CodeFile | CodeLine | CodeFunc | CodeLocation,
+		RuntimeUsec:  true,
+		InvocationID: true,
+		HostFields:   true,
+		ThreadID:     true,
+		GoroutineID:  true,
+		Trace:        func(context.Context) (string, string) { return "", "" },
+		SigningKey:   []byte{0},
+	}}
+	return h.handlerFields()
+})
+
+// Validate checks e against s: required fields must be present, and the
+// values of declared fields must match their type. It returns a
+// *ValidationError listing the violations, or nil.
+func (s Schema) Validate(e Entry) error {
+	return s.validate(e, anyHandlerFields())
+}
+
+// validate is like Validate, but allows only the fields in builtin to be
+// added to closed schemas.
+func (s Schema) validate(e Entry, builtin map[string]bool) error {
+	var violations []Violation
+	declared := make(map[string]FieldSpec, len(s.Fields))
+	for _, spec := range s.Fields {
+		declared[spec.Name] = spec
+		if _, ok := e.Get(spec.Name); spec.Required && !ok {
+			violations = append(violations, Violation{Field: spec.Name, Reason: "required field is missing"})
+		}
+	}
+	for _, f := range e {
+		spec, ok := declared[f.Name]
+		switch {
+		case ok && !spec.Type.check(f.Value):
+			violations = append(violations, Violation{Field: f.Name, Reason: fmt.Sprintf("value %q is not of type %s", f.Value, spec.Type)})
+		case !ok && s.Closed && !builtin[f.Name] && !strings.HasPrefix(f.Name, "_"):
+			violations = append(violations, Violation{Field: f.Name, Reason: "field is not declared by the schema of MESSAGE_ID " + s.MessageID})
+		}
+	}
+	if len(violations) > 0 {
+		return &ValidationError{violations}
+	}
+	return nil
+}
+
+var schemas = struct {
+	sync.RWMutex
+	m map[string]Schema
+}{m: map[string]Schema{}}
+
+// RegisterSchema registers s as the schema of the entries with the
+// MESSAGE_ID s.MessageID, replacing any previous registration. Handlers with
+// Options.SchemaMode set check entries against it.
+//
+// The attributes of a record with a MESSAGE_ID attribute whose schema
+// declares them are part of the event, so like MESSAGE_ID they are not
+// prefixed by the groups of the handler: a handler derived with
+// WithGroup("DB") writes the USER attribute of a record of a schema
+// declaring USER as USER, not DB_USER.
+//
+// RegisterSchema is meant to be called during initialization.
+func RegisterSchema(s Schema) {
+	schemas.Lock()
+	defer schemas.Unlock()
+	schemas.m[s.MessageID] = s
+}
+
+// LookupSchema returns the schema registered for the MESSAGE_ID id.
+func LookupSchema(id string) (Schema, bool) {
+	schemas.RLock()
+	defer schemas.RUnlock()
+	s, ok := schemas.m[id]
+	return s, ok
+}
+
+// declares reports whether s declares the field name.
+func (s Schema) declares(name string) bool {
+	for _, spec := range s.Fields {
+		if spec.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// recordSchema returns the schema registered for the MESSAGE_ID attribute
+// of r, if the handler has groups whose prefix the fields it declares are
+// exempt from.
+func (h *Handler) recordSchema(r slog.Record) (Schema, bool) {
+	if h.prefix == "" {
+		return Schema{}, false
+	}
+	var s Schema
+	ok := false
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key != MessageIDKey {
+			return true
+		}
+		s, ok = LookupSchema(a.Value.Resolve().String())
+		return false
+	})
+	return s, ok
+}
+
+// SchemaMode controls how a Handler treats entries that violate the schema
+// registered for their MESSAGE_ID.
+type SchemaMode int
+
+const (
+	// SchemaIgnore does not check entries against schemas.
+	SchemaIgnore SchemaMode = iota
+	// SchemaFlag writes entries that violate their schema with a
+	// SCHEMA_VIOLATION field for each violation.
+	SchemaFlag
+	// SchemaReject drops entries that violate their schema. Handle returns
+	// a *ValidationError listing the violations.
+	SchemaReject
+)
+
+// SchemaViolationKey is the field that flags schema violations in SchemaFlag mode.
+const SchemaViolationKey = "SCHEMA_VIOLATION"
+
+// checkSchema checks the entry b in the native protocol format against the
+// schema of its MESSAGE_ID according to h.opts.SchemaMode.
+func (h *Handler) checkSchema(b []byte) ([]byte, error) {
+	e, err := ParseEntry(b)
+	if err != nil {
+		return b, err
+	}
+	id, ok := e.Get(MessageIDKey)
+	if !ok {
+		return b, nil
+	}
+	s, ok := LookupSchema(string(id))
+	if !ok {
+		return b, nil
+	}
+	err = s.validate(e, h.schemaFields)
+	if err == nil || h.opts.SchemaMode == SchemaReject {
+		return b, err
+	}
+	for _, v := range err.(*ValidationError).Violations {
+		b = h.appendKV(b, SchemaViolationKey, []byte(v.String()))
+	}
+	return b, nil
+}
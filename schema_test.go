@@ -0,0 +1,182 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSchemaID = "8d45620c1a4348dbb17410da57c60c66"
+
+func TestSchema(t *testing.T) {
+	var s Schema
+	if err := json.Unmarshal([]byte(`{
+		"message_id": "`+testSchemaID+`",
+		"name": "UserLoginFailed",
+		"fields": [
+			{"name": "USER", "type": "string", "required": true},
+			{"name": "ATTEMPTS", "type": "uint"}
+		],
+		"closed": true
+	}`), &s); err != nil {
+		t.Fatal(err)
+	}
+	RegisterSchema(s)
+
+	tests := []struct {
+		name   string
+		attrs  []slog.Attr
+		fields []string
+	}{
+		{"Valid", []slog.Attr{slog.String("USER", "alice"), slog.Int("ATTEMPTS", 3)}, nil},
+		{"Missing", []slog.Attr{slog.Int("ATTEMPTS", 3)}, []string{"USER"}},
+		{"WrongType", []slog.Attr{slog.String("USER", "alice"), slog.Int("ATTEMPTS", -1)}, []string{"ATTEMPTS"}},
+		{"Undeclared", []slog.Attr{slog.String("USER", "alice"), slog.String("HOST", "example")}, []string{"HOST"}},
+		{"EmptyName", []slog.Attr{slog.String("USER", "alice"), slog.String("", "v")}, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := slog.NewRecord(time.Now(), slog.LevelWarn, "login failed", 0)
+			record.AddAttrs(slog.String("MESSAGE_ID", testSchemaID))
+			record.AddAttrs(tt.attrs...)
+
+			buf := new(bytes.Buffer)
+			handler, err := NewHandler(&Options{SchemaMode: SchemaReject})
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler.w = buf
+			err = handler.Handle(context.TODO(), record)
+			var verr *ValidationError
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else if !errors.As(err, &verr) || len(verr.Violations) != len(tt.fields) || verr.Violations[0].Field != tt.fields[0] {
+				t.Fatalf("expected violations of %v, got %v", tt.fields, err)
+			} else if buf.Len() != 0 {
+				t.Error("expected rejected entry not to be written")
+			}
+
+			handler.opts.SchemaMode = SchemaFlag
+			if err := handler.Handle(context.TODO(), record); err != nil {
+				t.Fatal(err)
+			}
+			e, err := ParseEntry(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var flagged []string
+			for _, f := range e {
+				if f.Name == SchemaViolationKey {
+					flagged = append(flagged, string(f.Value))
+				}
+			}
+			if len(flagged) != len(tt.fields) || (len(flagged) > 0 && !strings.Contains(flagged[0], tt.fields[0])) {
+				t.Errorf("expected violations of %v to be flagged, got %v", tt.fields, flagged)
+			}
+		})
+	}
+}
+
+func TestSchemaValidateEmptyName(t *testing.T) {
+	s := Schema{MessageID: testSchemaID, Closed: true}
+	var verr *ValidationError
+	if err := s.Validate(Entry{{"", []byte("v")}}); !errors.As(err, &verr) || verr.Violations[0].Field != "" {
+		t.Errorf("expected a violation of the empty name, got %v", err)
+	}
+}
+
+func TestSchemaHandlerFields(t *testing.T) {
+	RegisterSchema(Schema{
+		MessageID: testSchemaID,
+		Fields:    []FieldSpec{{Name: "USER", Type: TypeString, Required: true}},
+		Closed:    true,
+	})
+	record := slog.NewRecord(time.Now(), slog.LevelWarn, "login failed", 0)
+	record.AddAttrs(slog.String("MESSAGE_ID", testSchemaID), slog.String("USER", "alice"))
+
+	// The fields added with the options of the handler are allowed.
+	handler, err := NewHandler(&Options{
+		SchemaMode:   SchemaReject,
+		TimestampKey: "TIMESTAMP",
+		ThreadID:     true,
+		LevelFields:  map[slog.Level][]slog.Attr{slog.LevelWarn: {slog.String("ONCALL", "team")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = io.Discard
+	if err := handler.Handle(context.TODO(), record); err != nil {
+		t.Errorf("expected the fields of the handler to be allowed, got %v", err)
+	}
+
+	// Those of options that are not set are not.
+	handler, err = NewHandler(&Options{SchemaMode: SchemaReject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = io.Discard
+	record.AddAttrs(slog.Int(ThreadIDKey, 1))
+	var verr *ValidationError
+	if err := handler.Handle(context.TODO(), record); !errors.As(err, &verr) || verr.Violations[0].Field != ThreadIDKey {
+		t.Errorf("expected a violation of %s, got %v", ThreadIDKey, err)
+	}
+}
+
+func TestSchemaFieldsWithGroup(t *testing.T) {
+	RegisterSchema(Schema{
+		MessageID: testSchemaID,
+		Fields:    []FieldSpec{{Name: "USER", Type: TypeString, Required: true}},
+		Closed:    true,
+	})
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{SchemaMode: SchemaReject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+	record := slog.NewRecord(time.Now(), slog.LevelWarn, "login failed", 0)
+	record.AddAttrs(slog.String("USER", "alice"), slog.String(MessageIDKey, testSchemaID))
+	if err := handler.WithGroup("DB").Handle(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+	e, err := ParseEntry(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := e.Get("USER"); string(v) != "alice" {
+		t.Errorf("expected USER=alice without the group prefix, got %q", e)
+	}
+
+	// Other records are prefixed as usual.
+	buf.Reset()
+	record = slog.NewRecord(time.Now(), slog.LevelWarn, "login failed", 0)
+	record.AddAttrs(slog.String("USER", "alice"))
+	if err := handler.WithGroup("DB").Handle(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := ParseEntry(buf.Bytes()); !slices.ContainsFunc(e, func(f Field) bool { return f.Name == "DB_USER" }) {
+		t.Errorf("expected DB_USER, got %q", e)
+	}
+}
+
+func TestSchemaTypeIP(t *testing.T) {
+	var spec FieldSpec
+	if err := json.Unmarshal([]byte(`{"name": "CLIENT_IP", "type": "ip"}`), &spec); err != nil || spec.Type != TypeIP {
+		t.Fatalf("expected type ip, got %v, %v", spec.Type, err)
+	}
+	s := Schema{MessageID: testSchemaID, Fields: []FieldSpec{spec}}
+	for v, valid := range map[string]bool{"192.0.2.1": true, "2001:db8::1": true, "example.com": false} {
+		if err := s.Validate(Entry{{"CLIENT_IP", []byte(v)}}); (err == nil) != valid {
+			t.Errorf("Validate(CLIENT_IP=%s): expected valid %t, got %v", v, valid, err)
+		}
+	}
+}
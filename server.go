@@ -0,0 +1,185 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// ErrServerClosed is returned by the Serve and ListenAndServe methods of a
+// Server after a call to Close.
+var ErrServerClosed = errors.New("slogjournal: server closed")
+
+// Server receives entries sent with the [native protocol] on a unixgram
+// socket, like journald does, including entries passed as file descriptors
+// to memfds or temporary files. It can stand in for journald in development
+// containers and tests, or serve as the receiving end of a log relay.
+//
+// Malformed and empty entries are ignored.
+//
+// [native protocol]: https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
+type Server struct {
+	// Handler, if non-nil, receives every entry converted by [Entry.Record]
+	// whose level it is enabled for.
+	Handler slog.Handler
+
+	// HandleEntry, if non-nil, is called with every entry.
+	HandleEntry func(Entry)
+
+	// Original, if true, renames the trusted fields of the entries, such as
+	// _PID or _SYSTEMD_UNIT, with [Entry.Original] before they are passed
+	// on, so that they survive being forwarded to a journal. Trusted fields
+	// are only present in entries sent by other forwarders, e.g. entries
+	// read with journalctl -o export.
+	Original bool
+
+	mu     sync.Mutex
+	conn   *net.UnixConn
+	closed bool
+}
+
+// ListenAndServe listens on the unixgram socket path and serves entries
+// received on it. If path exists, it is removed first.
+func (s *Server) ListenAndServe(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	return s.Serve(conn)
+}
+
+// Serve serves entries received on conn until Close is called or reading from
+// conn fails. Serve always closes conn.
+func (s *Server) Serve(conn *net.UnixConn) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return ErrServerClosed
+	}
+	s.conn = conn
+	s.mu.Unlock()
+	defer conn.Close()
+
+	buf := make([]byte, maxDatagramSize)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	for {
+		n, oobn, flags, _, err := conn.ReadMsgUnix(buf, oob)
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if flags&(syscall.MSG_TRUNC|syscall.MSG_CTRUNC) != 0 {
+			closeRights(oob[:oobn])
+			continue
+		}
+		p := buf[:n]
+		if oobn > 0 {
+			if p, err = readRights(oob[:oobn]); err != nil {
+				continue
+			}
+		}
+		e, err := ParseEntry(p)
+		if err != nil || len(e) == 0 {
+			continue
+		}
+		s.serveEntry(e)
+	}
+}
+
+func (s *Server) serveEntry(e Entry) {
+	if s.Original {
+		e = e.Original()
+	}
+	if s.HandleEntry != nil {
+		s.HandleEntry(e)
+	}
+	if s.Handler != nil {
+		r := e.Record()
+		if s.Handler.Enabled(context.Background(), r.Level) {
+			_ = s.Handler.Handle(context.Background(), r)
+		}
+	}
+}
+
+// Close stops the server. Serve and ListenAndServe return ErrServerClosed.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// readRights reads the entry passed as a file descriptor in the socket
+// control message oob. Like journald, it only accepts regular files, such
+// as memfds, of at most maxEntrySize bytes.
+func readRights(oob []byte) ([]byte, error) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, err
+	}
+	var files []*os.File
+	for _, m := range msgs {
+		fds, err := syscall.ParseUnixRights(&m)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			f := os.NewFile(uintptr(fd), "journal")
+			defer f.Close()
+			files = append(files, f)
+		}
+	}
+	if len(files) != 1 {
+		return nil, errMalformedEntry
+	}
+	f := files[0]
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() || fi.Size() > maxEntrySize {
+		return nil, errMalformedEntry
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	// The file may grow after Stat unless it is a sealed memfd.
+	p, err := io.ReadAll(io.LimitReader(f, maxEntrySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(p) > maxEntrySize {
+		return nil, errMalformedEntry
+	}
+	return p, nil
+}
+
+// closeRights closes all file descriptors passed in oob.
+func closeRights(oob []byte) {
+	msgs, _ := syscall.ParseSocketControlMessage(oob)
+	for _, m := range msgs {
+		fds, _ := syscall.ParseUnixRights(&m)
+		for _, fd := range fds {
+			syscall.Close(fd)
+		}
+	}
+}
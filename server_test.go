@@ -0,0 +1,117 @@
+//go:build unix && !(darwin && cgo)
+
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "socket")
+	entries := make(chan Entry, 2)
+	out := new(bytes.Buffer)
+	s := &Server{
+		Handler:     slog.NewTextHandler(out, nil),
+		HandleEntry: func(e Entry) { entries <- e },
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.ListenAndServe(path) }()
+
+	handler, err := NewHandler(&Options{SocketPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jw := handler.w.(*journalWriter)
+
+	// Wait for the server to listen.
+	for i := 0; ; i++ {
+		if _, err := jw.conn().WriteToUnix(nil, jw.addr); err == nil {
+			break
+		} else if i == 100 {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	record := slog.NewRecord(time.Now(), slog.LevelWarn, "Hello, World!", 0)
+	record.AddAttrs(slog.String("KEY", "value"))
+	if err := handler.Handle(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+	e := <-entries
+	if v, _ := e.Get("MESSAGE"); string(v) != "Hello, World!" {
+		t.Errorf("expected MESSAGE=Hello, World!, got %q", v)
+	}
+	if v, _ := e.Get("KEY"); string(v) != "value" {
+		t.Errorf("expected KEY=value, got %q", v)
+	}
+
+	_ = jw.conns[0].SetWriteBuffer(1024)
+	large := strings.Repeat("a", 4096)
+	if err := handler.Handle(context.TODO(), slog.NewRecord(time.Now(), slog.LevelInfo, large, 0)); err != nil {
+		t.Fatal(err)
+	}
+	e = <-entries
+	if v, _ := e.Get("MESSAGE"); string(v) != large {
+		t.Errorf("expected large MESSAGE, got %d bytes", len(v))
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("expected ErrServerClosed, got %v", err)
+	}
+	if !strings.Contains(out.String(), `level=WARN msg="Hello, World!"`) || !strings.Contains(out.String(), "KEY=value") {
+		t.Error("unexpected handler output", out.String())
+	}
+}
+
+func TestReadRights(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "entry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString("MESSAGE=Hello, World!\n"); err != nil {
+		t.Fatal(err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for _, tt := range []struct {
+		name string
+		f    *os.File
+		want string
+	}{
+		{"File", file, "MESSAGE=Hello, World!\n"},
+		{"Pipe", r, ""},
+	} {
+		// readRights closes the descriptors it is passed.
+		fd, err := syscall.Dup(int(tt.f.Fd()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := readRights(syscall.UnixRights(fd))
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.name)
+			}
+			continue
+		}
+		if err != nil || string(p) != tt.want {
+			t.Errorf("%s: readRights = %q, %v, want %q", tt.name, p, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package slogjournal
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// SignatureKey is the field that holds the signature of a signed entry.
+const SignatureKey = "SIGNATURE"
+
+var (
+	// ErrUnsigned is returned by VerifySignature for entries without a
+	// SIGNATURE field.
+	ErrUnsigned = errors.New("journal entry is not signed")
+	// ErrSignatureMismatch is returned by VerifySignature for entries whose
+	// signature does not match their fields.
+	ErrSignatureMismatch = errors.New("journal entry signature mismatch")
+)
+
+// signature returns the HMAC-SHA256 of the canonical form of e keyed by key,
+// hex encoded. The canonical form consists of the fields of e sorted by name
+// and value, excluding SIGNATURE and the fields that journald adds on its
+// own, whose names start with an underscore. Each field is encoded like a
+// binary field of the native protocol, so that the encoding is unambiguous.
+func signature(e Entry, key []byte) []byte {
+	fields := slices.DeleteFunc(slices.Clone(e), func(f Field) bool {
+		return f.Name == SignatureKey || strings.HasPrefix(f.Name, "_")
+	})
+	slices.SortStableFunc(fields, func(a, b Field) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), bytes.Compare(a.Value, b.Value))
+	})
+	mac := hmac.New(sha256.New, key)
+	var b []byte
+	for _, f := range fields {
+		b = append(b[:0], f.Name...)
+		b = append(b, '\n')
+		b = binary.LittleEndian.AppendUint64(b, uint64(len(f.Value)))
+		b = append(b, f.Value...)
+		b = append(b, '\n')
+		mac.Write(b)
+	}
+	return hex.AppendEncode(nil, mac.Sum(nil))
+}
+
+// appendSignature appends the SIGNATURE field for the entry b in the native
+// protocol format.
+func (h *Handler) appendSignature(b []byte) []byte {
+	e, err := ParseEntry(b)
+	if err != nil {
+		return b
+	}
+	return h.appendKV(b, SignatureKey, signature(e, h.opts.SigningKey))
+}
+
+// VerifySignature verifies the SIGNATURE field of an entry written by a
+// Handler with Options.SigningKey set to key. It returns ErrUnsigned if e has
+// no signature and ErrSignatureMismatch if the signature does not match.
+// Fields whose names start with an underscore are not signed, so e may be an
+// entry read back from the journal.
+func VerifySignature(e Entry, key []byte) error {
+	sig, ok := e.Get(SignatureKey)
+	if !ok {
+		return ErrUnsigned
+	}
+	if !hmac.Equal(sig, signature(e, key)) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSignature(t *testing.T) {
+	key := []byte("secret")
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{SigningKey: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	record := slog.NewRecord(time.Now(), LevelNotice, "user logged in", 0)
+	record.AddAttrs(slog.String("USER", "alice"), slog.String("DATA", "line 1\nline 2"))
+	_ = handler.Handle(context.TODO(), record)
+	e, err := ParseEntry(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifySignature(e, key); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fields added by journald do not affect the signature, nor does order.
+	stored := append(Entry{{"_PID", []byte("1")}}, e[1:]...)
+	stored = append(stored, e[0], Field{"__CURSOR", []byte("s=1")})
+	if err := VerifySignature(stored, key); err != nil {
+		t.Error("expected valid signature for stored entry:", err)
+	}
+
+	if err := VerifySignature(e, []byte("other")); !errors.Is(err, ErrSignatureMismatch) {
+		t.Error("expected ErrSignatureMismatch, got", err)
+	}
+	for i, f := range e {
+		if f.Name == "USER" {
+			e[i].Value = []byte("mallory")
+		}
+	}
+	if err := VerifySignature(e, key); !errors.Is(err, ErrSignatureMismatch) {
+		t.Error("expected ErrSignatureMismatch, got", err)
+	}
+	if err := VerifySignature(Entry{{"MESSAGE", []byte("hi")}}, key); !errors.Is(err, ErrUnsigned) {
+		t.Error("expected ErrUnsigned, got", err)
+	}
+}
@@ -0,0 +1,110 @@
+package slogjournal
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// maxSpoolSize bounds the size of a spool file. Entries that do not fit are
+// dropped.
+const maxSpoolSize = 64 * 1024 * 1024
+
+var errSpoolFull = errors.New("journal spool is full")
+
+// spool is a file that keeps entries in the native protocol format while the
+// journal is unavailable. Each entry is prefixed by its little-endian 64-bit
+// length.
+type spool struct {
+	path string
+
+	mu   sync.Mutex
+	size int64
+	// pending is set when the spool holds entries that await replay.
+	pending atomic.Bool
+}
+
+func newSpool(path string) *spool {
+	s := &spool{path: path}
+	if fi, err := os.Stat(path); err == nil && fi.Size() > 0 {
+		s.size = fi.Size()
+		s.pending.Store(true)
+	}
+	return s
+}
+
+// append adds an entry to the spool.
+func (s *spool) append(p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size+int64(8+len(p)) > maxSpoolSize {
+		return errSpoolFull
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b := binary.LittleEndian.AppendUint64(make([]byte, 0, 8+len(p)), uint64(len(p)))
+	if _, err := f.Write(append(b, p...)); err != nil {
+		return err
+	}
+	s.size += int64(len(b))
+	s.pending.Store(true)
+	return nil
+}
+
+// replay sends all spooled entries in order. Entries are removed from the
+// spool once sent. If send fails, the remaining entries are kept and the error
+// is returned.
+func (s *spool) replay(send func([]byte) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for len(b) > 0 {
+		p, rest, ok := nextSpooled(b)
+		if !ok {
+			// A truncated entry, e.g. from a crash while spooling.
+			b = nil
+			break
+		}
+		if err := send(p); err != nil {
+			return s.rewrite(b, err)
+		}
+		b = rest
+	}
+	return s.rewrite(b, nil)
+}
+
+// nextSpooled returns the first entry of the spooled entries b and the
+// entries after it. ok is false if the first entry is truncated.
+func nextSpooled(b []byte) (p, rest []byte, ok bool) {
+	if len(b) < 8 || uint64(len(b)-8) < binary.LittleEndian.Uint64(b) {
+		return nil, nil, false
+	}
+	n := 8 + int(binary.LittleEndian.Uint64(b))
+	return b[8:n], b[n:], true
+}
+
+// rewrite replaces the contents of the spool with the remaining entries b and
+// returns err.
+func (s *spool) rewrite(b []byte, err error) error {
+	if len(b) == 0 {
+		if rerr := os.Remove(s.path); rerr != nil && !errors.Is(rerr, os.ErrNotExist) {
+			return rerr
+		}
+		s.size = 0
+		s.pending.Store(false)
+		return err
+	}
+	if werr := os.WriteFile(s.path, b, 0o600); werr != nil {
+		return werr
+	}
+	s.size = int64(len(b))
+	return err
+}
@@ -0,0 +1,115 @@
+//go:build unix && !(darwin && cgo)
+
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+	spoolPath := filepath.Join(dir, "spool")
+	raddr := &net.UnixAddr{Name: filepath.Join(dir, "socket"), Net: "unixgram"}
+
+	var diagnostics []DiagnosticKind
+	handler, err := NewHandler(&Options{SocketPath: raddr.Name, SpoolPath: spoolPath, OnDiagnostic: func(d Diagnostic) {
+		diagnostics = append(diagnostics, d.Kind)
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"first", "second"} {
+		if err := handler.Handle(context.TODO(), slog.Record{Level: slog.LevelInfo, Message: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fi, err := os.Stat(spoolPath); err != nil || fi.Size() == 0 {
+		t.Fatal("expected entries to be spooled", err)
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", raddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := handler.Handle(context.TODO(), slog.Record{Level: slog.LevelInfo, Message: "third"}); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1024)
+	for _, want := range []string{"first", "second", "third"} {
+		n, _, err := conn.ReadFromUnix(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kv, err := deserializeKeyValue(bytes.NewReader(buf[:n]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kv["MESSAGE"] != want {
+			t.Errorf("expected MESSAGE=%s, got %q", want, kv["MESSAGE"])
+		}
+	}
+	if _, err := os.Stat(spoolPath); !os.IsNotExist(err) {
+		t.Error("expected spool to be removed after replay", err)
+	}
+	if want := []DiagnosticKind{DiagnosticSpooling, DiagnosticReplayed}; !slices.Equal(diagnostics, want) {
+		t.Errorf("expected diagnostics %v, got %v", want, diagnostics)
+	}
+}
+
+func TestSpoolReplayLargeEntry(t *testing.T) {
+	dir := t.TempDir()
+	raddr := &net.UnixAddr{Name: filepath.Join(dir, "socket"), Net: "unixgram"}
+
+	var diagnostics []DiagnosticKind
+	handler, err := NewHandler(&Options{
+		SocketPath:        raddr.Name,
+		SpoolPath:         filepath.Join(dir, "spool"),
+		DiagnosticEntries: true,
+		OnDiagnostic:      func(d Diagnostic) { diagnostics = append(diagnostics, d.Kind) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Larger than the send buffer, so it is replayed in a file descriptor.
+	large := slog.Record{Level: slog.LevelInfo, Message: "large"}
+	large.AddAttrs(slog.String("DATA", strings.Repeat("x", sndBufSize+1)))
+	if err := handler.Handle(context.TODO(), large); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", raddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	done := make(chan error)
+	go func() {
+		done <- handler.Handle(context.TODO(), slog.Record{Level: slog.LevelInfo, Message: "after"})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock replaying a large entry")
+	}
+	for _, want := range []DiagnosticKind{DiagnosticSpooling, DiagnosticLargeEntry, DiagnosticReplayed} {
+		if !slices.Contains(diagnostics, want) {
+			t.Errorf("expected a %v diagnostic, got %v", want, diagnostics)
+		}
+	}
+}
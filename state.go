@@ -0,0 +1,79 @@
+package slogjournal
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+)
+
+// State describes the effective configuration of a handler, e.g. for an
+// administrative endpoint that shows how logging is configured.
+type State struct {
+	// Level is the current minimum level.
+	Level slog.Level
+	// Groups are the groups of the handler, after ReplaceGroup.
+	Groups []string
+	// Prefix is the prefix of the fields of the attributes of records.
+	Prefix string
+	// Fields are the fields the handler adds to every entry: those of
+	// WithAttrs calls, INVOCATION_ID and the host fields.
+	Fields Entry
+	// SyslogIdentifier is the SYSLOG_IDENTIFIER of the entries.
+	SyslogIdentifier string
+	// Namespace is the journal namespace, see Options.Namespace.
+	Namespace string
+	// Sink describes where entries are written to, e.g. "journal
+	// /run/systemd/journal/socket", "json stdout" or "console stderr".
+	Sink string
+}
+
+// sink is implemented by writers to describe where they write entries to.
+type sink interface {
+	sink() string
+}
+
+// State returns the effective configuration of h.
+func (h *Handler) State() State {
+	fields, _ := ParseEntry(h.preformatted)
+	return State{
+		Level:            h.opts.Level.Level(),
+		Groups:           slices.Clone(h.groups),
+		Prefix:           h.prefix,
+		Fields:           fields,
+		SyslogIdentifier: string(h.identifier),
+		Namespace:        h.opts.Namespace,
+		Sink:             sinkOf(h.w),
+	}
+}
+
+// sinkOf describes where w writes entries to.
+func sinkOf(w io.Writer) string {
+	if s, ok := w.(sink); ok {
+		return s.sink()
+	}
+	return fmt.Sprintf("writer %T", w)
+}
+
+func (w *jsonWriter) sink() string {
+	return "json " + fileName(w.w)
+}
+
+func (w *consoleWriter) sink() string {
+	return "console " + fileName(w.w)
+}
+
+func (l *lazyWriter) sink() string {
+	if w := l.w.Load(); w != nil {
+		return sinkOf(*w)
+	}
+	return "lazy, not connected yet"
+}
+
+// fileName returns the name of w if it is a file such as os.Stdout.
+func fileName(w io.Writer) string {
+	if f, ok := w.(interface{ Name() string }); ok {
+		return f.Name()
+	}
+	return fmt.Sprintf("%T", w)
+}
@@ -0,0 +1,45 @@
+package slogjournal
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestState(t *testing.T) {
+	level := &slog.LevelVar{}
+	handler, err := NewHandler(&Options{Level: level, SyslogIdentifier: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = &jsonWriter{w: os.Stdout}
+
+	h := handler.WithAttrs([]slog.Attr{slog.String("KEY", "value")}).WithGroup("HTTP").(*Handler)
+	level.Set(slog.LevelWarn)
+	s := h.State()
+	if s.Level != slog.LevelWarn {
+		t.Errorf("expected level WARN, got %v", s.Level)
+	}
+	if !slices.Equal(s.Groups, []string{"HTTP"}) || s.Prefix != "HTTP_" {
+		t.Errorf("expected group HTTP, got %q, prefix %q", s.Groups, s.Prefix)
+	}
+	if !equalEntries(s.Fields, Entry{{"KEY", []byte("value")}}) {
+		t.Errorf("expected KEY=value, got %q", s.Fields)
+	}
+	if s.SyslogIdentifier != "app" {
+		t.Errorf("expected identifier app, got %q", s.SyslogIdentifier)
+	}
+	if s.Sink != "json /dev/stdout" {
+		t.Errorf("expected json sink, got %q", s.Sink)
+	}
+
+	handler.w = new(bytes.Buffer)
+	if s := handler.State(); s.Sink != "writer *bytes.Buffer" {
+		t.Errorf("expected bytes.Buffer sink, got %q", s.Sink)
+	}
+	if s := NewLazyHandler(nil).State(); s.Sink != "lazy, not connected yet" {
+		t.Errorf("expected lazy sink, got %q", s.Sink)
+	}
+}
@@ -0,0 +1,51 @@
+package slogjournal
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// TruncatedKey is the field added to entries that were truncated to fit
+// Options.StaticBufferSize.
+const TruncatedKey = "TRUNCATED"
+
+// minStaticBufferSize is the smallest Options.StaticBufferSize.
+const minStaticBufferSize = 256
+
+var truncatedField = []byte(TruncatedKey + "=1\n")
+
+// staticBuffer is the preallocated entry buffer of a handler with
+// Options.StaticBufferSize set, shared by the handlers derived from it.
+// mu is held from encoding an entry until it is written.
+type staticBuffer struct {
+	mu        sync.Mutex
+	b         []byte
+	truncated bool
+}
+
+func newStaticBuffer(size int) *staticBuffer {
+	return &staticBuffer{b: make([]byte, 0, max(size, minStaticBufferSize))}
+}
+
+// fits reports whether n more bytes fit into b. Only the static buffer is
+// limited; if a field does not fit into it, the entry is marked as
+// truncated, leaving room for the TRUNCATED field.
+func (h *Handler) fits(b []byte, n int) bool {
+	s := h.static
+	if s == nil || unsafe.SliceData(b) != unsafe.SliceData(s.b) {
+		return true
+	}
+	if len(b)+n <= cap(b)-len(truncatedField) {
+		return true
+	}
+	s.truncated = true
+	return false
+}
+
+// appendRaw appends the preformatted fields p to b if they fit.
+func (h *Handler) appendRaw(b, p []byte) []byte {
+	if !h.fits(b, len(p)) {
+		return b
+	}
+	return append(b, p...)
+}
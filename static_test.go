@@ -0,0 +1,102 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// entryWriter records the entries written to it, and whether they were
+// written from the static buffer.
+type entryWriter struct {
+	entries  [][]byte
+	static   *staticBuffer
+	inStatic []bool
+}
+
+func (w *entryWriter) Write(p []byte) (int, error) {
+	w.entries = append(w.entries, bytes.Clone(p))
+	w.inStatic = append(w.inStatic, unsafe.SliceData(p) == unsafe.SliceData(w.static.b))
+	return len(p), nil
+}
+
+func TestStaticBuffer(t *testing.T) {
+	const size = 512
+	handler, err := NewHandler(&Options{StaticBufferSize: size, SourceFields: NoSource})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &entryWriter{static: handler.static}
+	handler.w = w
+	logger := slog.New(handler).With("SERVICE", "test")
+
+	logger.Info("small", "KEY", "value")
+	logger.Info("large", "FIRST", "value", "HUGE", strings.Repeat("x", size), "LAST", "value")
+	logger.Info("small again")
+
+	if len(w.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(w.entries))
+	}
+	for i, e := range w.entries {
+		if len(e) > size {
+			t.Errorf("entry %d is %d bytes, more than %d", i, len(e), size)
+		}
+		if !w.inStatic[i] {
+			t.Errorf("entry %d was not written from the static buffer", i)
+		}
+	}
+
+	want := []map[string]string{
+		{"MESSAGE": "small", "SERVICE": "test", "KEY": "value"},
+		{"MESSAGE": "large", "SERVICE": "test", "FIRST": "value", "LAST": "value", TruncatedKey: "1"},
+		{"MESSAGE": "small again", "SERVICE": "test"},
+	}
+	for i, e := range w.entries {
+		kv, err := deserializeKeyValue(bytes.NewBuffer(e))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range want[i] {
+			if kv[k] != v {
+				t.Errorf("entry %d: expected %s=%s, got %q", i, k, v, kv[k])
+			}
+		}
+		if _, ok := kv["HUGE"]; ok {
+			t.Errorf("entry %d: expected HUGE to be dropped", i)
+		}
+		if _, ok := kv[TruncatedKey]; ok && want[i][TruncatedKey] == "" {
+			t.Errorf("entry %d: unexpected %s", i, TruncatedKey)
+		}
+	}
+}
+
+func TestStaticBufferNoGrowth(t *testing.T) {
+	handler, err := NewHandler(&Options{StaticBufferSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cap(handler.static.b); got != minStaticBufferSize {
+		t.Errorf("expected minimum size %d, got %d", minStaticBufferSize, got)
+	}
+	w := &entryWriter{static: handler.static}
+	handler.w = w
+	for i := range 100 {
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, strings.Repeat("m", i*10), 0)
+		r.AddAttrs(slog.Int("N", i), slog.String("MULTILINE", "a\nb"))
+		if err := handler.Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, e := range w.entries {
+		if len(e) > minStaticBufferSize || !w.inStatic[i] {
+			t.Fatalf("entry %d: %d bytes, in static buffer: %v", i, len(e), w.inStatic[i])
+		}
+		if _, err := ParseEntry(e); err != nil {
+			t.Fatalf("entry %d: %v", i, err)
+		}
+	}
+}
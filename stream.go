@@ -0,0 +1,59 @@
+package slogjournal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// StderrIsJournal reports whether stderr is connected to the journal, as
+// systemd sets it up for services with StandardError=journal, the default.
+// It compares the device and inode number in $JOURNAL_STREAM with those of
+// stderr, as described in systemd.exec(5).
+func StderrIsJournal() bool {
+	var dev, ino uint64
+	if _, err := fmt.Sscanf(os.Getenv("JOURNAL_STREAM"), "%d:%d", &dev, &ino); err != nil {
+		return false
+	}
+	sdev, sino, ok := fileID(os.Stderr)
+	return ok && sdev == dev && sino == ino
+}
+
+// GuardConsole guards against logging every record twice in programs that
+// log both to the journal and to a console handler h writing to stderr:
+// under systemd, stderr is connected to the journal itself. If it is,
+// GuardConsole returns a handler that only passes records at or above level
+// to h, or none at all if level is nil. Otherwise it returns h.
+//
+//	console := slogjournal.GuardConsole(slog.NewTextHandler(os.Stderr, nil), slog.LevelError)
+func GuardConsole(h slog.Handler, level slog.Leveler) slog.Handler {
+	if !StderrIsJournal() {
+		return h
+	}
+	return &guardHandler{h, level}
+}
+
+// guardHandler passes the records at or above level to h.
+type guardHandler struct {
+	h     slog.Handler
+	level slog.Leveler
+}
+
+func (g *guardHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return g.level != nil && level >= g.level.Level() && g.h.Enabled(ctx, level)
+}
+
+func (g *guardHandler) Handle(ctx context.Context, r slog.Record) error {
+	return g.h.Handle(ctx, r)
+}
+
+func (g *guardHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &guardHandler{g.h.WithAttrs(attrs), g.level}
+}
+
+func (g *guardHandler) WithGroup(name string) slog.Handler {
+	return &guardHandler{g.h.WithGroup(name), g.level}
+}
+
+var _ slog.Handler = &guardHandler{}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package slogjournal
+
+import "os"
+
+// fileID returns the device and inode number of f. There is no journal to
+// be connected to outside of Unix.
+func fileID(*os.File) (dev, ino uint64, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,39 @@
+//go:build unix
+
+package slogjournal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestGuardConsole(t *testing.T) {
+	console := slog.NewTextHandler(os.Stderr, nil)
+
+	t.Setenv("JOURNAL_STREAM", "")
+	if StderrIsJournal() {
+		t.Error("expected stderr not to be connected to the journal")
+	}
+	if GuardConsole(console, nil) != console {
+		t.Error("expected the console handler to be returned unchanged")
+	}
+
+	dev, ino, ok := fileID(os.Stderr)
+	if !ok {
+		t.Skip("cannot determine the inode of stderr")
+	}
+	t.Setenv("JOURNAL_STREAM", fmt.Sprintf("%d:%d", dev, ino))
+	if !StderrIsJournal() {
+		t.Fatal("expected stderr to be connected to the journal")
+	}
+	guarded := GuardConsole(console, slog.LevelError).WithGroup("g")
+	if guarded.Enabled(context.TODO(), slog.LevelWarn) || !guarded.Enabled(context.TODO(), slog.LevelError) {
+		t.Error("expected only errors to be enabled")
+	}
+	if GuardConsole(console, nil).Enabled(context.TODO(), slog.LevelError) {
+		t.Error("expected no level to be enabled")
+	}
+}
@@ -0,0 +1,68 @@
+package slogjournal
+
+import "slices"
+
+// StrictMode controls whether WithAttrs and WithGroup check the field names
+// they produce against the rules of journald, which silently drops fields
+// with invalid names.
+type StrictMode int
+
+const (
+	// StrictOff does not check field names.
+	StrictOff StrictMode = iota
+	// StrictRecord records invalid field names in the derived handler, to be
+	// reported by [Handler.Err].
+	StrictRecord
+	// StrictPanic makes WithAttrs and WithGroup panic with a
+	// *ValidationError on invalid field names. It is meant for tests.
+	StrictPanic
+)
+
+// Err returns the invalid field names recorded by the WithAttrs and WithGroup
+// calls h was derived with in StrictRecord mode, as a *ValidationError, or
+// nil.
+func (h *Handler) Err() error {
+	return h.strictErr
+}
+
+// checkStrictFields checks the field names of the native protocol fields b
+// according to h.opts.Strict, and returns the error to record in the derived
+// handler.
+func (h *Handler) checkStrictFields(b []byte) error {
+	fields, err := ParseEntry(b)
+	if err != nil {
+		return err
+	}
+	var violations []Violation
+	for _, f := range fields {
+		if reason := checkFieldName(f.Name); reason != "" {
+			violations = append(violations, Violation{Field: f.Name, Reason: reason})
+		}
+	}
+	return h.strictViolations(violations)
+}
+
+// checkStrictGroup checks the field name prefix of a group like
+// checkStrictFields.
+func (h *Handler) checkStrictGroup(prefix string) error {
+	if reason := checkFieldName(prefix); reason != "" {
+		return h.strictViolations([]Violation{{Field: prefix, Reason: "group " + reason}})
+	}
+	return h.strictErr
+}
+
+// strictViolations panics with violations or adds them to the recorded
+// error, depending on h.opts.Strict.
+func (h *Handler) strictViolations(violations []Violation) error {
+	if len(violations) == 0 {
+		return h.strictErr
+	}
+	verr := &ValidationError{Violations: violations}
+	if h.opts.Strict == StrictPanic {
+		panic(verr)
+	}
+	if prev, ok := h.strictErr.(*ValidationError); ok {
+		verr.Violations = append(slices.Clip(prev.Violations), violations...)
+	}
+	return verr
+}
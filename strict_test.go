@@ -0,0 +1,56 @@
+package slogjournal
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestStrictRecord(t *testing.T) {
+	handler, err := NewHandler(&Options{Strict: StrictRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.WithAttrs([]slog.Attr{slog.String("VALID", "x")}).(*Handler)
+	if err := h.Err(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	h = h.WithAttrs([]slog.Attr{slog.String("lower", "x"), slog.Group("", slog.String("_TRUSTED", "x"))}).(*Handler)
+	h = h.WithGroup("http").(*Handler)
+	h = h.WithAttrs([]slog.Attr{slog.String("METHOD", "GET")}).(*Handler)
+
+	var verr *ValidationError
+	if !errors.As(h.Err(), &verr) {
+		t.Fatalf("expected *ValidationError, got %v", h.Err())
+	}
+	var fields []string
+	for _, v := range verr.Violations {
+		fields = append(fields, v.Field)
+	}
+	want := []string{"lower", "_TRUSTED", "http", "http_METHOD"}
+	if len(fields) != len(want) {
+		t.Fatalf("expected violations of %q, got %q", want, fields)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("expected violations of %q, got %q", want, fields)
+		}
+	}
+	if handler.Err() != nil {
+		t.Error("expected the parent handler to be unaffected")
+	}
+}
+
+func TestStrictPanic(t *testing.T) {
+	handler, err := NewHandler(&Options{Strict: StrictPanic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = handler.WithGroup("HTTP").WithAttrs([]slog.Attr{slog.Group("EMPTY")})
+	defer func() {
+		if _, ok := recover().(*ValidationError); !ok {
+			t.Error("expected panic with *ValidationError")
+		}
+	}()
+	_ = handler.WithAttrs([]slog.Attr{slog.String("bad-key", "x")})
+}
@@ -1,4 +1,4 @@
-//go:build linux
+//go:build linux && !slogjournal_nosys
 
 package slogjournal
 
@@ -8,15 +8,21 @@ import (
 	"golang.org/x/sys/unix"
 )
 
-func tempFd() (*os.File, error) {
-	fd, err := unix.MemfdCreate("journal", unix.MFD_ALLOW_SEALING)
+// tempFd returns a file to pass a large entry in, and whether it can be
+// sealed. Like sd_journal_send, it prefers a memfd, which journald can mmap
+// safely once it is sealed, and falls back to an unlinked temporary file on
+// kernels older than 3.17 without memfd_create.
+func tempFd() (*os.File, bool, error) {
+	fd, err := unix.MemfdCreate("journal", unix.MFD_ALLOW_SEALING|unix.MFD_CLOEXEC)
 	if err == nil {
-		return os.NewFile(uintptr(fd), ""), nil
+		return os.NewFile(uintptr(fd), "memfd:journal"), true, nil
 	}
-	return tempFdCommon()
+	f, err := tempFdCommon()
+	return f, false, err
 }
 
-func trySeal(f *os.File) error {
+// seal seals the memfd f against any further changes.
+func seal(f *os.File) error {
 	_, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS, unix.F_SEAL_SEAL|unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE)
 	return err
 }
@@ -0,0 +1,36 @@
+package slogjournal
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestTempFdSealed(t *testing.T) {
+	f, sealable, err := tempFd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if !sealable {
+		t.Skip("memfd_create is not available")
+	}
+	if _, err := f.Write([]byte("MESSAGE=Hello, World!\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := seal(f); err != nil {
+		t.Fatal(err)
+	}
+	// F_GET_SEALS, without golang.org/x/sys/unix so that the test also
+	// runs with slogjournal_nosys.
+	seals, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), 1034, 0)
+	if errno != 0 {
+		t.Fatal(errno)
+	}
+	// F_SEAL_SEAL | F_SEAL_SHRINK | F_SEAL_GROW | F_SEAL_WRITE
+	if want := uintptr(0xf); seals&want != want {
+		t.Errorf("expected seals %#x, got %#x", want, seals)
+	}
+	if _, err := f.Write([]byte("more")); err == nil {
+		t.Error("expected writes to the sealed memfd to fail")
+	}
+}
@@ -0,0 +1,65 @@
+//go:build slogjournal_nosys
+
+package slogjournal
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// sysMemfdCreate are the numbers of the memfd_create system call, which the
+// syscall package only defines for some architectures.
+var sysMemfdCreate = map[string]uintptr{
+	"386":      356,
+	"amd64":    319,
+	"arm":      385,
+	"arm64":    279,
+	"loong64":  279,
+	"mips":     4354,
+	"mipsle":   4354,
+	"mips64":   5314,
+	"mips64le": 5314,
+	"ppc64":    360,
+	"ppc64le":  360,
+	"riscv64":  279,
+	"s390x":    350,
+}
+
+// Flags of memfd_create and fcntl, from linux/memfd.h and linux/fcntl.h.
+const (
+	mfdCloexec      = 0x1
+	mfdAllowSealing = 0x2
+	fAddSeals       = 1033
+	fSealSeal       = 0x1
+	fSealShrink     = 0x2
+	fSealGrow       = 0x4
+	fSealWrite      = 0x8
+)
+
+// tempFd returns a file to pass a large entry in, and whether it can be
+// sealed, like the tempFd built with golang.org/x/sys/unix.
+func tempFd() (*os.File, bool, error) {
+	if nr := sysMemfdCreate[runtime.GOARCH]; nr != 0 {
+		name, err := syscall.BytePtrFromString("journal")
+		if err != nil {
+			return nil, false, err
+		}
+		fd, _, errno := syscall.Syscall(nr, uintptr(unsafe.Pointer(name)), mfdCloexec|mfdAllowSealing, 0)
+		if errno == 0 {
+			return os.NewFile(fd, "memfd:journal"), true, nil
+		}
+	}
+	f, err := tempFdCommon()
+	return f, false, err
+}
+
+// seal seals the memfd f against any further changes.
+func seal(f *os.File) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), fAddSeals, fSealSeal|fSealShrink|fSealGrow|fSealWrite)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
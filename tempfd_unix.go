@@ -4,10 +4,14 @@ package slogjournal
 
 import "os"
 
-func tempFd() (*os.File, error) {
-	return tempFdCommon()
+// Without memfd_create, large entries are passed in an unlinked temporary
+// file, which cannot be sealed.
+
+func tempFd() (*os.File, bool, error) {
+	f, err := tempFdCommon()
+	return f, false, err
 }
 
-func trySeal(*os.File) error {
+func seal(*os.File) error {
 	return nil
 }
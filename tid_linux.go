@@ -0,0 +1,8 @@
+package slogjournal
+
+import "syscall"
+
+// gettid returns the ID of the calling thread.
+func gettid() (int, bool) {
+	return syscall.Gettid(), true
+}
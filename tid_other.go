@@ -0,0 +1,8 @@
+//go:build !linux
+
+package slogjournal
+
+// gettid reports that thread IDs are not available.
+func gettid() (int, bool) {
+	return 0, false
+}
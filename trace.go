@@ -0,0 +1,22 @@
+package slogjournal
+
+import "context"
+
+// Fields correlating entries with distributed traces, see Options.Trace.
+const (
+	TraceIDKey = "TRACE_ID"
+	SpanIDKey  = "SPAN_ID"
+)
+
+// appendTrace appends the trace and span IDs of the span carried by ctx.
+func (h *Handler) appendTrace(b []byte, ctx context.Context) []byte {
+	traceID, spanID := h.opts.Trace(ctx)
+	if traceID == "" {
+		return b
+	}
+	b = h.appendKVString(b, TraceIDKey, traceID)
+	if spanID != "" {
+		b = h.appendKVString(b, SpanIDKey, spanID)
+	}
+	return b
+}
@@ -0,0 +1,38 @@
+package slogjournal
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	type spanKey struct{}
+	w := &queueWriter{}
+	handler, err := NewHandlerWithWriter(w, &Options{Trace: func(ctx context.Context) (string, string) {
+		if span, ok := ctx.Value(spanKey{}).(string); ok {
+			return "4bf92f3577b34da6a3ce929d0e0e4736", span
+		}
+		return "", ""
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(handler).WithGroup("G")
+	logger.InfoContext(context.WithValue(context.Background(), spanKey{}, "00f067aa0ba902b7"), "traced")
+	logger.InfoContext(context.Background(), "untraced")
+	if len(w.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(w.entries))
+	}
+	for k, want := range map[string]string{
+		TraceIDKey: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanIDKey:  "00f067aa0ba902b7",
+	} {
+		if v, _ := w.entries[0].Get(k); string(v) != want {
+			t.Errorf("expected %s=%s, got %q", k, want, v)
+		}
+		if v, ok := w.entries[1].Get(k); ok {
+			t.Errorf("expected no %s, got %q", k, v)
+		}
+	}
+}
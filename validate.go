@@ -0,0 +1,179 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Limits enforced by journald on entries it receives.
+const (
+	// maxFieldNameLen is the maximum length of a field name.
+	maxFieldNameLen = 64
+	// maxEntryFields is the maximum number of fields of an entry,
+	// ENTRY_FIELD_COUNT_MAX in journald.
+	maxEntryFields = 1024
+	// maxFieldSize is the maximum size of a field, DATA_SIZE_MAX in journald.
+	maxFieldSize = 768 * 1024 * 1024
+	// maxEntrySize is the maximum size of an entry, ENTRY_SIZE_MAX in journald.
+	maxEntrySize = 770 * 1024 * 1024
+)
+
+// Violation describes a rule of journald that an entry violates.
+type Violation struct {
+	// Field is the name of the offending field, or empty if the violation
+	// concerns the entry as a whole.
+	Field string
+	// Reason describes the violation.
+	Reason string
+	// DropsEntry reports whether journald drops the entire entry, rather than
+	// just the field.
+	DropsEntry bool
+}
+
+func (v Violation) String() string {
+	if v.Field == "" {
+		return v.Reason
+	}
+	return fmt.Sprintf("field %q: %s", v.Field, v.Reason)
+}
+
+// ValidationError is returned for entries that violate the rules of journald.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid journal entry: ")
+	for i, v := range e.Violations {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(v.String())
+	}
+	return b.String()
+}
+
+// Validate checks the entry h would write for r against the rules of
+// journald: field name syntax, fields reserved for trusted use, size limits
+// and the number of fields. journald silently drops offending fields, or
+// even the entire entry. Validate returns a *ValidationError listing the
+// violations instead, or nil if r is valid.
+//
+// If Options.SchemaMode is SchemaReject, violations of the schema of the
+// entry are reported as well.
+func (h *Handler) Validate(ctx context.Context, r slog.Record) error {
+	var b []byte
+	if h.static != nil {
+		h.static.mu.Lock()
+		defer h.static.mu.Unlock()
+		b = h.static.b[:0]
+	}
+	b, err := h.encode(ctx, r, b)
+	if err != nil {
+		return err
+	}
+	if err := h.checkMessageID(r); err != nil {
+		return err
+	}
+	return validateEntry(b)
+}
+
+var invalidMessageID = Violation{Field: MessageIDKey, Reason: "value is not a 128-bit ID of 32 hexadecimal characters"}
+
+// checkMessageID returns a *ValidationError if an attribute of r sets an
+// invalid MESSAGE_ID, which the handler leaves out of the entry.
+func (h *Handler) checkMessageID(r slog.Record) error {
+	var err error
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == MessageIDKey && !ValidMessageID(a.Value.Resolve().String()) {
+			err = &ValidationError{[]Violation{invalidMessageID}}
+			return false
+		}
+		return true
+	})
+	return err
+}
+
+// validateEntry validates an entry in the native protocol format.
+func validateEntry(b []byte) error {
+	fields, err := ParseEntry(b)
+	if err != nil {
+		return err
+	}
+	return fields.validate(len(b))
+}
+
+// Validate checks e against the rules journald applies to the entries it
+// receives, like [Handler.Validate]. Fields starting with an underscore are
+// reported as reserved, so remove the trusted fields journald added before
+// validating entries read back from the journal.
+func (e Entry) Validate() error {
+	size := 0
+	for _, f := range e {
+		size += len(f.Name) + len(f.Value) + 2
+		if bytes.IndexByte(f.Value, '\n') != -1 {
+			size += 8
+		}
+	}
+	return e.validate(size)
+}
+
+// validate validates e, which is size bytes in the native protocol format.
+func (e Entry) validate(size int) error {
+	var violations []Violation
+	if len(e) > maxEntryFields {
+		violations = append(violations, Violation{
+			Reason:     fmt.Sprintf("entry has %d fields, more than %d", len(e), maxEntryFields),
+			DropsEntry: true,
+		})
+	}
+	if size > maxEntrySize {
+		violations = append(violations, Violation{
+			Reason:     fmt.Sprintf("entry is %d bytes, more than %d", size, maxEntrySize),
+			DropsEntry: true,
+		})
+	}
+	for _, f := range e {
+		if reason := checkFieldName(f.Name); reason != "" {
+			violations = append(violations, Violation{Field: f.Name, Reason: reason})
+		}
+		if f.Name == MessageIDKey && !ValidMessageID(string(f.Value)) {
+			violations = append(violations, invalidMessageID)
+		}
+		if len(f.Value) > maxFieldSize {
+			violations = append(violations, Violation{
+				Field:  f.Name,
+				Reason: fmt.Sprintf("value is %d bytes, more than %d", len(f.Value), maxFieldSize),
+			})
+		}
+	}
+	if len(violations) > 0 {
+		return &ValidationError{violations}
+	}
+	return nil
+}
+
+// checkFieldName returns why journald rejects name as a field name of an
+// entry sent by a client, or the empty string if it accepts it.
+func checkFieldName(name string) string {
+	switch {
+	case name == "":
+		return "name is empty"
+	case len(name) > maxFieldNameLen:
+		return fmt.Sprintf("name is longer than %d characters", maxFieldNameLen)
+	case name[0] == '_':
+		return "names starting with an underscore are reserved for trusted fields"
+	case name[0] >= '0' && name[0] <= '9':
+		return "name starts with a digit"
+	}
+	for _, c := range []byte(name) {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' {
+			return fmt.Sprintf("name contains %q, only A-Z, 0-9 and _ are allowed", c)
+		}
+	}
+	return ""
+}
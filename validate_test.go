@@ -0,0 +1,159 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	handler, err := NewHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		attrs  []slog.Attr
+		fields []string
+		entry  bool
+	}{
+		{"Valid", []slog.Attr{slog.String("KEY", "value")}, nil, false},
+		{"Lowercase", []slog.Attr{slog.String("key", "value")}, []string{"key"}, false},
+		{"Trusted", []slog.Attr{slog.String("_PID", "1")}, []string{"_PID"}, false},
+		{"Digit", []slog.Attr{slog.String("1KEY", "value")}, []string{"1KEY"}, false},
+		{"TooLong", []slog.Attr{slog.String(strings.Repeat("K", 65), "value")}, []string{strings.Repeat("K", 65)}, false},
+		{"Group", []slog.Attr{slog.Group("HTTP", slog.String("status-code", "200"))}, []string{"HTTP_status-code"}, false},
+		{"TooManyFields", manyAttrs(maxEntryFields), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+			record.AddAttrs(tt.attrs...)
+			err := handler.Validate(context.TODO(), record)
+			if tt.fields == nil && !tt.entry {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected *ValidationError, got %v", err)
+			}
+			var fields []string
+			for _, v := range verr.Violations {
+				if v.DropsEntry != tt.entry {
+					t.Errorf("expected DropsEntry=%v: %v", tt.entry, v)
+				}
+				if v.Field != "" {
+					fields = append(fields, v.Field)
+				}
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("expected violations for %v, got %v", tt.fields, verr)
+			}
+		})
+	}
+}
+
+func manyAttrs(n int) []slog.Attr {
+	attrs := make([]slog.Attr, n)
+	for i := range attrs {
+		attrs[i] = slog.Int("KEY_"+strconv.Itoa(i), i)
+	}
+	return attrs
+}
+
+func TestDryRun(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	if err := handler.Handle(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+	record.AddAttrs(slog.String("key", "value"))
+	if err := handler.Handle(context.TODO(), record); err == nil {
+		t.Fatal("expected error")
+	}
+	if buf.Len() != 0 {
+		t.Error("expected nothing to be written")
+	}
+}
+
+func TestEntryValidate(t *testing.T) {
+	if err := (Entry{{"MESSAGE", []byte("hi")}}).Validate(); err != nil {
+		t.Errorf("expected valid entry, got %v", err)
+	}
+	err := (Entry{{"MESSAGE", []byte("hi")}, {"_PID", []byte("1")}, {"lower", nil}}).Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Violations) != 2 {
+		t.Errorf("expected 2 violations, got %v", err)
+	}
+}
+
+func TestVerifyRoundTrip(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{VerifyRoundTrip: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	record.AddAttrs(slog.String("MULTILINE", "line 1\nline 2"), slog.String("EMPTY", ""))
+	if err := handler.Handle(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	record = slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	record.AddAttrs(slog.String("BAD\nKEY", "value"))
+	err = handler.Handle(context.TODO(), record)
+	var rerr *RoundTripError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("expected *RoundTripError, got %v", err)
+	}
+	if !bytes.Equal(rerr.Entry, buf.Bytes()) {
+		t.Error("expected the entry to be written anyway")
+	}
+
+	// A field name with '=' decodes as a different field.
+	record = slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	record.AddAttrs(slog.String("A=B", "v"))
+	if err := handler.Handle(context.TODO(), record); !errors.As(err, &rerr) || !slices.ContainsFunc(rerr.Expected, func(f Field) bool { return f.Name == "A=B" }) {
+		t.Errorf("expected *RoundTripError expecting A=B, got %v", err)
+	}
+
+	// Fields encoded ahead of Handle are verified too.
+	handler, err = NewHandler(&Options{
+		VerifyRoundTrip:  true,
+		LevelFields:      map[slog.Level][]slog.Attr{slog.LevelInfo: {slog.String("ONCALL", "team")}},
+		StaticBufferSize: 4096,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = io.Discard
+	record = slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	record.AddAttrs(slog.String("KEY", "value"))
+	h := handler.WithAttrs([]slog.Attr{slog.Int("N", 1)}).WithGroup("G").WithAttrs([]slog.Attr{slog.String("KEY", "value")})
+	if err := h.Handle(context.TODO(), record); err != nil {
+		t.Errorf("expected the entry to verify, got %v", err)
+	}
+	if err := handler.WithAttrs([]slog.Attr{slog.String("A=B", "v")}).Handle(context.TODO(), record); !errors.As(err, &rerr) {
+		t.Errorf("expected *RoundTripError, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package slogjournal
+
+import (
+	"slices"
+	"sync"
+)
+
+// minCachedValueSize is the length below which values are cheaper to encode
+// than to look up in the value cache.
+const minCachedValueSize = 256
+
+type valueCacheKey struct {
+	key, value string
+}
+
+// valueCache holds the encoded fields of recently logged large string values,
+// so that values repeated across entries, such as user agents or SQL
+// statements, are only encoded once. When full, the oldest field is evicted.
+type valueCache struct {
+	mu     sync.Mutex
+	fields map[valueCacheKey][]byte
+	order  []valueCacheKey
+	next   int
+}
+
+func newValueCache(size int) *valueCache {
+	return &valueCache{
+		fields: make(map[valueCacheKey][]byte, size),
+		order:  make([]valueCacheKey, 0, size),
+	}
+}
+
+// appendField appends the encoded field for key and value to b, encoding it
+// with h.appendKV if it is not cached yet.
+func (c *valueCache) appendField(h *Handler, b []byte, key, value string) []byte {
+	// The fields of cached entries are not collected.
+	if h.expected != nil {
+		return h.appendKV(b, key, []byte(value))
+	}
+	k := valueCacheKey{key, value}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if f, ok := c.fields[k]; ok {
+		return h.appendRaw(b, f)
+	}
+	n := len(b)
+	b = h.appendKV(b, key, []byte(value))
+	if len(b) == n {
+		return b
+	}
+	if len(c.order) < cap(c.order) {
+		c.order = append(c.order, k)
+	} else {
+		delete(c.fields, c.order[c.next])
+		c.order[c.next] = k
+		c.next = (c.next + 1) % len(c.order)
+	}
+	c.fields[k] = slices.Clone(b[n:])
+	return b
+}
@@ -0,0 +1,58 @@
+package slogjournal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValueCache(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler, err := NewHandler(&Options{ValueCacheSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.w = buf
+
+	values := []string{
+		strings.Repeat("a", minCachedValueSize),
+		strings.Repeat("b", minCachedValueSize) + "\n" + strings.Repeat("b", minCachedValueSize),
+		strings.Repeat("c", minCachedValueSize),
+	}
+	for range 2 {
+		for _, v := range values {
+			record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+			record.AddAttrs(slog.String("USER_AGENT", v))
+			_ = handler.Handle(context.TODO(), record)
+			kv, err := deserializeKeyValue(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kv["USER_AGENT"] != v {
+				t.Errorf("expected USER_AGENT=%.10s..., got %.10q...", v, kv["USER_AGENT"])
+			}
+		}
+	}
+	if n := len(handler.cache.fields); n != 2 {
+		t.Errorf("expected 2 cached fields, got %d", n)
+	}
+}
+
+func BenchmarkValueCache(b *testing.B) {
+	handler, err := NewHandler(&Options{ValueCacheSize: 16})
+	if err != nil {
+		b.Fatal(err)
+	}
+	handler.w = io.Discard
+	ua := strings.Repeat("Mozilla/5.0 (X11; Linux x86_64) ", 16)
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "Hello, World!", 0)
+	record.AddAttrs(slog.String("USER_AGENT", ua))
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = handler.Handle(context.TODO(), record)
+	}
+}
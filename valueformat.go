@@ -0,0 +1,50 @@
+package slogjournal
+
+import (
+	"strconv"
+	"time"
+)
+
+// DurationEncoding controls how a Handler writes time.Duration values.
+type DurationEncoding int
+
+const (
+	// DurationMicroseconds writes durations as integer microseconds, the
+	// resolution of the journal's own timestamps, e.g. 1500000.
+	DurationMicroseconds DurationEncoding = iota
+	// DurationNanoseconds writes durations as integer nanoseconds, e.g.
+	// 1500000000.
+	DurationNanoseconds
+	// DurationString writes durations as formatted by time.Duration.String,
+	// e.g. 1.5s.
+	DurationString
+)
+
+// appendDuration appends d as selected by Options.DurationEncoding. quote
+// quotes the string form for JSON.
+func (h *Handler) appendDuration(b []byte, d time.Duration, quote bool) []byte {
+	switch h.opts.DurationEncoding {
+	case DurationNanoseconds:
+		return strconv.AppendInt(b, int64(d), 10)
+	case DurationString:
+		if quote {
+			return appendJSONString(b, d.String())
+		}
+		return append(b, d.String()...)
+	default:
+		return strconv.AppendInt(b, d.Microseconds(), 10)
+	}
+}
+
+// appendTime appends t in the layout of Options.TimeLayout. quote quotes
+// the formatted time for JSON.
+func (h *Handler) appendTime(b []byte, t time.Time, quote bool) []byte {
+	if h.opts.TimeLayout == "" {
+		return strconv.AppendInt(b, t.UnixMicro(), 10)
+	}
+	if quote {
+		var buf [64]byte
+		return appendJSONString(b, string(t.AppendFormat(buf[:0], h.opts.TimeLayout)))
+	}
+	return t.AppendFormat(b, h.opts.TimeLayout)
+}
@@ -0,0 +1,47 @@
+package slogjournal
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestValueFormat(t *testing.T) {
+	tm := time.Date(2024, 5, 1, 12, 30, 0, 500, time.UTC)
+	for _, tt := range []struct {
+		name string
+		opts Options
+		want map[string]string
+	}{
+		{"default", Options{}, map[string]string{
+			"DURATION": "1500000", "TIME": "1714566600000000", "G": "",
+		}},
+		{"nanoseconds", Options{DurationEncoding: DurationNanoseconds}, map[string]string{
+			"DURATION": "1500000000",
+		}},
+		{"string", Options{DurationEncoding: DurationString, TimeLayout: time.RFC3339Nano}, map[string]string{
+			"DURATION": "1.5s", "TIME": "2024-05-01T12:30:00.0000005Z",
+		}},
+		{"json", Options{DurationEncoding: DurationString, TimeLayout: time.RFC3339Nano, GroupEncoding: GroupsJSON}, map[string]string{
+			"G": `{"D":"1.5s","T":"2024-05-01T12:30:00.0000005Z"}`,
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &queueWriter{}
+			handler, err := NewHandlerWithWriter(w, &tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slog.New(handler).Info("Hello, World!",
+				"DURATION", 1500*time.Millisecond,
+				"TIME", tm,
+				slog.Group("G", "D", 1500*time.Millisecond, "T", tm),
+			)
+			for k, want := range tt.want {
+				if v, _ := w.entries[0].Get(k); string(v) != want {
+					t.Errorf("expected %s=%s, got %q", k, want, v)
+				}
+			}
+		})
+	}
+}
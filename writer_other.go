@@ -0,0 +1,18 @@
+//go:build !unix && !windows
+
+package slogjournal
+
+import (
+	"io"
+	"os"
+)
+
+// newWriter returns the writer of platforms without a system log the
+// handler can write to, such as plan9 and WebAssembly. Entries go to the
+// console with Options.ConsoleFallback, or else to Options.Fallback.
+func (h *Handler) newWriter() (io.Writer, error) {
+	if h.opts.ConsoleFallback {
+		return newConsoleWriter(os.Stderr), nil
+	}
+	return nil, ErrNotSupported
+}
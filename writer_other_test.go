@@ -0,0 +1,27 @@
+//go:build !unix && !windows
+
+package slogjournal
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNotSupported(t *testing.T) {
+	if _, err := NewHandler(nil); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	handler, err := NewHandler(&Options{Fallback: slog.NewTextHandler(&buf, nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.New(handler).Info("Hello, World!")
+	if !strings.Contains(buf.String(), "Hello, World!") {
+		t.Errorf("expected the record to be handled by Fallback, got %q", buf.String())
+	}
+}